@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/da-semenov/gophermart/internal/accrualstub"
+)
+
+func main() {
+	accrualstub.Run()
+}
@@ -0,0 +1,24 @@
+package accrualstub
+
+import (
+	"fmt"
+	"github.com/caarlos0/env/v6"
+	"github.com/spf13/pflag"
+	"time"
+)
+
+type Config struct {
+	ServerAddress   string        `env:"RUN_ADDRESS" envDefault:":3000"`
+	ProcessingDelay time.Duration `env:"PROCESSING_DELAY" envDefault:"0s"`
+}
+
+func (config *Config) Init() error {
+	if err := env.Parse(config); err != nil {
+		fmt.Println("unable to load stub settings", err)
+		return err
+	}
+	pflag.StringVarP(&config.ServerAddress, "a", "a", config.ServerAddress, "Http-server address")
+	pflag.DurationVar(&config.ProcessingDelay, "processing-delay", config.ProcessingDelay, "How long a registered order stays in the PROCESSING state")
+	pflag.Parse()
+	return nil
+}
@@ -0,0 +1,33 @@
+package accrualstub
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Duration is a time.Duration read from JSON as a string like "1.5s" or as a number of milliseconds.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var ms int64
+	if err := json.Unmarshal(b, &ms); err == nil {
+		d.Duration = time.Duration(ms) * time.Millisecond
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
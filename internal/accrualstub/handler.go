@@ -0,0 +1,135 @@
+package accrualstub
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/handlers"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Handler struct {
+	store *Store
+	log   *infrastructure.Logger
+}
+
+func NewHandler(store *Store, log *infrastructure.Logger) *Handler {
+	var target Handler
+	target.store = store
+	target.log = log
+	return &target
+}
+
+func (h *Handler) Routes() http.Handler {
+	router := chi.NewRouter()
+	router.Get("/api/orders/{number}", h.GetOrder)
+	router.Post("/api/orders", h.AddOrder)
+	router.Post("/api/goods", h.AddReward)
+	router.Post("/api/stub/faults", h.AddFault)
+	router.Delete("/api/stub/faults", h.ClearFaults)
+	return router
+}
+
+func (h *Handler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	orderNum := chi.URLParam(r, "number")
+	if fault, ok := h.store.TakeFault(orderNum); ok {
+		h.log.Debug("Stub: GetOrder. Fault injected", zap.String("orderNum", orderNum), zap.Int("statusCode", fault.StatusCode))
+		if fault.Delay.Duration > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(fault.Delay.Duration):
+			}
+		}
+		if fault.StatusCode != 0 {
+			if fault.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(fault.RetryAfter))
+			}
+			w.WriteHeader(fault.StatusCode)
+			return
+		}
+	}
+	status, accrual, ok := h.store.GetOrder(orderNum)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	res := domain.Accrual{Order: orderNum, Status: status}
+	if accrual != nil {
+		res.Accrual = *accrual
+	}
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("Stub: GetOrder. Can't serialize response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writeResponse(w, http.StatusOK, responseBody)
+}
+
+func (h *Handler) AddOrder(w http.ResponseWriter, r *http.Request) {
+	var req OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, ErrBadRequest)
+		return
+	}
+	if err := h.store.AddOrder(req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.log.Debug("Stub: AddOrder. Order registered", zap.String("orderNum", req.Order))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) AddReward(w http.ResponseWriter, r *http.Request) {
+	var reward Reward
+	if err := json.NewDecoder(r.Body).Decode(&reward); err != nil {
+		h.writeError(w, ErrBadRequest)
+		return
+	}
+	if err := h.store.AddReward(reward); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) AddFault(w http.ResponseWriter, r *http.Request) {
+	var fault Fault
+	if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+		h.writeError(w, ErrBadRequest)
+		return
+	}
+	if err := h.store.AddFault(fault); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) ClearFaults(w http.ResponseWriter, r *http.Request) {
+	h.store.ClearFaults()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, ErrAlreadyExists):
+		statusCode = http.StatusConflict
+	}
+	h.writeResponse(w, statusCode, handlers.ErrMessage(err.Error()))
+}
+
+func (h *Handler) writeResponse(w http.ResponseWriter, statusCode int, body []byte) {
+	if err := handlers.WriteResponse(w, statusCode, body); err != nil {
+		h.log.Error("Stub: can't write response", zap.Error(err))
+	}
+}
@@ -0,0 +1,91 @@
+package accrualstub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure/client"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, url string, body string) int {
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestStub_AccrualClient(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+	server := httptest.NewServer(NewHandler(NewStore(0), logger).Routes())
+	defer server.Close()
+	accrualClient := client.NewAccrualClient(server.URL, logger)
+
+	assert.Equal(t, http.StatusOK, post(t, server.URL+"/api/goods", `{"match": "Bork", "reward": 10, "reward_type": "%"}`))
+	assert.Equal(t, http.StatusOK, post(t, server.URL+"/api/goods", `{"match": "Tefal", "reward": 15, "reward_type": "pt"}`))
+	assert.Equal(t, http.StatusConflict, post(t, server.URL+"/api/goods", `{"match": "Bork", "reward": 5, "reward_type": "pt"}`))
+	assert.Equal(t, http.StatusBadRequest, post(t, server.URL+"/api/goods", `{"match": "LG", "reward": 5, "reward_type": "x"}`))
+	assert.Equal(t, http.StatusAccepted, post(t, server.URL+"/api/orders",
+		`{"order": "1", "goods": [{"description": "Чайник Bork", "price": 7000}, {"description": "Сковорода Tefal", "price": 1000}]}`))
+	assert.Equal(t, http.StatusConflict, post(t, server.URL+"/api/orders", `{"order": "1", "status": "INVALID"}`))
+	assert.Equal(t, http.StatusAccepted, post(t, server.URL+"/api/orders", `{"order": "2", "status": "INVALID"}`))
+
+	accrual, err := accrualClient.GetAccrual(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Accrual{Order: "1", Status: StatusProcessed, Accrual: money.Rubles(715, 0),
+		Response: json.RawMessage(`{"order":"1","status":"PROCESSED","accrual":715}`)}, *accrual)
+	accrual, err = accrualClient.GetAccrual(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusInvalid, accrual.Status)
+	_, err = accrualClient.GetAccrual(ctx, "3")
+	assert.ErrorIs(t, err, domain.ErrOrderNotRegistered)
+
+	assert.Equal(t, http.StatusOK, post(t, server.URL+"/api/stub/faults", `{"order": "1", "status_code": 429, "retry_after": 7, "count": 1}`))
+	_, err = accrualClient.GetAccrual(ctx, "1")
+	var tooMany *domain.TooManyRequestError
+	if assert.ErrorAs(t, err, &tooMany) {
+		assert.Equal(t, 7*time.Second, tooMany.RetryAfter)
+	}
+	_, err = accrualClient.GetAccrual(ctx, "1")
+	assert.NoError(t, err, "fault must be consumed")
+
+	assert.Equal(t, http.StatusOK, post(t, server.URL+"/api/stub/faults", `{"status_code": 503, "delay": "10ms"}`))
+	started := time.Now()
+	_, err = accrualClient.GetAccrual(ctx, "2")
+	assert.ErrorIs(t, err, domain.ErrRemoteServiceError)
+	assert.GreaterOrEqual(t, time.Since(started), 10*time.Millisecond, "response must be delayed")
+
+	request, _ := http.NewRequest(http.MethodDelete, server.URL+"/api/stub/faults", nil)
+	resp, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	_, err = accrualClient.GetAccrual(ctx, "2")
+	assert.NoError(t, err, "faults must be cleared")
+}
+
+func TestStore_ProcessingDelay(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	target := NewStore(time.Minute)
+	target.now = func() time.Time { return now }
+	assert.NoError(t, target.AddOrder(OrderRequest{Order: "1"}))
+
+	status, accrual, ok := target.GetOrder("1")
+	assert.True(t, ok)
+	assert.Equal(t, StatusProcessing, status)
+	assert.Nil(t, accrual)
+
+	now = now.Add(time.Minute)
+	status, accrual, _ = target.GetOrder("1")
+	assert.Equal(t, StatusProcessed, status)
+	assert.Equal(t, money.Amount(0), *accrual)
+}
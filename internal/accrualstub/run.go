@@ -0,0 +1,23 @@
+package accrualstub
+
+import (
+	"go.uber.org/zap"
+	"log"
+	"net/http"
+)
+
+func Run() {
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+	defer logger.Sync()
+
+	config := &Config{}
+	if err = config.Init(); err != nil {
+		logger.Fatal("can't init configuration", zap.Error(err))
+	}
+	handler := NewHandler(NewStore(config.ProcessingDelay), logger)
+	logger.Info("starting accrual stub", zap.String("address", config.ServerAddress))
+	logger.Fatal("accrual stub stopped", zap.Error(http.ListenAndServe(config.ServerAddress, handler.Routes())))
+}
@@ -0,0 +1,204 @@
+package accrualstub
+
+import (
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RewardTypePercent = "%"
+	RewardTypePoints  = "pt"
+)
+
+const (
+	StatusRegistered = "REGISTERED"
+	StatusProcessing = "PROCESSING"
+	StatusInvalid    = "INVALID"
+	StatusProcessed  = "PROCESSED"
+)
+
+var ErrBadRequest = errors.New("bad request")
+var ErrAlreadyExists = errors.New("already exists")
+
+type Reward struct {
+	Match      string       `json:"match"`
+	Reward     money.Amount `json:"reward"`
+	RewardType string       `json:"reward_type"`
+}
+
+type Good struct {
+	Description string       `json:"description"`
+	Price       money.Amount `json:"price"`
+}
+
+type OrderRequest struct {
+	Order   string        `json:"order"`
+	Goods   []Good        `json:"goods"`
+	Status  string        `json:"status"`
+	Accrual *money.Amount `json:"accrual"`
+}
+
+// Fault makes the stub answer with StatusCode (when set) after Delay.
+// An empty Order matches every order, zero Count keeps the fault until cleared.
+type Fault struct {
+	Order      string   `json:"order"`
+	StatusCode int      `json:"status_code"`
+	RetryAfter int      `json:"retry_after"`
+	Delay      Duration `json:"delay"`
+	Count      int      `json:"count"`
+}
+
+type order struct {
+	num        string
+	status     string
+	accrual    money.Amount
+	readyAt    time.Time
+	hasAccrual bool
+}
+
+type Store struct {
+	mu              sync.Mutex
+	rewards         []Reward
+	orders          map[string]*order
+	faults          []*Fault
+	processingDelay time.Duration
+	now             func() time.Time
+}
+
+func NewStore(processingDelay time.Duration) *Store {
+	var target Store
+	target.orders = make(map[string]*order)
+	target.processingDelay = processingDelay
+	target.now = time.Now
+	return &target
+}
+
+func (s *Store) AddReward(reward Reward) error {
+	if reward.Match == "" || reward.Reward < 0 {
+		return ErrBadRequest
+	}
+	if reward.RewardType != RewardTypePercent && reward.RewardType != RewardTypePoints {
+		return ErrBadRequest
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.rewards {
+		if r.Match == reward.Match {
+			return ErrAlreadyExists
+		}
+	}
+	s.rewards = append(s.rewards, reward)
+	return nil
+}
+
+// AddOrder registers an order. The accrual is either given explicitly with the status or computed from the goods
+// by the registered reward rules.
+func (s *Store) AddOrder(req OrderRequest) error {
+	if req.Order == "" {
+		return ErrBadRequest
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[req.Order]; ok {
+		return ErrAlreadyExists
+	}
+	o := order{num: req.Order}
+	switch req.Status {
+	case "":
+		o.status = StatusProcessed
+		o.accrual = s.computeAccrual(req.Goods)
+		o.hasAccrual = true
+		o.readyAt = s.now().Add(s.processingDelay)
+	case StatusRegistered, StatusProcessing, StatusInvalid:
+		o.status = req.Status
+	case StatusProcessed:
+		o.status = req.Status
+		if req.Accrual != nil {
+			o.accrual = *req.Accrual
+			o.hasAccrual = true
+		}
+	default:
+		return ErrBadRequest
+	}
+	s.orders[req.Order] = &o
+	return nil
+}
+
+func (s *Store) computeAccrual(goods []Good) money.Amount {
+	var accrual money.Amount
+	for _, g := range goods {
+		for _, r := range s.rewards {
+			if !strings.Contains(g.Description, r.Match) {
+				continue
+			}
+			if r.RewardType == RewardTypePercent {
+				// reward is a percentage kept in hundredths, the share is rounded half up to kopecks
+				accrual += (g.Price*r.Reward + 5000) / 10000
+			} else {
+				accrual += r.Reward
+			}
+			break
+		}
+	}
+	return accrual
+}
+
+// GetOrder returns the order state as seen by the accrual system clients, ok is false for unknown orders.
+func (s *Store) GetOrder(num string) (status string, accrual *money.Amount, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[num]
+	if !ok {
+		return "", nil, false
+	}
+	if s.now().Before(o.readyAt) {
+		return StatusProcessing, nil, true
+	}
+	if o.hasAccrual {
+		a := o.accrual
+		accrual = &a
+	}
+	return o.status, accrual, true
+}
+
+func (s *Store) AddFault(fault Fault) error {
+	if fault.Count < 0 || fault.RetryAfter < 0 || fault.Delay.Duration < 0 {
+		return ErrBadRequest
+	}
+	if fault.StatusCode != 0 && (fault.StatusCode < 100 || fault.StatusCode > 599) {
+		return ErrBadRequest
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+	return nil
+}
+
+func (s *Store) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// TakeFault returns the first fault matching the order and consumes one of its repetitions.
+func (s *Store) TakeFault(num string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.faults {
+		if f.Order != "" && f.Order != num {
+			continue
+		}
+		res := *f
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return res, true
+	}
+	return Fault{}, false
+}
@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"expvar"
 	conf "github.com/da-semenov/gophermart/internal/app/config"
+	"github.com/da-semenov/gophermart/internal/app/domain"
 	"github.com/da-semenov/gophermart/internal/app/handlers"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure/client"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure/datastore"
@@ -12,6 +14,7 @@ import (
 	"go.uber.org/zap"
 	"log"
 	"net/http"
+	"os"
 )
 
 func RunApp() {
@@ -32,18 +35,18 @@ func RunApp() {
 		logger.Fatal("can't init postgres handler", zap.Error(err))
 	}
 
-	if config.ReInit {
-		err = repository.ClearDatabase(context.Background(), postgresHandlerTx)
-		if err != nil {
-			logger.Fatal("can't clear dbqueries structure", zap.Error(err))
-			return
-		}
-	}
-	err = repository.InitDatabase(context.Background(), postgresHandlerTx)
+	migrationRepository, err := repository.NewMigrationRepository(postgresHandlerTx, logger)
 	if err != nil {
-		logger.Fatal("can't init dbqueries structure", zap.Error(err))
+		logger.Fatal("can't init migration repository", zap.Error(err))
 		return
 	}
+	migrationService := service.NewMigrationService(migrationRepository, postgresHandlerTx, logger)
+	if len(config.Command) == 0 || config.Command[0] != "migrate" {
+		if _, err = migrationService.Up(context.Background()); err != nil {
+			logger.Fatal("can't migrate database", zap.Error(err))
+			return
+		}
+	}
 
 	userRepository, err := repository.NewUserRepository(postgresHandlerTx, logger)
 	if err != nil {
@@ -63,25 +66,184 @@ func RunApp() {
 		return
 	}
 
+	ledgerRepository, err := repository.NewLedgerRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init ledger repository", zap.Error(err))
+		return
+	}
+
+	withdrawalRepository, err := repository.NewWithdrawalRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init withdrawal repository", zap.Error(err))
+		return
+	}
+
+	clawbackRepository, err := repository.NewClawbackRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init clawback repository", zap.Error(err))
+		return
+	}
+
+	reconciliationRepository, err := repository.NewReconciliationRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init reconciliation repository", zap.Error(err))
+		return
+	}
+
+	creditLotRepository, err := repository.NewCreditLotRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init credit lot repository", zap.Error(err))
+		return
+	}
+
+	idempotencyRepository, err := repository.NewIdempotencyRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init idempotency repository", zap.Error(err))
+		return
+	}
+
+	eventRepository, err := repository.NewEventRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init event repository", zap.Error(err))
+		return
+	}
+
+	webhookRepository, err := repository.NewWebhookRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init webhook repository", zap.Error(err))
+		return
+	}
+
+	outboxRepository, err := repository.NewOutboxRepository(postgresHandlerTx, logger)
+	if err != nil {
+		logger.Fatal("can't init outbox repository", zap.Error(err))
+		return
+	}
+
 	authService := service.NewAuthService(userRepository, logger)
 	orderService := service.NewOrderService(orderRepository, logger, config.ValidateOrderNum)
-	balanceService := service.NewBalanceService(balanceRepository, logger)
+	ledgerService := service.NewLedgerService(ledgerRepository, logger)
+	expiryService := service.NewExpiryService(creditLotRepository, balanceRepository, ledgerService, postgresHandlerTx, logger,
+		service.ExpiryConfig{
+			Months:       config.PointsExpiryMonths,
+			NoticePeriod: config.ExpiryNoticePeriod,
+		})
+	eventService := service.NewEventService(eventRepository, logger, config.EventsRetention)
+	webhookService := service.NewWebhookService(webhookRepository, client.NewWebhookClient(logger), logger,
+		service.WebhookConfig{
+			MaxAttempts: config.WebhookMaxAttempts,
+			RetryBase:   config.WebhookRetryBase,
+			RetryMax:    config.WebhookRetryMax,
+		})
+	outboxService := service.NewOutboxService(outboxRepository, logger, config.OutboxRetention, config.OutboxMaxAttempts,
+		outboxSinks(config.OutboxSinks, eventService, webhookService, logger)...)
+	balanceService := service.NewBalanceService(balanceRepository, withdrawalRepository, ledgerService, expiryService, outboxService, logger)
+	withdrawalService := service.NewWithdrawalService(withdrawalRepository, ledgerService, logger)
+	transferService := service.NewTransferService(balanceRepository, userRepository, ledgerService, logger,
+		service.TransferLimits{
+			MaxAmount:  config.TransferMaxAmount,
+			DailyLimit: config.TransferDailyLimit,
+		})
+	clawbackService := service.NewClawbackService(clawbackRepository, orderRepository, balanceRepository, ledgerService, logger,
+		config.ClawbackPolicy)
+	reconciliationService := service.NewReconciliationService(reconciliationRepository, ledgerService, postgresHandlerTx, logger)
+	if len(config.Command) > 0 {
+		err = runCommand(context.Background(), config.Command, commands{migrator: migrationService, reconciler: reconciliationService}, os.Stdout)
+		if err != nil {
+			logger.Fatal("command failed", zap.Strings("command", config.Command), zap.Error(err))
+		}
+		return
+	}
+	idempotencyService := service.NewIdempotencyService(idempotencyRepository, logger, config.IdempotencyKeyTTL)
 	auth := handlers.NewAuth("secret")
 	authHandler := handlers.NewAuthHandler(authService, auth, logger)
 	orderHandler := handlers.NewOrderHandler(orderService, auth, logger)
 	balanceHandler := handlers.NewBalanceHandler(balanceService, auth, logger)
+	transferHandler := handlers.NewTransferHandler(transferService, auth, logger)
+	eventHandler := handlers.NewEventHandler(eventService, auth, logger)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, auth, logger)
 
-	accrualClient := client.NewAccrualClient(config.AccrualSystemAddress, logger)
-	gophermartClient := client.NewGophermartClient(config.ServerAddress, logger)
-	accrualService := service.NewAccrualService(orderRepository, balanceRepository, accrualClient, gophermartClient, logger, config.EnableAccrual)
-	accrualHandler := handlers.NewAccrualHandler(accrualService, logger)
+	accrualClient := client.NewCircuitBreaker(client.NewAccrualClient(config.AccrualSystemAddress, logger), logger,
+		client.CircuitBreakerConfig{
+			FailureThreshold: config.BreakerFailureThreshold,
+			CoolDown:         config.BreakerCoolDown,
+			HalfOpenCalls:    config.BreakerHalfOpenCalls,
+		})
+	accrualService := service.NewAccrualService(orderRepository, balanceRepository, ledgerService, clawbackService, outboxService, accrualClient, postgresHandlerTx, logger,
+		service.AccrualServiceConfig{
+			Enable:        config.EnableAccrual,
+			Workers:       config.AccrualWorkers,
+			QueueSize:     config.AccrualQueueSize,
+			PollInterval:  config.AccrualPollInterval,
+			RetryBase:     config.AccrualRetryBase,
+			RetryMax:      config.AccrualRetryMax,
+			MaxAttempts:   config.AccrualMaxAttempts,
+			MaxAge:        config.AccrualMaxAge,
+			InstanceID:    config.InstanceID,
+			LeaseDuration: config.AccrualLease,
+		})
+	adminHandler := handlers.NewAdminHandler(accrualService, logger)
+	withdrawalHandler := handlers.NewWithdrawalHandler(withdrawalService, logger)
+	clawbackHandler := handlers.NewClawbackHandler(clawbackService, logger)
+	outboxHandler := handlers.NewOutboxHandler(outboxService, logger)
 
 	router := chi.NewRouter()
-	publicRoutes(router, authHandler, accrualHandler, postgresHandlerTx, logger)
+	publicRoutes(router, authHandler, postgresHandlerTx, logger)
 	protectedOrderRoutes(router, auth.GetJWTAuth(), postgresHandlerTx, orderHandler, logger)
-	protectedBalanceRoutes(router, auth.GetJWTAuth(), postgresHandlerTx, balanceHandler, logger)
+	protectedEventRoutes(router, auth.GetJWTAuth(), eventHandler)
+	protectedWebhookRoutes(router, auth.GetJWTAuth(), postgresHandlerTx, webhookHandler, logger)
+	protectedBalanceRoutes(router, auth.GetJWTAuth(), postgresHandlerTx, balanceHandler, transferHandler, idempotencyService, logger)
+	if config.AdminToken != "" {
+		adminRoutes(router, config.AdminToken, postgresHandlerTx, adminHandler, withdrawalHandler, clawbackHandler, outboxHandler, logger)
+	}
+
+	if config.DebugAddress != "" {
+		go runDebugServer(config.DebugAddress, logger)
+	}
+	scheduler := NewScheduler(postgresHandlerTx, logger, config.SchedulerRetryInterval)
+	scheduler.Add("stuck-orders-sweep", config.StuckSweepInterval, accrualService.SweepStuckOrders)
+	scheduler.Add("idempotency-keys-cleanup", config.IdempotencyCleanupInterval, idempotencyService.DeleteExpiredKeys)
+	scheduler.Add("events-cleanup", config.EventsCleanupInterval, eventService.DeleteOldEvents)
+	scheduler.Add("webhook-delivery", config.WebhookDeliveryInterval, webhookService.DeliverPending)
+	scheduler.Add("outbox-relay", config.OutboxRelayInterval, outboxService.Relay)
+	scheduler.Add("outbox-cleanup", config.OutboxCleanupInterval, outboxService.DeletePublished)
+	if config.ReconcileInterval > 0 {
+		scheduler.Add("reconciliation", config.ReconcileInterval, func(ctx context.Context) error {
+			_, err := reconciliationService.Reconcile(ctx, config.ReconcileRepair)
+			return err
+		})
+	}
+	if expiryService.Enabled() {
+		scheduler.Add("points-expiration", config.ExpireInterval, expiryService.ExpirePoints)
+	}
 
-	go accrualService.StartProcessJob(1)
+	go accrualService.StartProcessJob(context.Background())
+	go scheduler.Start(context.Background())
 	log.Println("starting server on 8080...")
 	log.Fatal(http.ListenAndServe(config.ServerAddress, router))
 }
+
+// outboxSinks returns the configured sinks of domain events in the order they receive an event.
+func outboxSinks(names []string, events *service.EventService, webhooks *service.WebhookService, logger *zap.Logger) []service.OutboxSink {
+	var sinks []service.OutboxSink
+	for _, name := range names {
+		switch name {
+		case domain.OutboxSinkLog:
+			sinks = append(sinks, service.NewLogSink(logger))
+		case domain.OutboxSinkEvents:
+			sinks = append(sinks, service.NewEventStreamSink(events))
+		case domain.OutboxSinkWebhook:
+			sinks = append(sinks, service.NewWebhookSink(webhooks))
+		}
+	}
+	return sinks
+}
+
+func runDebugServer(address string, logger *zap.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	logger.Info("starting debug server", zap.String("address", address))
+	if err := http.ListenAndServe(address, mux); err != nil {
+		logger.Error("debug server stopped", zap.Error(err))
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/spf13/pflag"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: gophermart migrate up | down [steps] | status | reset"
+const reconcileUsage = "usage: gophermart reconcile [--repair]"
+
+type migrator interface {
+	Up(ctx context.Context) (int, error)
+	Down(ctx context.Context, steps int) (int, error)
+	Reset(ctx context.Context) error
+	Status(ctx context.Context) ([]domain.MigrationStatus, error)
+}
+
+type reconciler interface {
+	Reconcile(ctx context.Context, repair bool) (*domain.ReconciliationReport, error)
+}
+
+type commands struct {
+	migrator   migrator
+	reconciler reconciler
+}
+
+// runCommand executes a command given as positional arguments instead of starting the server.
+func runCommand(ctx context.Context, args []string, c commands, out io.Writer) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(ctx, args[1:], c.migrator, out)
+	case "reconcile":
+		return runReconcile(ctx, args[1:], c.reconciler, out)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func runMigrate(ctx context.Context, args []string, m migrator, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	switch args[0] {
+	case "up":
+		count, err := m.Up(ctx)
+		fmt.Fprintf(out, "%d migrations applied\n", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return errors.New(migrateUsage)
+			}
+		}
+		count, err := m.Down(ctx, steps)
+		fmt.Fprintf(out, "%d migrations reverted\n", count)
+		return err
+	case "status":
+		statusList, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statusList {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Unknown {
+				appliedAt += " (unknown to this build)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	case "reset":
+		if err := m.Reset(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "database reset")
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// runReconcile prints accounts whose cached totals differ from their postings, with --repair the accounts are
+// repaired with adjustment entries. The command fails if any mismatch remains, so it can be used in scripts.
+func runReconcile(ctx context.Context, args []string, r reconciler, out io.Writer) error {
+	flags := pflag.NewFlagSet("reconcile", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	repair := flags.Bool("repair", false, "Repair mismatched accounts with adjustment entries")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errors.New(reconcileUsage)
+	}
+	report, err := r.Reconcile(ctx, *repair)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tUSER\tBALANCE\tLEDGER BALANCE\tDEBIT\tLEDGER DEBIT\tCREDIT\tLEDGER CREDIT\tREPAIRED")
+	unresolved := len(report.UnbalancedEntries)
+	for _, a := range report.Accounts {
+		repaired := "no"
+		if a.Repaired {
+			repaired = "yes"
+			if a.AdjustmentEntryID != 0 {
+				repaired = fmt.Sprintf("entry %d", a.AdjustmentEntryID)
+			}
+		} else {
+			unresolved++
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			a.AccountID, a.UserID, a.Balance, a.LedgerBalance, a.Debit, a.LedgerDebit, a.Credit, a.LedgerCredit, repaired)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	for _, e := range report.UnbalancedEntries {
+		fmt.Fprintf(out, "unbalanced journal entry %d: debit %s, credit %s\n", e.EntryID, e.Debit, e.Credit)
+	}
+	fmt.Fprintf(out, "%d accounts out of sync, %d unbalanced journal entries\n", len(report.Accounts), len(report.UnbalancedEntries))
+	if unresolved > 0 {
+		return fmt.Errorf("%d mismatches unresolved", unresolved)
+	}
+	return nil
+}
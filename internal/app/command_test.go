@@ -0,0 +1,97 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type migratorStub struct {
+	downSteps int
+	reset     bool
+}
+
+func (m *migratorStub) Up(ctx context.Context) (int, error) {
+	return 2, nil
+}
+
+func (m *migratorStub) Down(ctx context.Context, steps int) (int, error) {
+	m.downSteps = steps
+	return steps, nil
+}
+
+func (m *migratorStub) Reset(ctx context.Context) error {
+	m.reset = true
+	return nil
+}
+
+func (m *migratorStub) Status(ctx context.Context) ([]domain.MigrationStatus, error) {
+	appliedAt := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []domain.MigrationStatus{
+		{Version: 1, Name: "initial_schema", AppliedAt: &appliedAt},
+		{Version: 2, Name: "order_accrual_attempts"},
+	}, nil
+}
+
+func TestRunCommand(t *testing.T) {
+	ctx := context.Background()
+	m := &migratorStub{}
+	c := commands{migrator: m}
+	var out bytes.Buffer
+
+	assert.NoError(t, runCommand(ctx, []string{"migrate", "up"}, c, &out))
+	assert.Contains(t, out.String(), "2 migrations applied")
+
+	assert.NoError(t, runCommand(ctx, []string{"migrate", "down"}, c, &out))
+	assert.Equal(t, 1, m.downSteps, "one migration must be reverted by default")
+	assert.NoError(t, runCommand(ctx, []string{"migrate", "down", "3"}, c, &out))
+	assert.Equal(t, 3, m.downSteps)
+	assert.Error(t, runCommand(ctx, []string{"migrate", "down", "-1"}, c, &out))
+
+	out.Reset()
+	assert.NoError(t, runCommand(ctx, []string{"migrate", "status"}, c, &out))
+	assert.Contains(t, out.String(), "2022-05-01T12:00:00Z")
+	assert.Contains(t, out.String(), "pending")
+
+	assert.NoError(t, runCommand(ctx, []string{"migrate", "reset"}, c, &out))
+	assert.True(t, m.reset)
+
+	assert.Error(t, runCommand(ctx, []string{"migrate"}, c, &out))
+	assert.Error(t, runCommand(ctx, []string{"serve"}, c, &out))
+}
+
+type reconcilerStub struct {
+	repair bool
+}
+
+func (r *reconcilerStub) Reconcile(ctx context.Context, repair bool) (*domain.ReconciliationReport, error) {
+	r.repair = repair
+	return &domain.ReconciliationReport{
+		Accounts: []domain.AccountMismatch{
+			{AccountID: 3, UserID: 7, Balance: money.Rubles(100, 0), LedgerBalance: money.Rubles(80, 50), Repaired: repair, AdjustmentEntryID: 42},
+		},
+	}, nil
+}
+
+func TestRunCommand_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	r := &reconcilerStub{}
+	c := commands{reconciler: r}
+	var out bytes.Buffer
+
+	assert.Error(t, runCommand(ctx, []string{"reconcile"}, c, &out), "unresolved mismatch must fail the command")
+	assert.False(t, r.repair)
+	assert.Contains(t, out.String(), "80.5")
+	assert.Contains(t, out.String(), "1 accounts out of sync")
+
+	out.Reset()
+	assert.NoError(t, runCommand(ctx, []string{"reconcile", "--repair"}, c, &out))
+	assert.True(t, r.repair)
+	assert.Contains(t, out.String(), "entry 42")
+
+	assert.Error(t, runCommand(ctx, []string{"reconcile", "--force"}, c, &out))
+}
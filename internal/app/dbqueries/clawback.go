@@ -0,0 +1,19 @@
+package dbqueries
+
+// GetOrderClawbackTotals returns the amount accrued to the user for the order and the amount already clawed back.
+const GetOrderClawbackTotals = "select coalesce((select sum(op.amount) from operations op\n" +
+	"join journal_entries je on je.id = op.entry_id join accounts acc on acc.id = op.account_id\n" +
+	"where op.order_id = ord.id and je.entry_type = 'ACCRUAL' and op.operation_type = 'CREDIT' and acc.code is null), 0),\n" +
+	"ord.clawback from orders ord where ord.id = $1"
+
+const AddOrderClawback = "UPDATE orders SET clawback = clawback + $2, updated_at = $3 WHERE id = $1;"
+
+const CreateDebt = "INSERT INTO debts (id, user_id, account_id, order_num, amount, remaining, created_at)\n" +
+	"VALUES(nextval('seq_debt'), $1, $2, $3, $4, $4, $5) returning id"
+
+const GetOutstandingDebtsForUpdate = "select id, user_id, account_id, order_num, amount, remaining, created_at from debts\n" +
+	"where user_id = $1 and remaining > 0 order by id for update"
+
+const UpdateDebtRemaining = "UPDATE debts SET remaining = $2 WHERE id = $1;"
+
+const GetDebtTotal = "select coalesce(sum(remaining), 0) from debts where user_id = $1 and remaining > 0"
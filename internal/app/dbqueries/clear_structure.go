@@ -4,5 +4,18 @@ const clearUsers = "drop table if exists users cascade;\n"
 const clearAccounts = "drop table if exists accounts cascade;\n"
 const clearOrders = "drop table if exists orders cascade;\n"
 const clearOperations = "drop table if exists operations cascade;\n"
+const clearIdempotencyKeys = "drop table if exists idempotency_keys cascade;\n"
+const clearJournalEntries = "drop table if exists journal_entries cascade;\n"
+const clearWithdrawals = "drop table if exists withdrawals cascade;\n"
+const clearDebts = "drop table if exists debts cascade;\n"
+const clearCreditLots = "drop table if exists credit_lots cascade;\n"
+const clearOrderStatusHistory = "drop table if exists order_status_history cascade;\n"
+const clearUserEvents = "drop table if exists user_events cascade;\n"
+const clearWebhooks = "drop table if exists webhooks cascade;\n"
+const clearWebhookDeliveries = "drop table if exists webhook_deliveries cascade;\n"
+const clearOutboxEvents = "drop table if exists outbox_events cascade;\n"
+const clearCreditLotConsumptions = "drop table if exists credit_lot_consumptions cascade;\n"
+const clearSchemaMigrations = "drop table if exists schema_migrations cascade;\n"
 
-const ClearDatabaseStructure = clearUsers + clearAccounts + clearOrders + clearOperations
+const ClearDatabaseStructure = clearUsers + clearAccounts + clearOrders + clearOperations + clearIdempotencyKeys + clearJournalEntries + clearWithdrawals + clearDebts + clearCreditLots +
+	clearOrderStatusHistory + clearUserEvents + clearWebhooks + clearWebhookDeliveries + clearOutboxEvents + clearCreditLotConsumptions + clearSchemaMigrations
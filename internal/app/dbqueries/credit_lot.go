@@ -0,0 +1,30 @@
+package dbqueries
+
+const creditLotColumns = "id, user_id, account_id, coalesce(entry_id, 0), coalesce(order_num, ''), amount, remaining, expirable, created_at"
+
+// CreateCreditLot creates a lot for points credited to a user account, credits of system accounts are skipped.
+const CreateCreditLot = "INSERT INTO credit_lots (id, user_id, account_id, entry_id, order_num, amount, remaining, expirable, created_at)\n" +
+	"select nextval('seq_credit_lot'), a.user_id, a.id, $2, $3, $4, $4, $5, $6 from accounts a where a.id = $1 and a.code is null;"
+
+// FindOpenCreditLots returns lots in the order debits consume them: expirable points first, oldest first.
+const FindOpenCreditLots = "select " + creditLotColumns + " from credit_lots where account_id = $1 and remaining > 0\n" +
+	"order by expirable desc, created_at, id"
+
+const UpdateCreditLotRemaining = "UPDATE credit_lots SET remaining = $2 WHERE id = $1;"
+
+const CreateCreditLotConsumption = "INSERT INTO credit_lot_consumptions (entry_id, lot_id, amount) VALUES ($1, $2, $3);"
+
+// FindConsumedCreditLots returns parts of lots consumed by the entry in the order they were consumed.
+const FindConsumedCreditLots = "select l.id, l.user_id, l.account_id, coalesce(l.entry_id, 0), coalesce(l.order_num, ''), c.amount, c.amount,\n" +
+	"l.expirable, l.created_at from credit_lot_consumptions c join credit_lots l on l.id = c.lot_id\n" +
+	"where c.entry_id = $1 order by l.expirable desc, l.created_at, l.id"
+
+const FindUsersWithExpiredLots = "select distinct user_id from credit_lots where expirable and remaining > 0 and created_at <= $1 limit $2"
+
+const GetExpiredLotsTotal = "select coalesce(sum(remaining), 0) from credit_lots\n" +
+	"where account_id = $1 and expirable and remaining > 0 and created_at <= $2"
+
+const CloseExpiredLots = "UPDATE credit_lots SET remaining = 0 WHERE account_id = $1 and expirable and remaining > 0 and created_at <= $2;"
+
+const FindExpirableLots = "select " + creditLotColumns + " from credit_lots\n" +
+	"where user_id = $1 and expirable and remaining > 0 and created_at <= $2 order by created_at, id"
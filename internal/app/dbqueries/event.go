@@ -0,0 +1,13 @@
+package dbqueries
+
+// CreateUserEvent returns no row if the event of the outbox event $5 is already saved.
+const CreateUserEvent = "insert into user_events (id, user_id, event_type, payload, created_at, outbox_event_id) \n" +
+	"values (nextval('seq_user_event'), $1, $2, $3::jsonb, $4, $5) on conflict (outbox_event_id) do nothing returning id"
+
+const FindUserEventsAfter = "select id, user_id, event_type, payload, created_at from user_events \n" +
+	"where user_id = $1 and id > $2 order by id limit $3"
+
+const GetLastUserEventID = "select COALESCE(max(id), 0) from user_events where user_id = $1"
+
+const DeleteUserEventsOlderThan = "with deleted as (delete from user_events where created_at < $1 returning 1)\n" +
+	"select count(*) from deleted"
@@ -0,0 +1,42 @@
+package dbqueries
+
+// userOperations selects postings of the user account with the change of the balance made by each of them.
+const userOperations = "select op.id, op.entry_id, je.entry_type, coalesce(op.order_num, '') as order_num,\n" +
+	"coalesce(je.description, '') as description, op.operation_type, op.amount, op.processed_at,\n" +
+	"case when op.operation_type = 'CREDIT' then op.amount else -op.amount end as delta\n" +
+	"from operations op join accounts acc on acc.id = op.account_id join journal_entries je on je.id = op.entry_id\n" +
+	"where acc.user_id = $1 and acc.code is null"
+
+// FindUserOperations returns operations newest first with the balance after each of them. Filters select the page,
+// the balance is seeded with the sum of operations before the oldest operation of the page and accumulated over all
+// operations between the oldest and the newest one. Empty filters are passed as nulls, an empty entry type list and
+// an empty operation type. $6, $7 is the position of the last operation of the previous page.
+const FindUserOperations = "with page as (select * from (" + userOperations + ") h\n" +
+	"where ($2::timestamptz is null or h.processed_at >= $2) and ($3::timestamptz is null or h.processed_at < $3)\n" +
+	"and (cardinality($4::varchar[]) = 0 or h.entry_type = any($4)) and ($5::varchar = '' or h.operation_type = $5)\n" +
+	"and ($6::timestamptz is null or (h.processed_at, h.id) < ($6, $7::numeric))\n" +
+	"order by h.processed_at desc, h.id desc limit $8),\n" +
+	"lo as (select processed_at, id from page order by processed_at, id limit 1),\n" +
+	"hi as (select processed_at, id from page order by processed_at desc, id desc limit 1),\n" +
+	"seed as (select coalesce(sum(b.delta), 0) as balance from (" + userOperations + ") b, lo\n" +
+	"where (b.processed_at, b.id) < (lo.processed_at, lo.id)),\n" +
+	"span as (select h.id, seed.balance + sum(h.delta) over (order by h.processed_at, h.id) as balance\n" +
+	"from (" + userOperations + ") h, lo, hi, seed\n" +
+	"where (h.processed_at, h.id) >= (lo.processed_at, lo.id) and (h.processed_at, h.id) <= (hi.processed_at, hi.id))\n" +
+	"select p.id, p.entry_id, p.entry_type, p.order_num, p.description, p.operation_type, p.amount, s.balance, p.processed_at\n" +
+	"from page p join span s on s.id = p.id\n" +
+	"order by p.processed_at desc, p.id desc"
+
+// FindUserOperationsForPeriod returns operations oldest first, $2 and $3 bound the period as in FindUserOperations.
+// The balance is seeded with the balance at the start of the period.
+const FindUserOperationsForPeriod = "select h.id, h.entry_id, h.entry_type, h.order_num, h.description, h.operation_type, h.amount,\n" +
+	"(select coalesce(sum(b.delta), 0) from (" + userOperations + ") b where $2::timestamptz is not null and b.processed_at < $2)\n" +
+	"+ sum(h.delta) over (order by h.processed_at, h.id) as balance, h.processed_at\n" +
+	"from (" + userOperations + ") h\n" +
+	"where ($2::timestamptz is null or h.processed_at >= $2) and ($3::timestamptz is null or h.processed_at < $3)\n" +
+	"order by h.processed_at, h.id"
+
+// GetUserBalanceAt returns the balance of the user account computed from operations before $2.
+const GetUserBalanceAt = "select coalesce(sum(case when op.operation_type = 'CREDIT' then op.amount else -op.amount end), 0)\n" +
+	"from operations op join accounts acc on acc.id = op.account_id\n" +
+	"where acc.user_id = $1 and acc.code is null and op.processed_at < $2"
@@ -0,0 +1,15 @@
+package dbqueries
+
+const LockIdempotencyKey = "select pg_advisory_xact_lock($1)"
+
+const GetIdempotencyKey = "select user_id, key, request_hash, status_code, response, created_at, expires_at \n" +
+	"from idempotency_keys where user_id = $1 and key = $2 and expires_at > $3"
+
+const SaveIdempotencyKey = "insert into idempotency_keys (user_id, key, request_hash, status_code, response, created_at, expires_at)\n" +
+	"values ($1, $2, $3, $4, $5, $6, $7)\n" +
+	"on conflict (user_id, key) do update set request_hash = excluded.request_hash, status_code = excluded.status_code,\n" +
+	"response = excluded.response, created_at = excluded.created_at, expires_at = excluded.expires_at\n" +
+	"where idempotency_keys.expires_at <= excluded.created_at"
+
+const DeleteExpiredIdempotencyKeys = "with deleted as (delete from idempotency_keys where expires_at <= $1 returning 1)\n" +
+	"select count(*) from deleted"
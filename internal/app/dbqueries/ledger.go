@@ -0,0 +1,14 @@
+package dbqueries
+
+// CreateJournalEntry returns no row if the entry key is taken. The conflict is not raised as an error, so
+// the transaction of the caller stays usable after a replayed entry.
+const CreateJournalEntry = "INSERT INTO journal_entries (id, entry_type, entry_key, order_num, description, created_at)\n" +
+	"VALUES(nextval('seq_journal_entry'), $1, $2, $3, $4, $5) on conflict (entry_key) do nothing returning id"
+
+const CreatePosting = "INSERT INTO operations (id, entry_id, account_id, order_id, order_num, operation_type, amount, processed_at)\n" +
+	"VALUES(nextval('seq_operation'), $1, $2, $3, $4, $5, $6, $7);"
+
+// ApplyPosting keeps the balance cache of user accounts, balances of system accounts are computed from postings.
+const ApplyPosting = "UPDATE accounts SET balance = balance + $2, debit = debit + $3, credit = credit + $4 WHERE id = $1 and code is null;"
+
+const GetSystemAccount = "select id, code from accounts where code = $1"
@@ -0,0 +1,7 @@
+package dbqueries
+
+const TryAdvisoryLock = "select pg_try_advisory_lock($1)"
+
+const AdvisoryUnlock = "select pg_advisory_unlock($1)"
+
+const CheckConnection = "select 1"
@@ -0,0 +1,252 @@
+package dbqueries
+
+// Migrations are applied in the order of their numbers and must never be changed once released,
+// a schema change is always a new migration.
+
+const Migration0001Up = "create table if not exists users (id numeric primary key, login varchar not null, pass varchar not null, active numeric not null default 1);\n" +
+	"create sequence if not exists seq_user increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by users.id;\n" +
+	"create unique index if not exists user_login_idx on users (login);\n" +
+	"create table if not exists accounts (id numeric primary key, user_id numeric not null, balance numeric not null default 0,\n" +
+	"debit numeric not null default 0, credit numeric not null default 0);\n" +
+	"create sequence if not exists seq_account increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by accounts.id;\n" +
+	"create unique index if not exists account_user_id_idx on accounts (user_id );\n" +
+	"create table if not exists orders (id numeric primary key, user_id numeric not null, num varchar not null,\n" +
+	"status varchar not null, upload_at timestamp with time zone not null, updated_at timestamp with time zone);\n" +
+	"create sequence if not exists seq_order increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by orders.id;\n" +
+	"create index if not exists order_user_id_idx on orders (user_id,status );\n" +
+	"create unique index if not exists order_num_idx on orders (num);\n" +
+	"create table if not exists operations (id numeric primary key, account_id numeric not null, order_id numeric not null,\n" +
+	"order_num varchar not null, operation_type varchar not null, amount numeric not null, processed_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_operation increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by operations.id;\n" +
+	"create index if not exists operation_account_id_idx on operations (account_id );\n" +
+	"create index if not exists operation_order_id_idx on operations (order_id );\n"
+
+const Migration0001Down = "drop table if exists operations cascade;\n" +
+	"drop table if exists orders cascade;\n" +
+	"drop table if exists accounts cascade;\n" +
+	"drop table if exists users cascade;\n"
+
+const Migration0002Up = "alter table orders add column if not exists attempt_count numeric not null default 0;\n" +
+	"alter table orders add column if not exists last_error varchar;\n" +
+	"alter table orders add column if not exists next_attempt_at timestamp with time zone not null default now();\n" +
+	"create index if not exists order_next_attempt_at_idx on orders (next_attempt_at);\n" +
+	"alter table orders add column if not exists lease_owner varchar;\n" +
+	"alter table orders add column if not exists lease_expires_at timestamp with time zone;\n"
+
+const Migration0002Down = "drop index if exists order_next_attempt_at_idx;\n" +
+	"alter table orders drop column if exists lease_expires_at;\n" +
+	"alter table orders drop column if exists lease_owner;\n" +
+	"alter table orders drop column if exists next_attempt_at;\n" +
+	"alter table orders drop column if exists last_error;\n" +
+	"alter table orders drop column if exists attempt_count;\n"
+
+const Migration0003Up = "create unique index if not exists operation_debit_order_num_idx on operations (account_id, order_num) where operation_type = 'DEBIT';\n" +
+	"create table if not exists idempotency_keys (user_id numeric not null, key varchar not null, request_hash varchar not null,\n" +
+	"status_code numeric not null, response bytea, created_at timestamp with time zone not null, expires_at timestamp with time zone not null,\n" +
+	"primary key (user_id, key));\n" +
+	"create index if not exists idempotency_keys_expires_at_idx on idempotency_keys (expires_at);\n"
+
+const Migration0003Down = "drop table if exists idempotency_keys cascade;\n" +
+	"drop index if exists operation_debit_order_num_idx;\n"
+
+// Migration0004Up turns operations into postings of balanced journal entries. Each existing operation gets its own
+// entry with a counter posting on a system account. Balances of system accounts are not cached in the accounts
+// table and are always computed from postings.
+const Migration0004Up = "alter table accounts alter column user_id drop not null;\n" +
+	"alter table accounts add column if not exists code varchar;\n" +
+	"create unique index if not exists account_code_idx on accounts (code);\n" +
+	"insert into accounts (id, code) select nextval('seq_account'), c.code\n" +
+	"from (values ('system:accruals'), ('system:withdrawals'), ('system:adjustments')) as c(code)\n" +
+	"where not exists (select 1 from accounts a where a.code = c.code);\n" +
+	"create table if not exists journal_entries (id numeric primary key, entry_type varchar not null, entry_key varchar,\n" +
+	"order_num varchar, description varchar, created_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_journal_entry increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by journal_entries.id;\n" +
+	"create unique index if not exists journal_entry_key_idx on journal_entries (entry_key);\n" +
+	"alter table operations add column if not exists entry_id numeric;\n" +
+	"insert into journal_entries (id, entry_type, entry_key, order_num, created_at)\n" +
+	"select op.id, case when op.operation_type = 'CREDIT' then 'ACCRUAL' else 'WITHDRAWAL' end,\n" +
+	"case when count(*) over (partition by op.account_id, op.order_num, op.operation_type) > 1 then null\n" +
+	"when op.operation_type = 'CREDIT' then 'ACCRUAL:' || op.order_num else 'WITHDRAWAL:' || acc.user_id || ':' || op.order_num end,\n" +
+	"op.order_num, op.processed_at\n" +
+	"from operations op join accounts acc on acc.id = op.account_id where op.entry_id is null;\n" +
+	"update operations set entry_id = id where entry_id is null;\n" +
+	"select setval('seq_journal_entry', greatest((select max(id) from journal_entries), 1));\n" +
+	"select setval('seq_operation', greatest((select max(id) from operations), 1));\n" +
+	"insert into operations (id, account_id, order_id, order_num, operation_type, amount, processed_at, entry_id)\n" +
+	"select nextval('seq_operation'), sys.id, op.order_id, op.order_num,\n" +
+	"case when op.operation_type = 'CREDIT' then 'DEBIT' else 'CREDIT' end, op.amount, op.processed_at, op.entry_id\n" +
+	"from operations op join accounts sys on sys.code = case when op.operation_type = 'CREDIT' then 'system:accruals' else 'system:withdrawals' end\n" +
+	"where not exists (select 1 from operations c where c.entry_id = op.entry_id and c.id <> op.id);\n" +
+	"alter table operations alter column entry_id set not null;\n" +
+	"create index if not exists operation_entry_id_idx on operations (entry_id);\n" +
+	"drop index if exists operation_debit_order_num_idx;\n"
+
+const Migration0004Down = "create unique index if not exists operation_debit_order_num_idx on operations (account_id, order_num) where operation_type = 'DEBIT';\n" +
+	"delete from operations where account_id in (select id from accounts where code is not null);\n" +
+	"drop index if exists operation_entry_id_idx;\n" +
+	"alter table operations drop column if exists entry_id;\n" +
+	"drop table if exists journal_entries cascade;\n" +
+	"delete from accounts where code is not null;\n" +
+	"drop index if exists account_code_idx;\n" +
+	"alter table accounts drop column if exists code;\n" +
+	"alter table accounts alter column user_id set not null;\n"
+
+// Migration0005Up makes withdrawals entities with a lifecycle. Amounts of pending withdrawals are held on the holds
+// system account, withdrawals posted before the migration are confirmed.
+const Migration0005Up = "insert into accounts (id, code) select nextval('seq_account'), 'system:holds'\n" +
+	"where not exists (select 1 from accounts a where a.code = 'system:holds');\n" +
+	"create table if not exists withdrawals (id numeric primary key, user_id numeric not null, account_id numeric not null,\n" +
+	"order_num varchar not null, amount numeric not null, status varchar not null, entry_id numeric not null, reason varchar,\n" +
+	"created_at timestamp with time zone not null, updated_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_withdrawal increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by withdrawals.id;\n" +
+	"create unique index if not exists withdrawal_user_order_idx on withdrawals (user_id, order_num);\n" +
+	"create index if not exists withdrawal_status_idx on withdrawals (status);\n" +
+	"insert into withdrawals (id, user_id, account_id, order_num, amount, status, entry_id, created_at, updated_at)\n" +
+	"select nextval('seq_withdrawal'), acc.user_id, acc.id, op.order_num, sum(op.amount), 'CONFIRMED', min(op.entry_id),\n" +
+	"min(op.processed_at), max(op.processed_at)\n" +
+	"from operations op join accounts acc on acc.id = op.account_id join journal_entries je on je.id = op.entry_id\n" +
+	"where je.entry_type = 'WITHDRAWAL' and op.operation_type = 'DEBIT' and acc.code is null\n" +
+	"group by acc.user_id, acc.id, op.order_num;\n"
+
+// Migration0005Down keeps postings on the holds system account, pending amounts stay held.
+const Migration0005Down = "drop table if exists withdrawals cascade;\n"
+
+// Migration0006Up tracks points clawed back from orders and debts of users who had already spent them.
+const Migration0006Up = "alter table orders add column if not exists clawback numeric not null default 0;\n" +
+	"create table if not exists debts (id numeric primary key, user_id numeric not null, account_id numeric not null,\n" +
+	"order_num varchar not null, amount numeric not null, remaining numeric not null, created_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_debt increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by debts.id;\n" +
+	"create index if not exists debt_outstanding_idx on debts (user_id) where remaining > 0;\n"
+
+const Migration0006Down = "drop table if exists debts cascade;\n" +
+	"alter table orders drop column if exists clawback;\n"
+
+// Migration0007Up tracks credited points by lots, so debits consume the oldest points first and accrued points can
+// expire. Lots are backfilled from accruals, the current balance is assigned to the newest of them, the part of the
+// balance not covered by accruals becomes a lot that never expires.
+const Migration0007Up = "insert into accounts (id, code) select nextval('seq_account'), 'system:expirations'\n" +
+	"where not exists (select 1 from accounts a where a.code = 'system:expirations');\n" +
+	"create table if not exists credit_lots (id numeric primary key, user_id numeric not null, account_id numeric not null,\n" +
+	"entry_id numeric, order_num varchar, amount numeric not null, remaining numeric not null, expirable boolean not null,\n" +
+	"created_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_credit_lot increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by credit_lots.id;\n" +
+	"create index if not exists credit_lot_open_idx on credit_lots (account_id, created_at) where remaining > 0;\n" +
+	"create index if not exists credit_lot_expirable_idx on credit_lots (created_at) where remaining > 0 and expirable;\n" +
+	"insert into credit_lots (id, user_id, account_id, entry_id, order_num, amount, remaining, expirable, created_at)\n" +
+	"select nextval('seq_credit_lot'), t.user_id, t.account_id, t.entry_id, t.order_num, t.amount,\n" +
+	"least(t.amount, t.balance - (t.cum - t.amount)), true, t.processed_at\n" +
+	"from (select acc.user_id, acc.id as account_id, op.entry_id, op.order_num, op.amount, acc.balance, op.processed_at,\n" +
+	"sum(op.amount) over (partition by acc.id order by op.processed_at desc, op.id desc) as cum\n" +
+	"from operations op join accounts acc on acc.id = op.account_id join journal_entries je on je.id = op.entry_id\n" +
+	"where acc.code is null and op.operation_type = 'CREDIT' and je.entry_type = 'ACCRUAL') t\n" +
+	"where t.balance - (t.cum - t.amount) > 0;\n" +
+	"insert into credit_lots (id, user_id, account_id, amount, remaining, expirable, created_at)\n" +
+	"select nextval('seq_credit_lot'), acc.user_id, acc.id, acc.balance - coalesce(l.remaining, 0), acc.balance - coalesce(l.remaining, 0), false, now()\n" +
+	"from accounts acc left join (select account_id, sum(remaining) as remaining from credit_lots group by account_id) l on l.account_id = acc.id\n" +
+	"where acc.code is null and acc.balance > coalesce(l.remaining, 0);\n"
+
+const Migration0007Down = "drop table if exists credit_lots cascade;\n"
+
+// Migration0008Up supports paging orders of a user by upload time.
+const Migration0008Up = "create index if not exists order_user_upload_at_idx on orders (user_id, upload_at, id);\n"
+
+const Migration0008Down = "drop index if exists order_user_upload_at_idx;\n"
+
+// Migration0009Up keeps status transitions of orders. Orders uploaded before the migration get the upload and
+// the current status, intermediate transitions are unknown.
+const Migration0009Up = "create table if not exists order_status_history (id numeric primary key, order_id numeric not null,\n" +
+	"status varchar not null, accrual_response jsonb, created_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_order_status increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by order_status_history.id;\n" +
+	"create index if not exists order_status_history_order_idx on order_status_history (order_id, created_at);\n" +
+	"insert into order_status_history (id, order_id, status, created_at)\n" +
+	"select nextval('seq_order_status'), ord.id, 'NEW', ord.upload_at from orders ord\n" +
+	"where not exists (select 1 from order_status_history h where h.order_id = ord.id);\n" +
+	"insert into order_status_history (id, order_id, status, created_at)\n" +
+	"select nextval('seq_order_status'), ord.id, ord.status, ord.updated_at from orders ord\n" +
+	"where ord.status not in ('NEW', 'STUCK')\n" +
+	"and not exists (select 1 from order_status_history h where h.order_id = ord.id and h.status = ord.status);\n"
+
+const Migration0009Down = "drop table if exists order_status_history cascade;\n"
+
+// Migration0010Up keeps events shown to users, so a client can resume a stream from the last event it received.
+const Migration0010Up = "create table if not exists user_events (id numeric primary key, user_id numeric not null,\n" +
+	"event_type varchar not null, payload jsonb not null, created_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_user_event increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by user_events.id;\n" +
+	"create index if not exists user_event_user_idx on user_events (user_id, id);\n" +
+	"create index if not exists user_event_created_at_idx on user_events (created_at);\n"
+
+const Migration0010Down = "drop table if exists user_events cascade;\n"
+
+// Migration0011Up adds webhooks of users and the log of their deliveries.
+const Migration0011Up = "create table if not exists webhooks (id numeric primary key, user_id numeric not null, url varchar not null,\n" +
+	"secret varchar not null, event_types varchar[] not null, created_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_webhook increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by webhooks.id;\n" +
+	"create index if not exists webhook_user_idx on webhooks (user_id);\n" +
+	"create table if not exists webhook_deliveries (id numeric primary key, webhook_id numeric not null, user_id numeric not null,\n" +
+	"event_id varchar not null, event_type varchar not null, payload jsonb not null, status varchar not null,\n" +
+	"attempt_count numeric not null default 0, next_attempt_at timestamp with time zone not null, response_code numeric,\n" +
+	"last_error varchar, created_at timestamp with time zone not null, updated_at timestamp with time zone not null);\n" +
+	"create sequence if not exists seq_webhook_delivery increment by 1 no minvalue no maxvalue start with 1 cache 10 owned by webhook_deliveries.id;\n" +
+	"create index if not exists webhook_delivery_webhook_idx on webhook_deliveries (webhook_id, id);\n" +
+	"create index if not exists webhook_delivery_pending_idx on webhook_deliveries (next_attempt_at) where status = 'PENDING';\n"
+
+const Migration0011Down = "drop table if exists webhook_deliveries cascade;\n" +
+	"drop table if exists webhooks cascade;\n"
+
+// Migration0012Up adds the outbox of domain events. The sequence is not cached, so events of an aggregate written
+// under its row lock get ascending ids even from different sessions.
+const Migration0012Up = "create table if not exists outbox_events (id numeric primary key, aggregate_type varchar not null,\n" +
+	"aggregate_id varchar not null, user_id numeric not null, event_type varchar not null, payload jsonb not null,\n" +
+	"created_at timestamp with time zone not null, published_at timestamp with time zone,\n" +
+	"attempt_count numeric not null default 0, last_error varchar);\n" +
+	"create sequence if not exists seq_outbox_event increment by 1 no minvalue no maxvalue start with 1 cache 1 owned by outbox_events.id;\n" +
+	"create index if not exists outbox_event_unpublished_idx on outbox_events (id) where published_at is null;\n" +
+	"create index if not exists outbox_event_published_at_idx on outbox_events (published_at) where published_at is not null;\n"
+
+const Migration0012Down = "drop table if exists outbox_events cascade;\n"
+
+// Migration0013Up records lots consumed by debits, so points moved between users or returned by a withdrawal
+// keep the expiration of the lots they were taken from.
+const Migration0013Up = "create table if not exists credit_lot_consumptions (entry_id numeric not null, lot_id numeric not null,\n" +
+	"amount numeric not null);\n" +
+	"create index if not exists credit_lot_consumption_entry_idx on credit_lot_consumptions (entry_id);\n"
+
+const Migration0013Down = "drop table if exists credit_lot_consumptions cascade;\n"
+
+// Migration0014Up supports reading the history of an account by ranges of its operations.
+const Migration0014Up = "create index if not exists operation_account_processed_at_idx on operations (account_id, processed_at, id);\n"
+
+const Migration0014Down = "drop index if exists operation_account_processed_at_idx;\n"
+
+// Migration0015Up records the stuck state of orders which moved to it without a status history record.
+const Migration0015Up = "insert into order_status_history (id, order_id, status, created_at)\n" +
+	"select nextval('seq_order_status'), ord.id, ord.status, ord.updated_at from orders ord\n" +
+	"where ord.status = 'STUCK'\n" +
+	"and not exists (select 1 from order_status_history h where h.order_id = ord.id and h.status = ord.status);\n"
+
+// Migration0015Down keeps the records, they describe transitions that really happened.
+const Migration0015Down = ""
+
+// Migration0016Up links user events and webhook deliveries to the outbox events they were published from,
+// so an outbox event handed to the sinks again doesn't duplicate them. Redeliveries have no outbox event.
+const Migration0016Up = "alter table user_events add column if not exists outbox_event_id numeric;\n" +
+	"create unique index if not exists user_event_outbox_event_idx on user_events (outbox_event_id);\n" +
+	"alter table webhook_deliveries add column if not exists outbox_event_id numeric;\n" +
+	"create unique index if not exists webhook_delivery_outbox_event_idx on webhook_deliveries (webhook_id, outbox_event_id);\n"
+
+const Migration0016Down = "drop index if exists webhook_delivery_outbox_event_idx;\n" +
+	"alter table webhook_deliveries drop column if exists outbox_event_id;\n" +
+	"drop index if exists user_event_outbox_event_idx;\n" +
+	"alter table user_events drop column if exists outbox_event_id;\n"
+
+// Migration0017Up keeps the state of outbox events, the relay gives up on an event after the limit of attempts
+// and marks it failed, so it doesn't hold the following events of its aggregate.
+const Migration0017Up = "alter table outbox_events add column if not exists status varchar not null default 'PENDING';\n" +
+	"update outbox_events set status = 'PUBLISHED' where published_at is not null;\n" +
+	"drop index if exists outbox_event_unpublished_idx;\n" +
+	"create index if not exists outbox_event_status_idx on outbox_events (status, id) where status <> 'PUBLISHED';\n"
+
+// Migration0017Down returns failed events to the unpublished ones.
+const Migration0017Down = "drop index if exists outbox_event_status_idx;\n" +
+	"alter table outbox_events drop column if exists status;\n" +
+	"create index if not exists outbox_event_unpublished_idx on outbox_events (id) where published_at is null;\n"
@@ -1,14 +1,91 @@
 package dbqueries
 
-const CreateOrder = "INSERT INTO orders (id, user_id, num, status, upload_at, updated_at) VALUES(nextval('seq_order'),  $1, $2, $3, $4, $5);"
+// CreateOrder saves the order and the first record of its status history.
+const CreateOrder = "with ord as (INSERT INTO orders (id, user_id, num, status, upload_at, updated_at) VALUES(nextval('seq_order'),  $1, $2, $3, $4, $5) \n" +
+	"returning id, status, upload_at) \n" +
+	"insert into order_status_history (id, order_id, status, created_at) select nextval('seq_order_status'), id, status, upload_at from ord;"
 
-const UpdateOrderStatus = "UPDATE orders SET status=$2, updated_at=$3 where id=$1 and status!=$2;"
+// CreateOrdersIfAbsent is CreateOrder for arrays of order fields skipping numbers that are already uploaded,
+// it returns numbers of the created orders.
+const CreateOrdersIfAbsent = "with ord as (INSERT INTO orders (id, user_id, num, status, upload_at, updated_at) \n" +
+	"select nextval('seq_order'), o.user_id, o.num, o.status, o.upload_at, o.updated_at \n" +
+	"from unnest($1::bigint[], $2::varchar[], $3::varchar[], $4::timestamptz[], $5::timestamptz[]) as o(user_id, num, status, upload_at, updated_at) \n" +
+	"on conflict (num) do nothing returning id, num, status, upload_at), \n" +
+	"hist as (insert into order_status_history (id, order_id, status, created_at) \n" +
+	"select nextval('seq_order_status'), id, status, upload_at from ord) \n" +
+	"select num from ord"
 
-const FindOrdersByUser = "select ord.id, ord.num, user_id, ord.status,  COALESCE(op.amount,0) as accrual, ord.upload_at, ord.updated_at \n" +
-	"from orders ord left join operations op on ord.id = op.order_id and op.operation_type = 'CREDIT' where ord.user_id = $1 order by upload_at asc"
+// UpdateOrderStatus records the transition in the status history with the accrual system response $4,
+// nothing is recorded if the status didn't change.
+const UpdateOrderStatus = "with upd as (UPDATE orders SET status=$2, updated_at=$3 where id=$1 and status!=$2 returning id, status, updated_at) \n" +
+	"insert into order_status_history (id, order_id, status, accrual_response, created_at) \n" +
+	"select nextval('seq_order_status'), id, status, $4::jsonb, updated_at from upd;"
+
+const GetOrderStatusHistory = "select status, accrual_response, created_at from order_status_history where order_id=$1 order by created_at, id"
+
+// ordersByUser filters orders of the user $1 by statuses $2 (all if empty) and the upload period from $3 to $4,
+// nulls don't limit the period.
+const ordersByUser = "from orders ord where ord.user_id = $1 and (cardinality($2::varchar[]) = 0 or ord.status = any($2)) \n" +
+	"and ($3::timestamptz is null or ord.upload_at >= $3) and ($4::timestamptz is null or ord.upload_at < $4)"
+
+// orderPage selects accruals only for orders of the page.
+const orderPage = "select p.id, p.num, p.user_id, p.status, \n" +
+	"COALESCE((select sum(op.amount) from operations op join accounts acc on acc.id = op.account_id \n" +
+	"where op.order_id = p.id and acc.user_id = p.user_id and acc.code is null and op.operation_type = 'CREDIT'), 0) as accrual, \n" +
+	"p.clawback, p.upload_at, p.updated_at \n"
+
+// FindOrdersByUser returns a page of $7 orders in the upload order after the order with upload_at $5 and id $6.
+const FindOrdersByUser = orderPage + "from (select ord.* " + ordersByUser + " \n" +
+	"and ($5::timestamptz is null or (ord.upload_at, ord.id) > ($5, $6::numeric)) order by ord.upload_at, ord.id limit $7) p \n" +
+	"order by p.upload_at, p.id"
+
+// FindOrdersByUserDesc is FindOrdersByUser for the reverse upload order.
+const FindOrdersByUserDesc = orderPage + "from (select ord.* " + ordersByUser + " \n" +
+	"and ($5::timestamptz is null or (ord.upload_at, ord.id) < ($5, $6::numeric)) order by ord.upload_at desc, ord.id desc limit $7) p \n" +
+	"order by p.upload_at desc, p.id desc"
+
+const CountOrdersByUser = "select count(*) " + ordersByUser
+
+const GetUserOrderByNum = orderPage + "from orders p where p.user_id = $1 and p.num = $2"
 
 const GetOrderByID = "select id, user_id, num, status, upload_at, updated_at from orders where id=$1;"
 const GetOrderByNum = "select id, user_id, num, status, upload_at, updated_at from orders where num=$1;"
+const FindOrdersByNums = "select id, user_id, num, status, upload_at, updated_at from orders where num = any($1)"
+
+const GetOrderByNumForUpdate = "select id, user_id, num, status, upload_at, updated_at, attempt_count, COALESCE(last_error, ''), \n" +
+	"COALESCE(lease_owner, '') from orders where num = $1 for update"
+
+// LeaseOrdersByStatuses leases due orders to the instance $1 until $2. Rows locked by other instances are skipped,
+// orders with an expired lease are leased again.
+const LeaseOrdersByStatuses = "UPDATE orders SET lease_owner=$1, lease_expires_at=$2 where id in (\n" +
+	"select id from orders where status in ($3, $4, $5) and next_attempt_at <= $6 \n" +
+	"and (lease_expires_at is null or lease_expires_at <= $6) \n" +
+	"order by next_attempt_at limit $7 for update skip locked) \n" +
+	"returning id, user_id, num, status, upload_at, updated_at, attempt_count, next_attempt_at, lease_owner"
+
+// UpdateOrderStuck moves the order to the stuck state and records the transition in the status history.
+const UpdateOrderStuck = "with upd as (UPDATE orders SET status=$2, attempt_count=$3, last_error=$4, updated_at=$5, lease_owner=null, lease_expires_at=null \n" +
+	"where id=$1 and status in ($6, $7, $8) and (lease_owner is null or lease_owner=$9) returning id, status, updated_at) \n" +
+	"insert into order_status_history (id, order_id, status, created_at) select nextval('seq_order_status'), id, status, updated_at from upd;"
+
+// MarkStuckOrdersOlderThan moves not leased pending orders uploaded before $7 to the stuck state, records
+// the transitions in the status history and returns the count of the orders.
+const MarkStuckOrdersOlderThan = "with upd as (UPDATE orders SET status=$1, last_error=$2, updated_at=$3, lease_owner=null, lease_expires_at=null \n" +
+	"where status in ($4, $5, $6) and upload_at < $7 and (lease_expires_at is null or lease_expires_at <= $3) returning id, status, updated_at), \n" +
+	"hist as (insert into order_status_history (id, order_id, status, created_at) \n" +
+	"select nextval('seq_order_status'), id, status, updated_at from upd) \n" +
+	"select count(*) from upd"
+
+const FindStuckOrders = "select id, user_id, num, status, upload_at, updated_at, attempt_count, COALESCE(last_error, '') from orders \n" +
+	"where status = $1 order by updated_at"
+
+// ReviveOrder returns the stuck order to polling and records the transition in the status history.
+const ReviveOrder = "with upd as (UPDATE orders SET status=$2, attempt_count=0, next_attempt_at=$3, updated_at=$3, lease_owner=null, lease_expires_at=null \n" +
+	"where num=$1 and status=$4 \n" +
+	"returning id, user_id, num, status, upload_at, updated_at), \n" +
+	"hist as (insert into order_status_history (id, order_id, status, created_at) \n" +
+	"select nextval('seq_order_status'), id, status, updated_at from upd) \n" +
+	"select id, user_id, num, status, upload_at, updated_at from upd"
 
-const GetOrderByNumForUpdate = "select id, user_id, num, status, upload_at, updated_at from orders where num = $1 for update"
-const FindOrderByStatuses = "select id, user_id, num, status, upload_at, updated_at from orders where status in ($1, $2, $3, $4, $5) limit $6"
+const UpdateOrderAttempt = "UPDATE orders SET attempt_count=$2, last_error=$3, next_attempt_at=$4, lease_owner=null, lease_expires_at=null \n" +
+	"where id=$1 and (lease_owner is null or lease_owner=$5);"
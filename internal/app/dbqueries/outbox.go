@@ -0,0 +1,25 @@
+package dbqueries
+
+const CreateOutboxEvent = "insert into outbox_events (id, aggregate_type, aggregate_id, user_id, event_type, payload, created_at) \n" +
+	"values (nextval('seq_outbox_event'), $1, $2, $3, $4, $5::jsonb, $6) returning id"
+
+const outboxEventFields = "id, aggregate_type, aggregate_id, user_id, event_type, payload, created_at, attempt_count, status, \n" +
+	"COALESCE(last_error, '')"
+
+// FindUnpublishedOutboxEvents returns events in the status $1 following the event $2.
+const FindUnpublishedOutboxEvents = "select " + outboxEventFields + " \n" +
+	"from outbox_events where status = $1 and id > $2 order by id limit $3"
+
+// FindOutboxEventsByStatus returns the latest $2 events in the status $1.
+const FindOutboxEventsByStatus = "select " + outboxEventFields + " \n" +
+	"from outbox_events where status = $1 order by id desc limit $2"
+
+const MarkOutboxEventsPublished = "update outbox_events set status = $2, published_at = $3 where id = any($1::bigint[])"
+
+const RecordOutboxEventFailure = "update outbox_events set status = $2, attempt_count = attempt_count + 1, last_error = $3 where id = $1"
+
+// RetryOutboxEvent moves the event $1 from the status $3 to the status $2 with no attempts.
+const RetryOutboxEvent = "update outbox_events set status = $2, attempt_count = 0 where id = $1 and status = $3 returning id"
+
+const DeleteOutboxEventsPublishedBefore = "with deleted as (delete from outbox_events where published_at < $1 returning 1)\n" +
+	"select count(*) from deleted"
@@ -0,0 +1,25 @@
+package dbqueries
+
+const accountTotals = "select a.id, a.user_id, a.balance, a.debit, a.credit, coalesce(o.debit, 0), coalesce(o.credit, 0)\n" +
+	"from accounts a left join (select account_id,\n" +
+	"sum(case when operation_type = 'DEBIT' then amount else 0 end) as debit,\n" +
+	"sum(case when operation_type = 'CREDIT' then amount else 0 end) as credit\n" +
+	"from operations group by account_id) o on o.account_id = a.id\n"
+
+// GetAccountDrifts returns user accounts whose cached totals differ from the totals of their postings.
+const GetAccountDrifts = accountTotals +
+	"where a.code is null and (a.debit <> coalesce(o.debit, 0) or a.credit <> coalesce(o.credit, 0)\n" +
+	"or a.balance <> coalesce(o.credit, 0) - coalesce(o.debit, 0))\n" +
+	"order by a.id"
+
+// LockAccountTotals locks the account row, postings of a locked account can't change.
+const LockAccountTotals = accountTotals + "where a.id = $1 and a.code is null for update of a"
+
+const GetUnbalancedEntries = "select entry_id,\n" +
+	"sum(case when operation_type = 'DEBIT' then amount else 0 end),\n" +
+	"sum(case when operation_type = 'CREDIT' then amount else 0 end)\n" +
+	"from operations group by entry_id\n" +
+	"having sum(case when operation_type = 'DEBIT' then amount else -amount end) <> 0\n" +
+	"order by entry_id"
+
+const SyncAccount = "UPDATE accounts SET balance = $2, debit = $3, credit = $4 WHERE id = $1 and code is null;"
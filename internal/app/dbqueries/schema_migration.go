@@ -0,0 +1,12 @@
+package dbqueries
+
+const CreateSchemaMigrations = "create table if not exists schema_migrations (version numeric primary key, name varchar not null,\n" +
+	"applied_at timestamp with time zone not null)"
+
+const LockSchemaMigrations = "select pg_advisory_xact_lock($1)"
+
+const GetAppliedMigrations = "select version, name, applied_at from schema_migrations order by version"
+
+const InsertSchemaMigration = "insert into schema_migrations (version, name, applied_at) values ($1, $2, $3)"
+
+const DeleteSchemaMigration = "delete from schema_migrations where version = $1"
@@ -0,0 +1,5 @@
+package dbqueries
+
+// GetTransferredTotal returns the amount the account sent by transfers since $2.
+const GetTransferredTotal = "select coalesce(sum(op.amount), 0) from operations op join journal_entries je on je.id = op.entry_id\n" +
+	"where op.account_id = $1 and op.operation_type = 'DEBIT' and je.entry_type = 'TRANSFER' and op.processed_at >= $2"
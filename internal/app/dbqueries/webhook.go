@@ -0,0 +1,43 @@
+package dbqueries
+
+const CreateWebhook = "insert into webhooks (id, user_id, url, secret, event_types, created_at) \n" +
+	"values (nextval('seq_webhook'), $1, $2, $3, $4, $5) returning id"
+
+const FindWebhooksByUser = "select id, user_id, url, secret, event_types, created_at from webhooks where user_id = $1 order by id"
+
+const GetWebhook = "select id, user_id, url, secret, event_types, created_at from webhooks where id = $1 and user_id = $2"
+
+// DeleteWebhook deletes the webhook with its delivery log and returns the number of deleted webhooks.
+const DeleteWebhook = "with deleted as (delete from webhooks where id = $1 and user_id = $2 returning id), \n" +
+	"deliveries as (delete from webhook_deliveries where webhook_id in (select id from deleted)) \n" +
+	"select count(*) from deleted"
+
+// EnqueueWebhookDeliveries creates a delivery of the event for every webhook of the user $1 subscribed to its type,
+// webhooks without event types get all events. Webhooks which already have a delivery of the outbox event $7 are skipped.
+const EnqueueWebhookDeliveries = "with created as (insert into webhook_deliveries \n" +
+	"(id, webhook_id, user_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at, outbox_event_id) \n" +
+	"select nextval('seq_webhook_delivery'), w.id, w.user_id, $2, $3, $4::jsonb, $5, $6, $6, $6, $7 from webhooks w \n" +
+	"where w.user_id = $1 and (cardinality(w.event_types) = 0 or $3 = any(w.event_types)) \n" +
+	"on conflict (webhook_id, outbox_event_id) do nothing returning 1) \n" +
+	"select count(*) from created"
+
+const CreateWebhookDelivery = "insert into webhook_deliveries \n" +
+	"(id, webhook_id, user_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at) \n" +
+	"values (nextval('seq_webhook_delivery'), $1, $2, $3, $4, $5::jsonb, $6, $7, $7, $7) returning id"
+
+const webhookDeliveryFields = "d.id, d.webhook_id, d.user_id, d.event_id, d.event_type, d.payload, d.status, d.attempt_count, \n" +
+	"d.next_attempt_at, COALESCE(d.response_code, 0), COALESCE(d.last_error, ''), d.created_at, d.updated_at"
+
+// FindWebhookDeliveries returns the latest $3 deliveries of the webhook $1.
+const FindWebhookDeliveries = "select " + webhookDeliveryFields + " from webhook_deliveries d \n" +
+	"where d.webhook_id = $1 and d.user_id = $2 order by d.id desc limit $3"
+
+const GetWebhookDelivery = "select " + webhookDeliveryFields + " from webhook_deliveries d \n" +
+	"where d.id = $1 and d.webhook_id = $2 and d.user_id = $3"
+
+// FindDueWebhookDeliveries returns deliveries in the status $1 due at $2 with the address and the secret of their webhook.
+const FindDueWebhookDeliveries = "select " + webhookDeliveryFields + ", w.url, w.secret from webhook_deliveries d \n" +
+	"join webhooks w on w.id = d.webhook_id where d.status = $1 and d.next_attempt_at <= $2 order by d.next_attempt_at limit $3"
+
+const UpdateWebhookDelivery = "UPDATE webhook_deliveries SET status=$2, attempt_count=$3, next_attempt_at=$4, response_code=$5, \n" +
+	"last_error=$6, updated_at=$7 where id=$1"
@@ -0,0 +1,17 @@
+package dbqueries
+
+const withdrawalColumns = "id, user_id, account_id, order_num, amount, status, entry_id, coalesce(reason, ''), created_at, updated_at"
+
+const CreateWithdrawal = "INSERT INTO withdrawals (id, user_id, account_id, order_num, amount, status, entry_id, created_at, updated_at)\n" +
+	"VALUES(nextval('seq_withdrawal'), $1, $2, $3, $4, $5, $6, $7, $7) returning id"
+
+const GetWithdrawalForUpdate = "select " + withdrawalColumns + " from withdrawals where id = $1 for update"
+
+const UpdateWithdrawalStatus = "UPDATE withdrawals SET status = $2, reason = $3, updated_at = $4 WHERE id = $1;"
+
+const FindWithdrawalsByStatus = "select " + withdrawalColumns + " from withdrawals where $1 = '' or status = $1 order by created_at"
+
+const GetWithdrawalByUser = "select order_num, amount, status, created_at from withdrawals where user_id = $1 order by created_at"
+
+// GetWithdrawnTotal counts pending withdrawals as withdrawn, their amounts are already held.
+const GetWithdrawnTotal = "select coalesce(sum(amount), 0) from withdrawals where user_id = $1 and status in ('PENDING', 'CONFIRMED')"
@@ -1,7 +1,14 @@
 package domain
 
+import (
+	"encoding/json"
+	"github.com/da-semenov/gophermart/internal/app/money"
+)
+
 type Accrual struct {
-	Order   string  `json:"order"`
-	Status  string  `json:"status"`
-	Accrual float32 `json:"accrual"`
+	Order   string       `json:"order"`
+	Status  string       `json:"status"`
+	Accrual money.Amount `json:"accrual"`
+	// Response is the raw body received from the accrual system.
+	Response json.RawMessage `json:"-"`
 }
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+type CircuitBreakerStatus struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+type AccrualStatus struct {
+	Paused         bool                  `json:"paused"`
+	PausedUntil    *time.Time            `json:"paused_until,omitempty"`
+	CircuitBreaker *CircuitBreakerStatus `json:"circuit_breaker,omitempty"`
+}
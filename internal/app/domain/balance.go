@@ -1,6 +1,21 @@
 package domain
 
+import (
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
+
 type Balance struct {
-	Current   float32 `json:"current"`
-	Withdrawn float32 `json:"withdrawn"`
+	Current   money.Amount `json:"current"`
+	Withdrawn money.Amount `json:"withdrawn"`
+	// Debt is the part of clawed back points not covered by the balance, it is repaid from next accruals.
+	Debt money.Amount `json:"debt,omitempty"`
+	// Expirations lists points expiring within the notice period, earliest first.
+	Expirations []Expiration `json:"expirations,omitempty"`
+}
+
+// Expiration is the amount of accrued points expiring on the date.
+type Expiration struct {
+	Date   time.Time    `json:"date"`
+	Amount money.Amount `json:"amount"`
 }
@@ -0,0 +1,25 @@
+package domain
+
+import "github.com/da-semenov/gophermart/internal/app/money"
+
+// Clawback policies for users whose balance doesn't cover the clawback.
+const (
+	// ClawbackPolicyNegative debits the whole amount, the balance goes negative.
+	ClawbackPolicyNegative = "negative"
+	// ClawbackPolicyDebt debits what the balance covers and records the rest as a debt repaid from next accruals.
+	ClawbackPolicyDebt = "debt"
+)
+
+// Clawback requests to take back points accrued for a returned order, the whole remaining accrual if Amount is empty.
+type Clawback struct {
+	Amount *money.Amount `json:"sum,omitempty"`
+	Reason string        `json:"reason"`
+}
+
+type ClawbackResult struct {
+	OrderNum string       `json:"order"`
+	Amount   money.Amount `json:"sum"`
+	// Debited is taken from the balance, Debt is the rest the user owes.
+	Debited money.Amount `json:"debited"`
+	Debt    money.Amount `json:"debt"`
+}
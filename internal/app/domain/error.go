@@ -2,6 +2,8 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"time"
 )
 
 type Error struct {
@@ -12,8 +14,38 @@ var ErrDuplicateKey = errors.New("duplicate key")
 var ErrBadParam = errors.New("bad param occurred")
 var ErrTooManyRequest = errors.New("too many request to remote service")
 var ErrRemoteServiceError = errors.New("remote service error")
+var ErrOrderNotRegistered = errors.New("order is not registered in remote service")
+var ErrCircuitOpen = fmt.Errorf("%w: circuit breaker is open", ErrRemoteServiceError)
 
 var ErrOrderRegistered = errors.New("order registered early")
 var ErrOrderRegisteredByAnotherUser = errors.New("order registered early by another user")
 var ErrBadOrderNum = errors.New("bad order num")
 var ErrNotEnoughFunds = errors.New("not enough funds")
+var ErrOrderNotFound = errors.New("order not found")
+var ErrTooManyOrders = errors.New("too many orders in the upload")
+var ErrWithdrawalExists = errors.New("withdrawal for the order already exists")
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused for another request")
+var ErrWithdrawalNotFound = errors.New("withdrawal not found")
+var ErrWithdrawalState = errors.New("withdrawal status transition is not allowed")
+var ErrNothingToClawBack = errors.New("nothing accrued for the order to claw back")
+var ErrClawbackExceedsAccrual = errors.New("clawback exceeds the accrued amount")
+var ErrRecipientNotFound = errors.New("transfer recipient not found")
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+var ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+var ErrEntryExists = errors.New("journal entry already posted")
+var ErrWebhookNotFound = errors.New("webhook not found")
+var ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+var ErrOutboxEventNotFound = errors.New("outbox event not found")
+
+// TooManyRequestError is returned when the remote service asks to retry after some delay.
+type TooManyRequestError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrTooManyRequest.Error(), e.RetryAfter)
+}
+
+func (e *TooManyRequestError) Is(target error) bool {
+	return target == ErrTooManyRequest
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"encoding/json"
+	"github.com/da-semenov/gophermart/internal/app/money"
+)
+
+// Types of events streamed to users.
+const (
+	EventOrderStatus = "order_status"
+	EventAccrual     = "accrual"
+)
+
+// Event is a user event, Data is the JSON payload of its type.
+type Event struct {
+	ID   int
+	Type string
+	Data json.RawMessage
+}
+
+type OrderStatusEvent struct {
+	Num     string       `json:"number"`
+	Status  string       `json:"status"`
+	Accrual money.Amount `json:"accrual,omitempty"`
+}
+
+type AccrualEvent struct {
+	Num    string       `json:"order"`
+	Amount money.Amount `json:"sum"`
+}
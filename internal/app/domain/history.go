@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
+
+// HistoryFilter selects operations of the balance history. From is inclusive, To is exclusive,
+// Cursor is NextCursor of the previous page.
+type HistoryFilter struct {
+	From          time.Time
+	To            time.Time
+	Types         []string
+	OperationType string
+	Cursor        string
+	Limit         int
+}
+
+// HistoryOperation is a balance change, Balance is the balance right after it.
+type HistoryOperation struct {
+	ID            int          `json:"id"`
+	Type          string       `json:"type"`
+	OperationType string       `json:"operation_type"`
+	OrderNum      string       `json:"order,omitempty"`
+	Description   string       `json:"description,omitempty"`
+	Amount        money.Amount `json:"sum"`
+	Balance       money.Amount `json:"balance"`
+	ProcessedAt   time.Time    `json:"processed_at"`
+}
+
+type BalanceHistory struct {
+	Operations []HistoryOperation `json:"operations"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// StatementHeader opens a statement, OpeningBalance is the balance at From.
+type StatementHeader struct {
+	From           time.Time
+	To             time.Time
+	OpeningBalance money.Amount
+	GeneratedAt    time.Time
+}
+
+// StatementTotals closes a statement, ClosingBalance is the balance at To.
+type StatementTotals struct {
+	Operations     int
+	Credit         money.Amount
+	Debit          money.Amount
+	ClosingBalance money.Amount
+}
@@ -0,0 +1,7 @@
+package domain
+
+// IdempotentResponse is the stored outcome of a request made with an idempotency key.
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+}
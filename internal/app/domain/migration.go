@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Unknown marks a migration applied by a newer build.
+	Unknown bool `json:"unknown,omitempty"`
+}
@@ -1,11 +1,85 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
 
 type Order struct {
-	Num      string    `json:"number"`
-	UserID   int       `json:"-"`
-	Status   string    `json:"status"`
-	Accrual  float32   `json:"accrual"`
-	UploadAt time.Time `json:"upload_at"`
+	Num      string       `json:"number"`
+	UserID   int          `json:"-"`
+	Status   string       `json:"status"`
+	Accrual  money.Amount `json:"accrual"`
+	Clawback money.Amount `json:"clawback,omitempty"`
+	UploadAt time.Time    `json:"upload_at"`
+}
+
+// OrderDetail is the order with all its status transitions from the oldest one.
+type OrderDetail struct {
+	Order
+	History []OrderStatusChange `json:"history"`
+}
+
+// OrderStatusChange is a status transition, AccrualResponse is the accrual system response that caused it.
+type OrderStatusChange struct {
+	Status          string          `json:"status"`
+	AccrualResponse json.RawMessage `json:"accrual_response,omitempty"`
+	ChangedAt       time.Time       `json:"changed_at"`
+}
+
+type StuckOrder struct {
+	Num          string    `json:"number"`
+	UserID       int       `json:"user_id"`
+	AttemptCount int       `json:"attempt_count"`
+	LastError    string    `json:"last_error"`
+	UploadAt     time.Time `json:"upload_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// OrderFilter selects orders of the order list. UploadedFrom is inclusive, UploadedTo is exclusive,
+// Cursor is NextCursor of the previous page.
+type OrderFilter struct {
+	Statuses     []string
+	UploadedFrom time.Time
+	UploadedTo   time.Time
+	Descending   bool
+	Cursor       string
+	Limit        int
+}
+
+// OrderList is a page of orders, Total counts all orders matching the filter.
+type OrderList struct {
+	Orders     []Order
+	NextCursor string
+	Total      int
+}
+
+// Results of orders of a bulk upload.
+const (
+	BulkOrderAccepted           = "accepted"
+	BulkOrderDuplicate          = "duplicate"
+	BulkOrderOwnedByAnotherUser = "owned_by_another_user"
+	BulkOrderInvalid            = "invalid"
+)
+
+// OrderLine is an order number read from the line Line of a bulk upload, Num is empty if the line is malformed.
+type OrderLine struct {
+	Line int
+	Num  string
+}
+
+type BulkOrderResult struct {
+	Line   int    `json:"line"`
+	Num    string `json:"number"`
+	Result string `json:"result"`
+}
+
+// BulkUploadReport has a result for every uploaded line and the number of lines with each result.
+type BulkUploadReport struct {
+	Accepted           int               `json:"accepted"`
+	Duplicate          int               `json:"duplicate"`
+	OwnedByAnotherUser int               `json:"owned_by_another_user"`
+	Invalid            int               `json:"invalid"`
+	Results            []BulkOrderResult `json:"results"`
 }
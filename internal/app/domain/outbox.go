@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Aggregates of domain events, events of an aggregate are published in the order they were written.
+const (
+	AggregateOrder      = "order"
+	AggregateWithdrawal = "withdrawal"
+)
+
+// Types of domain events written to the outbox.
+const (
+	OutboxOrderStatusChanged = "order.status_changed"
+	OutboxAccrualPosted      = "order.accrual_posted"
+	OutboxWithdrawalCreated  = "withdrawal.created"
+)
+
+// Names of outbox sinks used in the configuration.
+const (
+	OutboxSinkLog     = "log"
+	OutboxSinkEvents  = "events"
+	OutboxSinkWebhook = "webhook"
+)
+
+// OutboxEvent is a committed domain event, Data is the JSON payload of its type.
+type OutboxEvent struct {
+	ID            int
+	AggregateType string
+	AggregateID   string
+	UserID        int
+	Type          string
+	Data          json.RawMessage
+	CreatedAt     time.Time
+}
+
+// OutboxEventRecord is the state of an outbox event for the administrator.
+type OutboxEventRecord struct {
+	ID            int             `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	UserID        int             `json:"user_id"`
+	Type          string          `json:"event"`
+	Data          json.RawMessage `json:"data"`
+	Status        string          `json:"status"`
+	AttemptCount  int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
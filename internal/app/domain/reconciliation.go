@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
+
+// AccountMismatch compares cached totals of an account with totals computed from its postings.
+type AccountMismatch struct {
+	AccountID     int          `json:"account_id"`
+	UserID        int          `json:"user_id"`
+	Balance       money.Amount `json:"balance"`
+	Debit         money.Amount `json:"debit"`
+	Credit        money.Amount `json:"credit"`
+	LedgerBalance money.Amount `json:"ledger_balance"`
+	LedgerDebit   money.Amount `json:"ledger_debit"`
+	LedgerCredit  money.Amount `json:"ledger_credit"`
+	Repaired      bool         `json:"repaired"`
+	// AdjustmentEntryID is the journal entry posted by the repair, 0 if only the cached totals were fixed.
+	AdjustmentEntryID int `json:"adjustment_entry_id,omitempty"`
+}
+
+type UnbalancedEntry struct {
+	EntryID int          `json:"entry_id"`
+	Debit   money.Amount `json:"debit"`
+	Credit  money.Amount `json:"credit"`
+}
+
+type ReconciliationReport struct {
+	CheckedAt         time.Time         `json:"checked_at"`
+	Accounts          []AccountMismatch `json:"accounts"`
+	UnbalancedEntries []UnbalancedEntry `json:"unbalanced_entries"`
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// Types of events sent to webhooks.
+const (
+	WebhookOrderProcessed    = "order.processed"
+	WebhookOrderInvalid      = "order.invalid"
+	WebhookWithdrawalCreated = "withdrawal.created"
+)
+
+// Webhook receives events of the user, of all types if EventTypes is empty. Secret is shown only on creation.
+type Webhook struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"events"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID            int        `json:"id"`
+	EventID       string     `json:"event_id"`
+	EventType     string     `json:"event"`
+	Status        string     `json:"status"`
+	AttemptCount  int        `json:"attempts"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// WebhookPayload is the body of a webhook request. ID is the same for all deliveries of the event.
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -1,15 +1,40 @@
 package domain
 
-import "time"
+import (
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
 
 type Withdrawal struct {
-	OrderNum    string    `json:"order"`
-	Amount      float32   `json:"sum"`
-	Status      string    `json:"status"`
-	ProcessedAt time.Time `json:"processed_at"`
+	OrderNum    string       `json:"order"`
+	Amount      money.Amount `json:"sum"`
+	Status      string       `json:"status"`
+	ProcessedAt time.Time    `json:"processed_at"`
 }
 
 type Withdraw struct {
-	OrderNum string  `json:"order"`
-	Amount   float32 `json:"sum"`
+	OrderNum string       `json:"order"`
+	Amount   money.Amount `json:"sum"`
+}
+
+// WithdrawalRecord is the view of a withdrawal for the payment side.
+type WithdrawalRecord struct {
+	ID          int          `json:"id"`
+	UserID      int          `json:"user_id"`
+	OrderNum    string       `json:"order"`
+	Amount      money.Amount `json:"sum"`
+	Status      string       `json:"status"`
+	Reason      string       `json:"reason,omitempty"`
+	ProcessedAt time.Time    `json:"processed_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type WithdrawalAction struct {
+	Reason string `json:"reason"`
+}
+
+// Transfer moves points to the user with the given login.
+type Transfer struct {
+	Login  string       `json:"login"`
+	Amount money.Amount `json:"sum"`
 }
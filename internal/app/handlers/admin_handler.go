@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type AdminService interface {
+	GetStuckOrders(ctx context.Context) ([]domain.StuckOrder, error)
+	ReviveOrder(ctx context.Context, orderNum string) error
+	GetAccrualStatus(ctx context.Context) (*domain.AccrualStatus, error)
+}
+
+type AdminHandler struct {
+	adminService AdminService
+	log          *infrastructure.Logger
+}
+
+func NewAdminHandler(as AdminService, l *infrastructure.Logger) *AdminHandler {
+	var target AdminHandler
+	target.adminService = as
+	target.log = l
+	return &target
+}
+
+func (h *AdminHandler) GetStuckOrders(w http.ResponseWriter, r *http.Request) {
+	res, err := h.adminService.GetStuckOrders(r.Context())
+	if err != nil {
+		h.log.Error("AdminHandler:can't get stuck orders", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("AdminHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("AdminHandler: can't serialize response", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("AdminHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
+		h.log.Error("AdminHandler: can't write response", zap.Error(err))
+	}
+}
+
+func (h *AdminHandler) ReviveOrder(w http.ResponseWriter, r *http.Request) {
+	orderNum := chi.URLParam(r, "number")
+	err := h.adminService.ReviveOrder(r.Context(), orderNum)
+	if err != nil {
+		var (
+			statusCode int
+			msg        string
+		)
+		h.log.Error("AdminHandler:ReviveOrder error", zap.String("orderNum", orderNum), zap.Error(err))
+		switch {
+		case errors.Is(err, domain.ErrBadParam):
+			statusCode = http.StatusBadRequest
+			msg = "неверный формат запроса"
+		case errors.Is(err, domain.ErrOrderNotFound):
+			statusCode = http.StatusNotFound
+			msg = "зависший заказ не найден"
+		default:
+			statusCode = http.StatusInternalServerError
+			msg = "внутренняя ошибка сервера"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("AdminHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, nil); err != nil {
+		h.log.Error("AdminHandler: can't write response", zap.Error(err))
+	}
+	h.log.Info("Order revived", zap.String("orderNum", orderNum))
+}
+
+func (h *AdminHandler) GetAccrualStatus(w http.ResponseWriter, r *http.Request) {
+	res, err := h.adminService.GetAccrualStatus(r.Context())
+	if err != nil {
+		h.log.Error("AdminHandler:can't get accrual status", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("AdminHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("AdminHandler: can't serialize response", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("AdminHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
+		h.log.Error("AdminHandler: can't write response", zap.Error(err))
+	}
+}
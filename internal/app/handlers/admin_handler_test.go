@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/handlers/mocks"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminHandler_ReviveOrder(t *testing.T) {
+	type args struct {
+		orderNum string
+		error    error
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "AdminHandler. ReviveOrder. Test 1. Positive",
+			args: args{
+				orderNum: "12345678903",
+				error:    nil,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "AdminHandler. ReviveOrder. Test 2. Stuck order not found",
+			args: args{
+				orderNum: "12345678903",
+				error:    domain.ErrOrderNotFound,
+			},
+			wants: wants{
+				responseCode: http.StatusNotFound,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "AdminHandler. ReviveOrder. Test 3. Any error",
+			args: args{
+				orderNum: "12345678903",
+				error:    errors.New("any error"),
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	adminService := mocks.NewMockAdminService(mockCtrl)
+	target := NewAdminHandler(adminService, log)
+	router := chi.NewRouter()
+	router.Post("/api/admin/orders/{number}/revive", target.ReviveOrder)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adminService.EXPECT().ReviveOrder(gomock.Any(), tt.args.orderNum).Return(tt.args.error)
+
+			request := httptest.NewRequest("POST", "/api/admin/orders/"+tt.args.orderNum+"/revive", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
+		})
+	}
+}
+
+func TestAdminHandler_GetStuckOrders(t *testing.T) {
+	type args struct {
+		res   []domain.StuckOrder
+		error error
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "AdminHandler. GetStuckOrders. Test 1. Positive",
+			args: args{
+				res:   []domain.StuckOrder{{Num: "12345678903", AttemptCount: 50}},
+				error: nil,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "AdminHandler. GetStuckOrders. Test 2. Error",
+			args: args{
+				res:   nil,
+				error: errors.New("any error"),
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	adminService := mocks.NewMockAdminService(mockCtrl)
+	target := NewAdminHandler(adminService, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adminService.EXPECT().GetStuckOrders(gomock.Any()).Return(tt.args.res, tt.args.error)
+
+			request := httptest.NewRequest("GET", "/api/admin/orders/stuck", nil)
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(target.GetStuckOrders)
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
+		})
+	}
+}
+
+func TestAdminHandler_GetAccrualStatus(t *testing.T) {
+	type args struct {
+		res   *domain.AccrualStatus
+		error error
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "AdminHandler. GetAccrualStatus. Test 1. Positive",
+			args: args{
+				res:   &domain.AccrualStatus{CircuitBreaker: &domain.CircuitBreakerStatus{State: domain.CircuitOpen, Failures: 5}},
+				error: nil,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "AdminHandler. GetAccrualStatus. Test 2. Error",
+			args: args{
+				res:   nil,
+				error: errors.New("any error"),
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	adminService := mocks.NewMockAdminService(mockCtrl)
+	target := NewAdminHandler(adminService, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adminService.EXPECT().GetAccrualStatus(gomock.Any()).Return(tt.args.res, tt.args.error)
+
+			request := httptest.NewRequest("GET", "/api/admin/accrual/status", nil)
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(target.GetAccrualStatus)
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
+		})
+	}
+}
@@ -3,16 +3,33 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/da-semenov/gophermart/internal/app/domain"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/statement"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+// writeTracker tells whether anything was written, after that the status can't be changed.
+type writeTracker struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (t *writeTracker) Write(b []byte) (int, error) {
+	t.written = true
+	return t.w.Write(b)
+}
+
 type BalanceService interface {
 	GetCurrentBalance(ctx context.Context, userID int) (*domain.Balance, error)
 	Withdraw(ctx context.Context, obj *domain.Withdraw, userID int) error
 	GetWithdrawalsList(ctx context.Context, userID int) ([]domain.Withdrawal, error)
+	GetHistory(ctx context.Context, userID int, filter domain.HistoryFilter) (*domain.BalanceHistory, error)
+	WriteStatement(ctx context.Context, userID int, from time.Time, to time.Time, out statement.Writer) error
 }
 
 type BalanceHandler struct {
@@ -112,6 +129,12 @@ func (h *BalanceHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 		case domain.ErrBadOrderNum:
 			statusCode = http.StatusUnprocessableEntity
 			msg = "неверный номер заказа"
+		case domain.ErrBadParam:
+			statusCode = http.StatusBadRequest
+			msg = "неверный формат запроса"
+		case domain.ErrWithdrawalExists:
+			statusCode = http.StatusConflict
+			msg = "списание по этому номеру заказа уже выполнено"
 		default:
 			statusCode = http.StatusInternalServerError
 			msg = "внутренняя ошибка сервера"
@@ -119,6 +142,7 @@ func (h *BalanceHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
 			h.log.Error("BalanceHandler: can't write response", zap.Error(err))
 		}
+		return
 	}
 
 	if err = WriteResponse(w, http.StatusOK, nil); err != nil {
@@ -156,3 +180,115 @@ func (h *BalanceHandler) GetWithdrawalsList(w http.ResponseWriter, r *http.Reque
 		}
 	}
 }
+
+func (h *BalanceHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("BalanceHandler:can't get params from the token", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("BalanceHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		h.log.Debug("BalanceHandler: bad history filter", zap.Error(err))
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("BalanceHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	res, err := h.balanceService.GetHistory(ctx, userID, filter)
+	if err != nil {
+		statusCode, msg := http.StatusInternalServerError, "внутренняя ошибка сервера"
+		if errors.Is(err, domain.ErrBadParam) {
+			statusCode, msg = http.StatusBadRequest, "неверный формат запроса"
+		} else {
+			h.log.Error("BalanceHandler: can't get balance history", zap.Error(err))
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("BalanceHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("BalanceHandler: can't serialize response", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("BalanceHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
+		h.log.Error("BalanceHandler: can't write response", zap.Error(err))
+	}
+}
+
+func parseHistoryFilter(r *http.Request) (filter domain.HistoryFilter, err error) {
+	query := r.URL.Query()
+	if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeParam(query.Get("to")); err != nil {
+		return filter, err
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return filter, err
+		}
+	}
+	filter.Types = listParam(query["type"])
+	filter.OperationType = query.Get("operation_type")
+	filter.Cursor = query.Get("cursor")
+	return filter, nil
+}
+
+func (h *BalanceHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("BalanceHandler:can't get params from the token", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("BalanceHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	query := r.URL.Query()
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = "csv"
+	}
+	format, ok := statement.GetFormat(formatName)
+	from, errFrom := parseTimeParam(query.Get("from"))
+	to, errTo := parseTimeParam(query.Get("to"))
+	if !ok || errFrom != nil || errTo != nil {
+		h.log.Debug("BalanceHandler: bad statement params", zap.String("query", r.URL.RawQuery))
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("BalanceHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\"statement."+format.Extension+"\"")
+	out := writeTracker{w: w}
+	err = h.balanceService.WriteStatement(ctx, userID, from, to, format.NewWriter(&out))
+	if err != nil && out.written {
+		// the statement is cut off, the client sees a broken file
+		h.log.Error("BalanceHandler: statement interrupted", zap.Int("userID", userID), zap.Error(err))
+		return
+	}
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		statusCode, msg := http.StatusInternalServerError, "внутренняя ошибка сервера"
+		if errors.Is(err, domain.ErrBadParam) {
+			statusCode, msg = http.StatusBadRequest, "неверный формат запроса"
+		} else {
+			h.log.Error("BalanceHandler: can't write statement", zap.Error(err))
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("BalanceHandler: can't write response", zap.Error(err))
+		}
+	}
+}
@@ -233,3 +233,184 @@ func TestBalanceHandler_WithdrawalsList(t *testing.T) {
 		})
 	}
 }
+
+func TestBalanceHandler_GetHistory(t *testing.T) {
+	type args struct {
+		query string
+		res   *domain.BalanceHistory
+		error error
+		call  bool
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "BalanceHandler. GetHistory. Test 1. Positive",
+			args: args{
+				query: "?from=2021-06-01&to=2021-07-01T00:00:00Z&type=ACCRUAL,REVERSAL&operation_type=CREDIT&limit=10",
+				res:   &domain.BalanceHistory{Operations: []domain.HistoryOperation{}},
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "BalanceHandler. GetHistory. Test 2. Bad date",
+			args: args{
+				query: "?from=yesterday",
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "BalanceHandler. GetHistory. Test 3. Bad filter",
+			args: args{
+				query: "?type=UNKNOWN",
+				error: domain.ErrBadParam,
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "BalanceHandler. GetHistory. Test 4. Error",
+			args: args{
+				error: errors.New("any error"),
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	balanceService := mocks.NewMockBalanceService(mockCtrl)
+	target := NewBalanceHandler(balanceService, auth, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.call {
+				balanceService.EXPECT().GetHistory(gomock.Any(), 0, gomock.Any()).Return(tt.args.res, tt.args.error)
+			}
+
+			request := httptest.NewRequest("GET", "/api/user/balance/history"+tt.args.query, nil)
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(target.GetHistory)
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
+		})
+	}
+}
+
+func TestBalanceHandler_GetStatement(t *testing.T) {
+	type args struct {
+		query string
+		error error
+		call  bool
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "BalanceHandler. GetStatement. Test 1. CSV by default",
+			args: args{
+				query: "?from=2021-06-01&to=2021-07-01",
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "text/csv; charset=utf-8",
+			},
+		},
+		{
+			name: "BalanceHandler. GetStatement. Test 2. PDF",
+			args: args{
+				query: "?format=pdf",
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/pdf",
+			},
+		},
+		{
+			name: "BalanceHandler. GetStatement. Test 3. Unknown format",
+			args: args{
+				query: "?format=xls",
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "BalanceHandler. GetStatement. Test 4. Empty period",
+			args: args{
+				query: "?from=2021-07-01&to=2021-06-01",
+				error: domain.ErrBadParam,
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "BalanceHandler. GetStatement. Test 5. Error",
+			args: args{
+				error: errors.New("any error"),
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	balanceService := mocks.NewMockBalanceService(mockCtrl)
+	target := NewBalanceHandler(balanceService, auth, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.call {
+				balanceService.EXPECT().WriteStatement(gomock.Any(), 0, gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.args.error)
+			}
+
+			request := httptest.NewRequest("GET", "/api/user/balance/statement"+tt.args.query, nil)
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(target.GetStatement)
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
+		})
+	}
+}
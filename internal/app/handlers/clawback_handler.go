@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type ClawbackService interface {
+	Clawback(ctx context.Context, orderNum string, obj *domain.Clawback) (*domain.ClawbackResult, error)
+}
+
+// ClawbackHandler takes back points of returned orders.
+type ClawbackHandler struct {
+	clawbackService ClawbackService
+	log             *infrastructure.Logger
+}
+
+func NewClawbackHandler(cs ClawbackService, l *infrastructure.Logger) *ClawbackHandler {
+	var target ClawbackHandler
+	target.clawbackService = cs
+	target.log = l
+	return &target
+}
+
+func (h *ClawbackHandler) Clawback(w http.ResponseWriter, r *http.Request) {
+	orderNum := chi.URLParam(r, "number")
+	var clawback domain.Clawback
+	b, err := getRequestBody(r)
+	if err == nil && len(b) > 0 {
+		err = json.Unmarshal(b, &clawback)
+	}
+	if err != nil {
+		h.log.Info("ClawbackHandler:bad request body", zap.Error(err))
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("ClawbackHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	res, err := h.clawbackService.Clawback(r.Context(), orderNum, &clawback)
+	if err != nil {
+		var (
+			statusCode int
+			msg        string
+		)
+		h.log.Error("ClawbackHandler:Clawback error", zap.String("orderNum", orderNum), zap.Error(err))
+		switch {
+		case errors.Is(err, domain.ErrBadParam):
+			statusCode = http.StatusBadRequest
+			msg = "неверный формат запроса"
+		case errors.Is(err, domain.ErrOrderNotFound):
+			statusCode = http.StatusNotFound
+			msg = "заказ не найден"
+		case errors.Is(err, domain.ErrNothingToClawBack):
+			statusCode = http.StatusUnprocessableEntity
+			msg = "по заказу нет начисленных баллов"
+		case errors.Is(err, domain.ErrClawbackExceedsAccrual):
+			statusCode = http.StatusUnprocessableEntity
+			msg = "сумма превышает начисленные по заказу баллы"
+		default:
+			statusCode = http.StatusInternalServerError
+			msg = "внутренняя ошибка сервера"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("ClawbackHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("ClawbackHandler: can't serialize response", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("ClawbackHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
+		h.log.Error("ClawbackHandler: can't write response", zap.Error(err))
+	}
+	h.log.Info("Clawback success", zap.String("orderNum", orderNum))
+}
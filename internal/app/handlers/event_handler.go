@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// eventsPollInterval bounds the delay of events published by other instances.
+	eventsPollInterval = 5 * time.Second
+	// eventsHeartbeat keeps idle streams from being closed by proxies.
+	eventsHeartbeat = 15 * time.Second
+)
+
+type EventService interface {
+	Subscribe(userID int) (<-chan struct{}, func())
+	GetEvents(ctx context.Context, userID int, afterID int) ([]domain.Event, error)
+	GetLastEventID(ctx context.Context, userID int) (int, error)
+}
+
+type EventHandler struct {
+	eventService EventService
+	auth         *Auth
+	log          *infrastructure.Logger
+	pollInterval time.Duration
+}
+
+func NewEventHandler(es EventService, auth *Auth, l *infrastructure.Logger) *EventHandler {
+	var target EventHandler
+	target.log = l
+	target.eventService = es
+	target.auth = auth
+	target.pollInterval = eventsPollInterval
+	return &target
+}
+
+// Stream sends events of the user as Server-Sent Events until the client disconnects. A reconnecting client
+// gets the events following Last-Event-ID, a new stream starts with the events published after it was opened.
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("EventHandler:can't get params from the token", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("EventHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.log.Error("EventHandler: streaming is not supported by the response writer")
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("EventHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+
+	// subscribe before reading the log, so events published in between are not missed
+	notify, unsubscribe := h.eventService.Subscribe(userID)
+	defer unsubscribe()
+	lastID, err := h.lastEventID(ctx, r, userID)
+	if err != nil {
+		statusCode, msg := http.StatusInternalServerError, "внутренняя ошибка сервера"
+		if _, ok := err.(*strconv.NumError); ok {
+			statusCode, msg = http.StatusBadRequest, "неверный формат запроса"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("EventHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := time.NewTicker(h.pollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		events, err := h.eventService.GetEvents(ctx, userID, lastID)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.log.Error("EventHandler: can't get events", zap.Int("userID", userID), zap.Error(err))
+			}
+			return
+		}
+		for _, e := range events {
+			if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data); err != nil {
+				return
+			}
+			lastID = e.ID
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+			lastWrite = time.Now()
+			continue
+		}
+		if time.Since(lastWrite) >= eventsHeartbeat {
+			if _, err = fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-notify:
+		case <-poll.C:
+		}
+	}
+}
+
+// lastEventID takes the position to resume from the Last-Event-ID header or the last_event_id parameter,
+// EventSource can't set headers on the first connection.
+func (h *EventHandler) lastEventID(ctx context.Context, r *http.Request, userID int) (int, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value != "" {
+		return strconv.Atoi(value)
+	}
+	return h.eventService.GetLastEventID(ctx, userID)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/handlers/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEventHandler_Stream(t *testing.T) {
+	type args struct {
+		lastEventID string
+		query       string
+		afterID     int
+		lastIDCall  bool
+		events      []domain.Event
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+		body         string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "EventHandler. Stream. Test 1. Resume after Last-Event-ID",
+			args: args{
+				lastEventID: "3",
+				afterID:     3,
+				events: []domain.Event{
+					{ID: 4, Type: domain.EventOrderStatus, Data: []byte(`{"number":"12345678903","status":"PROCESSING"}`)},
+					{ID: 6, Type: domain.EventAccrual, Data: []byte(`{"order":"12345678903","sum":500}`)},
+				},
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "text/event-stream",
+				body: "id: 4\nevent: order_status\ndata: {\"number\":\"12345678903\",\"status\":\"PROCESSING\"}\n\n" +
+					"id: 6\nevent: accrual\ndata: {\"order\":\"12345678903\",\"sum\":500}\n\n",
+			},
+		},
+		{
+			name: "EventHandler. Stream. Test 2. New stream starts after the last event",
+			args: args{
+				afterID:    10,
+				lastIDCall: true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "text/event-stream",
+			},
+		},
+		{
+			name: "EventHandler. Stream. Test 3. Resume from the query parameter",
+			args: args{
+				query:   "?last_event_id=7",
+				afterID: 7,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "text/event-stream",
+			},
+		},
+		{
+			name: "EventHandler. Stream. Test 4. Bad Last-Event-ID",
+			args: args{
+				lastEventID: "last",
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	eventService := mocks.NewMockEventService(mockCtrl)
+	target := NewEventHandler(eventService, auth, log)
+	target.pollInterval = time.Millisecond
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			eventService.EXPECT().Subscribe(0).Return(make(chan struct{}), func() {})
+			if tt.args.lastIDCall {
+				eventService.EXPECT().GetLastEventID(gomock.Any(), 0).Return(tt.args.afterID, nil)
+			}
+			if tt.wants.responseCode == http.StatusOK {
+				eventService.EXPECT().GetEvents(gomock.Any(), 0, tt.args.afterID).Return(tt.args.events, nil)
+				lastID := tt.args.afterID
+				if len(tt.args.events) > 0 {
+					lastID = tt.args.events[len(tt.args.events)-1].ID
+				}
+				// the client disconnects once it got all events
+				eventService.EXPECT().GetEvents(gomock.Any(), 0, lastID).DoAndReturn(
+					func(ctx context.Context, userID int, afterID int) ([]domain.Event, error) {
+						cancel()
+						return nil, errors.New("context canceled")
+					})
+			}
+
+			request := httptest.NewRequest("GET", "/api/user/events"+tt.args.query, nil).WithContext(ctx)
+			if tt.args.lastEventID != "" {
+				request.Header.Set("Last-Event-ID", tt.args.lastEventID)
+			}
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(target.Stream)
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
+			if tt.wants.responseCode == http.StatusOK {
+				assert.Equal(t, tt.wants.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,49 +0,0 @@
-// Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/da-semenov/gophermart/internal/app/handlers (interfaces: AccrualService)
-
-// Package mock_handlers is a generated GoMock package.
-package mocks
-
-import (
-	context "context"
-	reflect "reflect"
-
-	gomock "github.com/golang/mock/gomock"
-)
-
-// MockAccrualService is a mock of AccrualService interface.
-type MockAccrualService struct {
-	ctrl     *gomock.Controller
-	recorder *MockAccrualServiceMockRecorder
-}
-
-// MockAccrualServiceMockRecorder is the mock recorder for MockAccrualService.
-type MockAccrualServiceMockRecorder struct {
-	mock *MockAccrualService
-}
-
-// NewMockAccrualService creates a new mock instance.
-func NewMockAccrualService(ctrl *gomock.Controller) *MockAccrualService {
-	mock := &MockAccrualService{ctrl: ctrl}
-	mock.recorder = &MockAccrualServiceMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockAccrualService) EXPECT() *MockAccrualServiceMockRecorder {
-	return m.recorder
-}
-
-// ProcessOrder mocks base method.
-func (m *MockAccrualService) ProcessOrder(arg0 context.Context, arg1 string) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ProcessOrder", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// ProcessOrder indicates an expected call of ProcessOrder.
-func (mr *MockAccrualServiceMockRecorder) ProcessOrder(arg0, arg1 interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOrder", reflect.TypeOf((*MockAccrualService)(nil).ProcessOrder), arg0, arg1)
-}
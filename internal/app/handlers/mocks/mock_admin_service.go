@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/handlers (interfaces: AdminService)
+
+// Package mock_handlers is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/da-semenov/gophermart/internal/app/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAdminService is a mock of AdminService interface.
+type MockAdminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminServiceMockRecorder
+}
+
+// MockAdminServiceMockRecorder is the mock recorder for MockAdminService.
+type MockAdminServiceMockRecorder struct {
+	mock *MockAdminService
+}
+
+// NewMockAdminService creates a new mock instance.
+func NewMockAdminService(ctrl *gomock.Controller) *MockAdminService {
+	mock := &MockAdminService{ctrl: ctrl}
+	mock.recorder = &MockAdminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminService) EXPECT() *MockAdminServiceMockRecorder {
+	return m.recorder
+}
+
+// GetAccrualStatus mocks base method.
+func (m *MockAdminService) GetAccrualStatus(arg0 context.Context) (*domain.AccrualStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccrualStatus", arg0)
+	ret0, _ := ret[0].(*domain.AccrualStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccrualStatus indicates an expected call of GetAccrualStatus.
+func (mr *MockAdminServiceMockRecorder) GetAccrualStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccrualStatus", reflect.TypeOf((*MockAdminService)(nil).GetAccrualStatus), arg0)
+}
+
+// GetStuckOrders mocks base method.
+func (m *MockAdminService) GetStuckOrders(arg0 context.Context) ([]domain.StuckOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStuckOrders", arg0)
+	ret0, _ := ret[0].([]domain.StuckOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStuckOrders indicates an expected call of GetStuckOrders.
+func (mr *MockAdminServiceMockRecorder) GetStuckOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStuckOrders", reflect.TypeOf((*MockAdminService)(nil).GetStuckOrders), arg0)
+}
+
+// ReviveOrder mocks base method.
+func (m *MockAdminService) ReviveOrder(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviveOrder", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReviveOrder indicates an expected call of ReviveOrder.
+func (mr *MockAdminServiceMockRecorder) ReviveOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviveOrder", reflect.TypeOf((*MockAdminService)(nil).ReviveOrder), arg0, arg1)
+}
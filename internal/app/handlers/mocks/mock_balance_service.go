@@ -7,8 +7,10 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/da-semenov/gophermart/internal/app/domain"
+	statement "github.com/da-semenov/gophermart/internal/app/statement"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -50,6 +52,21 @@ func (mr *MockBalanceServiceMockRecorder) GetCurrentBalance(arg0, arg1 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentBalance", reflect.TypeOf((*MockBalanceService)(nil).GetCurrentBalance), arg0, arg1)
 }
 
+// GetHistory mocks base method.
+func (m *MockBalanceService) GetHistory(arg0 context.Context, arg1 int, arg2 domain.HistoryFilter) (*domain.BalanceHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.BalanceHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockBalanceServiceMockRecorder) GetHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockBalanceService)(nil).GetHistory), arg0, arg1, arg2)
+}
+
 // GetWithdrawalsList mocks base method.
 func (m *MockBalanceService) GetWithdrawalsList(arg0 context.Context, arg1 int) ([]domain.Withdrawal, error) {
 	m.ctrl.T.Helper()
@@ -79,3 +96,16 @@ func (mr *MockBalanceServiceMockRecorder) Withdraw(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdraw", reflect.TypeOf((*MockBalanceService)(nil).Withdraw), arg0, arg1, arg2)
 }
 
+// WriteStatement mocks base method.
+func (m *MockBalanceService) WriteStatement(arg0 context.Context, arg1 int, arg2, arg3 time.Time, arg4 statement.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteStatement", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteStatement indicates an expected call of WriteStatement.
+func (mr *MockBalanceServiceMockRecorder) WriteStatement(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteStatement", reflect.TypeOf((*MockBalanceService)(nil).WriteStatement), arg0, arg1, arg2, arg3, arg4)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/handlers (interfaces: ClawbackService)
+
+// Package mock_handlers is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/da-semenov/gophermart/internal/app/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockClawbackService is a mock of ClawbackService interface.
+type MockClawbackService struct {
+	ctrl     *gomock.Controller
+	recorder *MockClawbackServiceMockRecorder
+}
+
+// MockClawbackServiceMockRecorder is the mock recorder for MockClawbackService.
+type MockClawbackServiceMockRecorder struct {
+	mock *MockClawbackService
+}
+
+// NewMockClawbackService creates a new mock instance.
+func NewMockClawbackService(ctrl *gomock.Controller) *MockClawbackService {
+	mock := &MockClawbackService{ctrl: ctrl}
+	mock.recorder = &MockClawbackServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClawbackService) EXPECT() *MockClawbackServiceMockRecorder {
+	return m.recorder
+}
+
+// Clawback mocks base method.
+func (m *MockClawbackService) Clawback(arg0 context.Context, arg1 string, arg2 *domain.Clawback) (*domain.ClawbackResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clawback", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.ClawbackResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Clawback indicates an expected call of Clawback.
+func (mr *MockClawbackServiceMockRecorder) Clawback(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clawback", reflect.TypeOf((*MockClawbackService)(nil).Clawback), arg0, arg1, arg2)
+}
@@ -35,19 +35,34 @@ func (m *MockOrderService) EXPECT() *MockOrderServiceMockRecorder {
 	return m.recorder
 }
 
+// GetOrder mocks base method.
+func (m *MockOrderService) GetOrder(arg0 context.Context, arg1 int, arg2 string) (*domain.OrderDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.OrderDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockOrderServiceMockRecorder) GetOrder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockOrderService)(nil).GetOrder), arg0, arg1, arg2)
+}
+
 // GetOrderList mocks base method.
-func (m *MockOrderService) GetOrderList(arg0 context.Context, arg1 int) ([]domain.Order, error) {
+func (m *MockOrderService) GetOrderList(arg0 context.Context, arg1 int, arg2 domain.OrderFilter) (*domain.OrderList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderList", arg0, arg1)
-	ret0, _ := ret[0].([]domain.Order)
+	ret := m.ctrl.Call(m, "GetOrderList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.OrderList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrderList indicates an expected call of GetOrderList.
-func (mr *MockOrderServiceMockRecorder) GetOrderList(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockOrderServiceMockRecorder) GetOrderList(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderList", reflect.TypeOf((*MockOrderService)(nil).GetOrderList), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderList", reflect.TypeOf((*MockOrderService)(nil).GetOrderList), arg0, arg1, arg2)
 }
 
 // Save mocks base method.
@@ -63,3 +78,18 @@ func (mr *MockOrderServiceMockRecorder) Save(arg0, arg1 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOrderService)(nil).Save), arg0, arg1)
 }
+
+// UploadOrders mocks base method.
+func (m *MockOrderService) UploadOrders(arg0 context.Context, arg1 int, arg2 []domain.OrderLine) (*domain.BulkUploadReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadOrders", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.BulkUploadReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadOrders indicates an expected call of UploadOrders.
+func (mr *MockOrderServiceMockRecorder) UploadOrders(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadOrders", reflect.TypeOf((*MockOrderService)(nil).UploadOrders), arg0, arg1, arg2)
+}
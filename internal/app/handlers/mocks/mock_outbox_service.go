@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/handlers (interfaces: OutboxService)
+
+// Package mock_handlers is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/da-semenov/gophermart/internal/app/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOutboxService is a mock of OutboxService interface.
+type MockOutboxService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxServiceMockRecorder
+}
+
+// MockOutboxServiceMockRecorder is the mock recorder for MockOutboxService.
+type MockOutboxServiceMockRecorder struct {
+	mock *MockOutboxService
+}
+
+// NewMockOutboxService creates a new mock instance.
+func NewMockOutboxService(ctrl *gomock.Controller) *MockOutboxService {
+	mock := &MockOutboxService{ctrl: ctrl}
+	mock.recorder = &MockOutboxServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxService) EXPECT() *MockOutboxServiceMockRecorder {
+	return m.recorder
+}
+
+// GetFailedEvents mocks base method.
+func (m *MockOutboxService) GetFailedEvents(arg0 context.Context) ([]domain.OutboxEventRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailedEvents", arg0)
+	ret0, _ := ret[0].([]domain.OutboxEventRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailedEvents indicates an expected call of GetFailedEvents.
+func (mr *MockOutboxServiceMockRecorder) GetFailedEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailedEvents", reflect.TypeOf((*MockOutboxService)(nil).GetFailedEvents), arg0)
+}
+
+// RetryEvent mocks base method.
+func (m *MockOutboxService) RetryEvent(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryEvent indicates an expected call of RetryEvent.
+func (mr *MockOutboxServiceMockRecorder) RetryEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryEvent", reflect.TypeOf((*MockOutboxService)(nil).RetryEvent), arg0, arg1)
+}
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/handlers (interfaces: TransferService)
+
+// Package mock_handlers is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/da-semenov/gophermart/internal/app/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTransferService is a mock of TransferService interface.
+type MockTransferService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransferServiceMockRecorder
+}
+
+// MockTransferServiceMockRecorder is the mock recorder for MockTransferService.
+type MockTransferServiceMockRecorder struct {
+	mock *MockTransferService
+}
+
+// NewMockTransferService creates a new mock instance.
+func NewMockTransferService(ctrl *gomock.Controller) *MockTransferService {
+	mock := &MockTransferService{ctrl: ctrl}
+	mock.recorder = &MockTransferServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransferService) EXPECT() *MockTransferServiceMockRecorder {
+	return m.recorder
+}
+
+// Transfer mocks base method.
+func (m *MockTransferService) Transfer(arg0 context.Context, arg1 *domain.Transfer, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockTransferServiceMockRecorder) Transfer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockTransferService)(nil).Transfer), arg0, arg1, arg2)
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/handlers (interfaces: WithdrawalService)
+
+// Package mock_handlers is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/da-semenov/gophermart/internal/app/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWithdrawalService is a mock of WithdrawalService interface.
+type MockWithdrawalService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWithdrawalServiceMockRecorder
+}
+
+// MockWithdrawalServiceMockRecorder is the mock recorder for MockWithdrawalService.
+type MockWithdrawalServiceMockRecorder struct {
+	mock *MockWithdrawalService
+}
+
+// NewMockWithdrawalService creates a new mock instance.
+func NewMockWithdrawalService(ctrl *gomock.Controller) *MockWithdrawalService {
+	mock := &MockWithdrawalService{ctrl: ctrl}
+	mock.recorder = &MockWithdrawalServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWithdrawalService) EXPECT() *MockWithdrawalServiceMockRecorder {
+	return m.recorder
+}
+
+// ConfirmWithdrawal mocks base method.
+func (m *MockWithdrawalService) ConfirmWithdrawal(arg0 context.Context, arg1 int) (*domain.WithdrawalRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmWithdrawal", arg0, arg1)
+	ret0, _ := ret[0].(*domain.WithdrawalRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmWithdrawal indicates an expected call of ConfirmWithdrawal.
+func (mr *MockWithdrawalServiceMockRecorder) ConfirmWithdrawal(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmWithdrawal", reflect.TypeOf((*MockWithdrawalService)(nil).ConfirmWithdrawal), arg0, arg1)
+}
+
+// GetWithdrawals mocks base method.
+func (m *MockWithdrawalService) GetWithdrawals(arg0 context.Context, arg1 string) ([]domain.WithdrawalRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawals", arg0, arg1)
+	ret0, _ := ret[0].([]domain.WithdrawalRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawals indicates an expected call of GetWithdrawals.
+func (mr *MockWithdrawalServiceMockRecorder) GetWithdrawals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawals", reflect.TypeOf((*MockWithdrawalService)(nil).GetWithdrawals), arg0, arg1)
+}
+
+// RejectWithdrawal mocks base method.
+func (m *MockWithdrawalService) RejectWithdrawal(arg0 context.Context, arg1 int, arg2 string) (*domain.WithdrawalRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectWithdrawal", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.WithdrawalRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectWithdrawal indicates an expected call of RejectWithdrawal.
+func (mr *MockWithdrawalServiceMockRecorder) RejectWithdrawal(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectWithdrawal", reflect.TypeOf((*MockWithdrawalService)(nil).RejectWithdrawal), arg0, arg1, arg2)
+}
+
+// ReverseWithdrawal mocks base method.
+func (m *MockWithdrawalService) ReverseWithdrawal(arg0 context.Context, arg1 int, arg2 string) (*domain.WithdrawalRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseWithdrawal", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.WithdrawalRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseWithdrawal indicates an expected call of ReverseWithdrawal.
+func (mr *MockWithdrawalServiceMockRecorder) ReverseWithdrawal(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseWithdrawal", reflect.TypeOf((*MockWithdrawalService)(nil).ReverseWithdrawal), arg0, arg1, arg2)
+}
@@ -1,20 +1,33 @@
 package handlers
 
 import (
+	"bufio"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/da-semenov/gophermart/internal/app/domain"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type OrderService interface {
 	Save(ctx context.Context, order *domain.Order) error
-	GetOrderList(ctx context.Context, userID int) ([]domain.Order, error)
+	GetOrderList(ctx context.Context, userID int, filter domain.OrderFilter) (*domain.OrderList, error)
+	GetOrder(ctx context.Context, userID int, num string) (*domain.OrderDetail, error)
+	UploadOrders(ctx context.Context, userID int, lines []domain.OrderLine) (*domain.BulkUploadReport, error)
 }
 
+// bulkUploadMaxBytes limits the body of a bulk upload.
+const bulkUploadMaxBytes = 1 << 20
+
 type OrderHandler struct {
 	orderService OrderService
 	auth         *Auth
@@ -97,6 +110,152 @@ func (h *OrderHandler) RegisterNewOrder(w http.ResponseWriter, r *http.Request)
 	h.log.Info(fmt.Sprintf("Order %s successfully registered", order.Num))
 }
 
+// UploadOrders registers order numbers given as CSV (the number is the first column, a header is allowed),
+// as NDJSON or as plain text with a number per line.
+func (h *OrderHandler) UploadOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("OrderHandler:can't get params from the token", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	body := http.MaxBytesReader(w, r.Body, bulkUploadMaxBytes)
+	defer body.Close()
+	var lines []domain.OrderLine
+	switch mediaType {
+	case "text/csv":
+		lines, err = readCSVOrders(body)
+	case "application/x-ndjson":
+		lines, err = readNDJSONOrders(body)
+	case "text/plain":
+		lines, err = readPlainOrders(body)
+	default:
+		err = fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	if err != nil && err.Error() == "http: request body too large" {
+		h.log.Info("OrderHandler: bulk upload is too large")
+		if err = WriteResponse(w, http.StatusRequestEntityTooLarge, ErrMessage("слишком много заказов в запросе")); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err != nil {
+		h.log.Info("OrderHandler: can't read bulk upload", zap.Error(err))
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+
+	report, err := h.orderService.UploadOrders(ctx, userID, lines)
+	if err != nil {
+		var (
+			statusCode int
+			msg        string
+		)
+		switch {
+		case errors.Is(err, domain.ErrBadParam):
+			statusCode = http.StatusBadRequest
+			msg = "неверный формат запроса"
+		case errors.Is(err, domain.ErrTooManyOrders):
+			statusCode = http.StatusRequestEntityTooLarge
+			msg = "слишком много заказов в запросе"
+		default:
+			h.log.Error("OrderHandler: can't upload orders", zap.Error(err))
+			statusCode = http.StatusInternalServerError
+			msg = "внутренняя ошибка сервера"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	responseBody, err := json.Marshal(report)
+	if err != nil {
+		h.log.Error("OrderHandler: can't serialize response", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
+		h.log.Error("OrderHandler: can't write response", zap.Error(err))
+	}
+}
+
+func readCSVOrders(body io.Reader) ([]domain.OrderLine, error) {
+	var lines []domain.OrderLine
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		num := strings.TrimSpace(record[0])
+		if len(lines) == 0 && isCSVHeader(num) {
+			continue
+		}
+		lines = append(lines, domain.OrderLine{Line: line, Num: num})
+	}
+}
+
+func isCSVHeader(field string) bool {
+	switch strings.ToLower(field) {
+	case "number", "order", "num":
+		return true
+	}
+	return false
+}
+
+// readNDJSONOrders accepts lines with a number, a string or an object with the number field.
+func readNDJSONOrders(body io.Reader) ([]domain.OrderLine, error) {
+	return scanOrderLines(body, func(text string) string {
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return ""
+		}
+		if obj, ok := value.(map[string]interface{}); ok {
+			value = obj["number"]
+		}
+		switch v := value.(type) {
+		case string:
+			return strings.TrimSpace(v)
+		case json.Number:
+			return v.String()
+		}
+		return ""
+	})
+}
+
+func readPlainOrders(body io.Reader) ([]domain.OrderLine, error) {
+	return scanOrderLines(body, func(text string) string { return text })
+}
+
+// scanOrderLines reads order numbers from not empty lines.
+func scanOrderLines(body io.Reader, parse func(text string) string) (lines []domain.OrderLine, err error) {
+	scanner := bufio.NewScanner(body)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		lines = append(lines, domain.OrderLine{Line: line, Num: parse(text)})
+	}
+	return lines, scanner.Err()
+}
+
 func (h *OrderHandler) GetOrderList(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID, _, err := h.auth.GetFromContext(ctx)
@@ -108,24 +267,112 @@ func (h *OrderHandler) GetOrderList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.orderService.GetOrderList(ctx, userID)
+	filter, err := parseOrderFilter(r)
 	if err != nil {
+		h.log.Debug("OrderHandler: bad order list filter", zap.Error(err))
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+
+	res, err := h.orderService.GetOrderList(ctx, userID, filter)
+	if errors.Is(err, domain.ErrBadParam) {
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+	} else if err != nil {
 		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
 			h.log.Error("OrderHandler: can't write response", zap.Error(err))
 		}
-	} else if len(res) == 0 {
+	} else if len(res.Orders) == 0 {
+		w.Header().Set(TotalCountHeader, strconv.Itoa(res.Total))
 		if err = WriteResponse(w, http.StatusNoContent, ErrMessage("нет данных для ответа")); err != nil {
 			h.log.Error("OrderHandler: can't write response", zap.Error(err))
 		}
 	} else {
-		responseBody, err := json.Marshal(res)
+		responseBody, err := json.Marshal(res.Orders)
 		h.log.Debug("All orders:", zap.String("domain", string(responseBody)))
 		if err != nil {
 			h.log.Error("OrderHandler: can't serialize response", zap.Error(err))
 			return
 		}
+		w.Header().Set(TotalCountHeader, strconv.Itoa(res.Total))
+		if res.NextCursor != "" {
+			w.Header().Set(NextCursorHeader, res.NextCursor)
+		}
 		if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
 			h.log.Error("OrderHandler: can't write response", zap.Error(err))
 		}
 	}
 }
+
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("OrderHandler:can't get params from the token", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	res, err := h.orderService.GetOrder(ctx, userID, chi.URLParam(r, "number"))
+	if err != nil {
+		var (
+			statusCode int
+			msg        string
+		)
+		switch {
+		case errors.Is(err, domain.ErrOrderNotFound):
+			statusCode = http.StatusNotFound
+			msg = "заказ не найден"
+		case errors.Is(err, domain.ErrBadParam):
+			statusCode = http.StatusBadRequest
+			msg = "неверный формат запроса"
+		default:
+			statusCode = http.StatusInternalServerError
+			msg = "внутренняя ошибка сервера"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("OrderHandler: can't serialize response", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("OrderHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
+		h.log.Error("OrderHandler: can't write response", zap.Error(err))
+	}
+}
+
+func parseOrderFilter(r *http.Request) (filter domain.OrderFilter, err error) {
+	query := r.URL.Query()
+	if filter.UploadedFrom, err = parseTimeParam(query.Get("from")); err != nil {
+		return filter, err
+	}
+	if filter.UploadedTo, err = parseTimeParam(query.Get("to")); err != nil {
+		return filter, err
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return filter, err
+		}
+	}
+	switch query.Get("sort") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, fmt.Errorf("unknown sort direction %q", query.Get("sort"))
+	}
+	filter.Statuses = listParam(query["status"])
+	filter.Cursor = query.Get("cursor")
+	return filter, nil
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"github.com/da-semenov/gophermart/internal/app/domain"
 	"github.com/da-semenov/gophermart/internal/app/handlers/mocks"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -128,49 +131,100 @@ func TestOrderHandler_GetOrderList(t *testing.T) {
 	type wants struct {
 		responseCode int
 		contentType  string
+		totalCount   string
+		nextCursor   string
 	}
 	type args struct {
-		userID    int
-		objCount  int
-		wantError bool
-		err       error
+		userID     int
+		objCount   int
+		query      string
+		filter     domain.OrderFilter
+		nextCursor string
+		call       bool
+		err        error
 	}
 	tests := []struct {
 		name  string
 		wants wants
 		args  args
 	}{
-		{name: "OrderHandler. RegisterNewOrder. Test 1. Positive",
+		{name: "OrderHandler. GetOrderList. Test 1. Positive",
 			wants: wants{
 				responseCode: http.StatusOK,
 				contentType:  "application/json",
+				totalCount:   "5",
 			},
 			args: args{
-				wantError: false,
-				objCount:  5,
-				err:       nil,
+				objCount: 5,
+				call:     true,
+				err:      nil,
 			},
 		},
-		{name: "OrderHandler. RegisterNewOrder. Test 2. No data for response",
+		{name: "OrderHandler. GetOrderList. Test 2. No data for response",
 			wants: wants{
 				responseCode: http.StatusNoContent,
 				contentType:  "application/json",
+				totalCount:   "0",
 			},
 			args: args{
-				wantError: false,
-				objCount:  0,
-				err:       nil,
+				objCount: 0,
+				call:     true,
+				err:      nil,
 			},
 		},
-		{name: "OrderHandler. RegisterNewOrder. Test 3. Internal error",
+		{name: "OrderHandler. GetOrderList. Test 3. Internal error",
 			wants: wants{
 				responseCode: http.StatusInternalServerError,
 				contentType:  "application/json",
 			},
 			args: args{
-				wantError: false,
-				objCount:  0,
-				err:       errors.New("any error"),
+				objCount: 0,
+				call:     true,
+				err:      errors.New("any error"),
+			},
+		},
+		{name: "OrderHandler. GetOrderList. Test 4. Filters and next page",
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+				totalCount:   "5",
+				nextCursor:   "next",
+			},
+			args: args{
+				objCount: 5,
+				query:    "?status=NEW,PROCESSING&status=INVALID&from=2021-06-01&sort=desc&limit=5&cursor=prev",
+				filter: domain.OrderFilter{
+					Statuses:     []string{"NEW", "PROCESSING", "INVALID"},
+					UploadedFrom: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+					Descending:   true,
+					Cursor:       "prev",
+					Limit:        5,
+				},
+				nextCursor: "next",
+				call:       true,
+			},
+		},
+		{name: "OrderHandler. GetOrderList. Test 5. Unknown sort direction",
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+			args: args{
+				query: "?sort=random",
+			},
+		},
+		{name: "OrderHandler. GetOrderList. Test 6. Bad filter",
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+			args: args{
+				query: "?status=LOST",
+				filter: domain.OrderFilter{
+					Statuses: []string{"LOST"},
+				},
+				call: true,
+				err:  domain.ErrBadParam,
 			},
 		},
 	}
@@ -183,18 +237,233 @@ func TestOrderHandler_GetOrderList(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
-			orderService.EXPECT().
-				GetOrderList(ctx, tt.args.userID).
-				Return(generateOrderList(tt.args.objCount, tt.args.userID), tt.args.err)
+			if tt.args.call {
+				orderService.EXPECT().
+					GetOrderList(gomock.Any(), tt.args.userID, tt.args.filter).
+					Return(&domain.OrderList{
+						Orders:     generateOrderList(tt.args.objCount, tt.args.userID),
+						NextCursor: tt.args.nextCursor,
+						Total:      tt.args.objCount,
+					}, tt.args.err)
+			}
 
-			request := httptest.NewRequest("GET", "/api/user/orders", nil)
+			request := httptest.NewRequest("GET", "/api/user/orders"+tt.args.query, nil)
 			w := httptest.NewRecorder()
 			h := http.HandlerFunc(target.GetOrderList)
 			h.ServeHTTP(w, request)
 			res := w.Result()
 			defer res.Body.Close()
 
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
+			assert.Equal(t, tt.wants.totalCount, res.Header.Get(TotalCountHeader))
+			assert.Equal(t, tt.wants.nextCursor, res.Header.Get(NextCursorHeader))
+		})
+	}
+}
+
+func TestOrderHandler_GetOrder(t *testing.T) {
+	type args struct {
+		orderNum string
+		res      *domain.OrderDetail
+		error    error
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+		body         string
+	}
+	uploadAt := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "OrderHandler. GetOrder. Test 1. Positive",
+			args: args{
+				orderNum: "12345678903",
+				res: &domain.OrderDetail{
+					Order: domain.Order{Num: "12345678903", Status: "PROCESSED", Accrual: money.Rubles(500, 0), UploadAt: uploadAt},
+					History: []domain.OrderStatusChange{
+						{Status: "NEW", ChangedAt: uploadAt},
+						{Status: "PROCESSED", AccrualResponse: []byte(`{"order":"12345678903","status":"PROCESSED","accrual":500}`), ChangedAt: uploadAt.Add(time.Minute)},
+					},
+				},
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+				body: `{"number":"12345678903","status":"PROCESSED","accrual":500,"upload_at":"2021-06-15T12:00:00Z","history":[` +
+					`{"status":"NEW","changed_at":"2021-06-15T12:00:00Z"},` +
+					`{"status":"PROCESSED","accrual_response":{"order":"12345678903","status":"PROCESSED","accrual":500},"changed_at":"2021-06-15T12:01:00Z"}]}`,
+			},
+		},
+		{
+			name: "OrderHandler. GetOrder. Test 2. Order not found",
+			args: args{
+				orderNum: "12345678903",
+				error:    domain.ErrOrderNotFound,
+			},
+			wants: wants{
+				responseCode: http.StatusNotFound,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "OrderHandler. GetOrder. Test 3. Any error",
+			args: args{
+				orderNum: "12345678903",
+				error:    errors.New("any error"),
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	orderService := mocks.NewMockOrderService(mockCtrl)
+	target := NewOrderHandler(orderService, auth, log)
+	router := chi.NewRouter()
+	router.Get("/api/user/orders/{number}", target.GetOrder)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderService.EXPECT().GetOrder(gomock.Any(), 0, tt.args.orderNum).Return(tt.args.res, tt.args.error)
+
+			request := httptest.NewRequest("GET", "/api/user/orders/"+tt.args.orderNum, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
+			if tt.wants.body != "" {
+				body, err := io.ReadAll(res.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, tt.wants.body, string(body))
+			}
+		})
+	}
+}
+
+func TestOrderHandler_UploadOrders(t *testing.T) {
+	type args struct {
+		contentType string
+		body        string
+		lines       []domain.OrderLine
+		call        bool
+		error       error
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "OrderHandler. UploadOrders. Test 1. CSV with header",
+			args: args{
+				contentType: "text/csv; charset=utf-8",
+				body:        "number,comment\n12345678903,first\n\n79927398713\n",
+				lines:       []domain.OrderLine{{Line: 2, Num: "12345678903"}, {Line: 4, Num: "79927398713"}},
+				call:        true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "OrderHandler. UploadOrders. Test 2. NDJSON",
+			args: args{
+				contentType: "application/x-ndjson",
+				body:        "{\"number\": \"12345678903\"}\n79927398713\n\"4561261212345467\"\n[1]\n",
+				lines: []domain.OrderLine{
+					{Line: 1, Num: "12345678903"}, {Line: 2, Num: "79927398713"}, {Line: 3, Num: "4561261212345467"}, {Line: 4, Num: ""},
+				},
+				call: true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "OrderHandler. UploadOrders. Test 3. Plain text",
+			args: args{
+				contentType: "text/plain",
+				body:        "12345678903\r\n79927398713",
+				lines:       []domain.OrderLine{{Line: 1, Num: "12345678903"}, {Line: 2, Num: "79927398713"}},
+				call:        true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "OrderHandler. UploadOrders. Test 4. Unsupported content type",
+			args: args{
+				contentType: "application/json",
+				body:        "[\"12345678903\"]",
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "OrderHandler. UploadOrders. Test 5. Too many orders",
+			args: args{
+				contentType: "text/plain",
+				body:        "12345678903",
+				lines:       []domain.OrderLine{{Line: 1, Num: "12345678903"}},
+				call:        true,
+				error:       domain.ErrTooManyOrders,
+			},
+			wants: wants{
+				responseCode: http.StatusRequestEntityTooLarge,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "OrderHandler. UploadOrders. Test 6. Body too large",
+			args: args{
+				contentType: "text/plain",
+				body:        strings.Repeat("12345678903\n", bulkUploadMaxBytes/12+1),
+			},
+			wants: wants{
+				responseCode: http.StatusRequestEntityTooLarge,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	orderService := mocks.NewMockOrderService(mockCtrl)
+	target := NewOrderHandler(orderService, auth, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.call {
+				orderService.EXPECT().UploadOrders(gomock.Any(), 0, tt.args.lines).Return(&domain.BulkUploadReport{}, tt.args.error)
+			}
+
+			request := httptest.NewRequest("POST", "/api/user/orders/bulk", strings.NewReader(tt.args.body))
+			request.Header.Set("Content-Type", tt.args.contentType)
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(target.UploadOrders)
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
 			contentType := res.Header.Get("Content-type")
 			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
 			assert.Equal(t, tt.wants.contentType, contentType, "Expected status %d, got %d", tt.wants.contentType, contentType)
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type OutboxService interface {
+	GetFailedEvents(ctx context.Context) ([]domain.OutboxEventRecord, error)
+	RetryEvent(ctx context.Context, id int) error
+}
+
+// OutboxHandler lets the administrator see and retry outbox events the relay gave up on.
+type OutboxHandler struct {
+	outboxService OutboxService
+	log           *infrastructure.Logger
+}
+
+func NewOutboxHandler(os OutboxService, l *infrastructure.Logger) *OutboxHandler {
+	var target OutboxHandler
+	target.outboxService = os
+	target.log = l
+	return &target
+}
+
+func (h *OutboxHandler) GetFailedEvents(w http.ResponseWriter, r *http.Request) {
+	res, err := h.outboxService.GetFailedEvents(r.Context())
+	if err != nil {
+		h.log.Error("OutboxHandler:can't get failed events", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("OutboxHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("OutboxHandler: can't serialize response", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("OutboxHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
+		h.log.Error("OutboxHandler: can't write response", zap.Error(err))
+	}
+}
+
+func (h *OutboxHandler) RetryEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		h.log.Info("OutboxHandler:bad event id", zap.String("id", chi.URLParam(r, "id")))
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("OutboxHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = h.outboxService.RetryEvent(r.Context(), id); err != nil {
+		h.log.Error("OutboxHandler:RetryEvent error", zap.Int("id", id), zap.Error(err))
+		statusCode, msg := http.StatusInternalServerError, "внутренняя ошибка сервера"
+		if errors.Is(err, domain.ErrOutboxEventNotFound) {
+			statusCode, msg = http.StatusNotFound, "неопубликованное событие не найдено"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("OutboxHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, nil); err != nil {
+		h.log.Error("OutboxHandler: can't write response", zap.Error(err))
+	}
+	h.log.Info("Outbox event retried", zap.Int("id", id))
+}
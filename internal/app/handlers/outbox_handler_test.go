@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/handlers/mocks"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOutboxHandler_RetryEvent(t *testing.T) {
+	type args struct {
+		id    string
+		call  bool
+		error error
+	}
+	type wants struct {
+		responseCode int
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "OutboxHandler. RetryEvent. Test 1. Positive",
+			args: args{
+				id:   "7",
+				call: true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+			},
+		},
+		{
+			name: "OutboxHandler. RetryEvent. Test 2. Not found",
+			args: args{
+				id:    "7",
+				call:  true,
+				error: domain.ErrOutboxEventNotFound,
+			},
+			wants: wants{
+				responseCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "OutboxHandler. RetryEvent. Test 3. Bad id",
+			args: args{
+				id: "abc",
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OutboxHandler. RetryEvent. Test 4. Any error",
+			args: args{
+				id:    "7",
+				call:  true,
+				error: errors.New("any error"),
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	outboxService := mocks.NewMockOutboxService(mockCtrl)
+	target := NewOutboxHandler(outboxService, log)
+	router := chi.NewRouter()
+	router.Post("/api/admin/outbox/{id}/retry", target.RetryEvent)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.call {
+				outboxService.EXPECT().RetryEvent(gomock.Any(), 7).Return(tt.args.error)
+			}
+
+			request := httptest.NewRequest("POST", "/api/admin/outbox/"+tt.args.id+"/retry", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type TransferService interface {
+	Transfer(ctx context.Context, obj *domain.Transfer, userID int) error
+}
+
+type TransferHandler struct {
+	transferService TransferService
+	auth            *Auth
+	log             *infrastructure.Logger
+}
+
+func NewTransferHandler(ts TransferService, auth *Auth, l *infrastructure.Logger) *TransferHandler {
+	var target TransferHandler
+	target.transferService = ts
+	target.auth = auth
+	target.log = l
+	return &target
+}
+
+func (h *TransferHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	b, err := getRequestBody(r)
+	if err != nil {
+		h.log.Error("TransferHandler:can't get request body", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("TransferHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	var transfer domain.Transfer
+	if len(b) == 0 || r.Header.Get("Content-Type") != "application/json" || json.Unmarshal(b, &transfer) != nil {
+		h.log.Info("TransferHandler:bad request body")
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("TransferHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("TransferHandler:can't get params from the token", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("TransferHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	err = h.transferService.Transfer(ctx, &transfer, userID)
+	if err != nil {
+		var (
+			statusCode int
+			msg        string
+		)
+		h.log.Error("TransferHandler:Transfer error", zap.Error(err))
+		switch {
+		case errors.Is(err, domain.ErrBadParam):
+			statusCode = http.StatusBadRequest
+			msg = "неверный формат запроса"
+		case errors.Is(err, domain.ErrNotEnoughFunds):
+			statusCode = http.StatusPaymentRequired
+			msg = "на счету недостаточно средств"
+		case errors.Is(err, domain.ErrRecipientNotFound):
+			statusCode = http.StatusNotFound
+			msg = "получатель не найден"
+		case errors.Is(err, domain.ErrTransferLimitExceeded):
+			statusCode = http.StatusUnprocessableEntity
+			msg = "превышен лимит переводов"
+		default:
+			statusCode = http.StatusInternalServerError
+			msg = "внутренняя ошибка сервера"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("TransferHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, nil); err != nil {
+		h.log.Error("TransferHandler: can't write response", zap.Error(err))
+	}
+	h.log.Info("Transfer success", zap.String("login", transfer.Login), zap.Int("userID", userID))
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/handlers/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransferHandler_Transfer(t *testing.T) {
+	type args struct {
+		error error
+		body  string
+		call  bool
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "TransferHandler. Transfer. Test 1. Positive",
+			args: args{
+				body: "{\"login\": \"wife\",\"sum\": 100}",
+				call: true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "TransferHandler. Transfer. Test 2. NotEnoughFunds",
+			args: args{
+				error: domain.ErrNotEnoughFunds,
+				body:  "{\"login\": \"wife\",\"sum\": 100}",
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusPaymentRequired,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "TransferHandler. Transfer. Test 3. Recipient not found",
+			args: args{
+				error: domain.ErrRecipientNotFound,
+				body:  "{\"login\": \"nobody\",\"sum\": 100}",
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusNotFound,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "TransferHandler. Transfer. Test 4. Limit exceeded",
+			args: args{
+				error: domain.ErrTransferLimitExceeded,
+				body:  "{\"login\": \"wife\",\"sum\": 100000}",
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusUnprocessableEntity,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "TransferHandler. Transfer. Test 5. Any error",
+			args: args{
+				error: errors.New("any error"),
+				body:  "{\"login\": \"wife\",\"sum\": 100}",
+				call:  true,
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "TransferHandler. Transfer. Test 6. Bad request (empty body)",
+			args: args{
+				body: "",
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	transferService := mocks.NewMockTransferService(mockCtrl)
+	target := NewTransferHandler(transferService, auth, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.call {
+				transferService.EXPECT().Transfer(gomock.Any(), gomock.Any(), 0).Return(tt.args.error)
+			}
+			body := strings.NewReader(tt.args.body)
+			request := httptest.NewRequest("POST", "/api/user/balance/transfer", body)
+			request.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(target.Transfer)
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected content type %s, got %s", tt.wants.contentType, contentType)
+		})
+	}
+}
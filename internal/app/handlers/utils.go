@@ -7,6 +7,15 @@ import (
 	"github.com/go-chi/jwtauth/v5"
 	"io"
 	"net/http"
+	"strings"
+	"time"
+)
+
+// List endpoints report the number of matching rows and the cursor of the next page in headers,
+// so their body stays a plain array.
+const (
+	TotalCountHeader = "X-Total-Count"
+	NextCursorHeader = "X-Next-Cursor"
 )
 
 type Auth struct {
@@ -79,3 +88,27 @@ func bakeCookie(token string) (*http.Cookie, error) {
 	c.Value = token
 	return &c, nil
 }
+
+// parseTimeParam parses a query parameter given as RFC 3339 time or as a date, dates are UTC midnights.
+// An empty parameter gives the zero time.
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// listParam collects values of a repeated or comma separated query parameter.
+func listParam(values []string) (resList []string) {
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				resList = append(resList, item)
+			}
+		}
+	}
+	return resList
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, userID int, webhook *domain.Webhook) (*domain.Webhook, error)
+	GetWebhooks(ctx context.Context, userID int) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID int, id int) error
+	GetDeliveries(ctx context.Context, userID int, webhookID int) ([]domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, userID int, webhookID int, deliveryID int) (*domain.WebhookDelivery, error)
+}
+
+type WebhookHandler struct {
+	webhookService WebhookService
+	auth           *Auth
+	log            *infrastructure.Logger
+}
+
+func NewWebhookHandler(ws WebhookService, auth *Auth, l *infrastructure.Logger) *WebhookHandler {
+	var target WebhookHandler
+	target.webhookService = ws
+	target.auth = auth
+	target.log = l
+	return &target
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	b, err := getRequestBody(r)
+	if err != nil {
+		h.log.Error("WebhookHandler:can't get request body", zap.Error(err))
+		h.writeError(w, err)
+		return
+	}
+	var webhook domain.Webhook
+	if len(b) == 0 || r.Header.Get("Content-Type") != "application/json" || json.Unmarshal(b, &webhook) != nil {
+		h.log.Info("WebhookHandler:bad request body")
+		h.writeError(w, domain.ErrBadParam)
+		return
+	}
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("WebhookHandler:can't get params from the token", zap.Error(err))
+		h.writeError(w, err)
+		return
+	}
+	res, err := h.webhookService.CreateWebhook(ctx, userID, &webhook)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, res)
+}
+
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("WebhookHandler:can't get params from the token", zap.Error(err))
+		h.writeError(w, err)
+		return
+	}
+	res, err := h.webhookService.GetWebhooks(ctx, userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, res)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("WebhookHandler:can't get params from the token", zap.Error(err))
+		h.writeError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, domain.ErrBadParam)
+		return
+	}
+	if err = h.webhookService.DeleteWebhook(ctx, userID, id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, nil); err != nil {
+		h.log.Error("WebhookHandler: can't write response", zap.Error(err))
+	}
+}
+
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("WebhookHandler:can't get params from the token", zap.Error(err))
+		h.writeError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, domain.ErrBadParam)
+		return
+	}
+	res, err := h.webhookService.GetDeliveries(ctx, userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, res)
+}
+
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, _, err := h.auth.GetFromContext(ctx)
+	if err != nil {
+		h.log.Error("WebhookHandler:can't get params from the token", zap.Error(err))
+		h.writeError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, domain.ErrBadParam)
+		return
+	}
+	deliveryID, err := strconv.Atoi(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		h.writeError(w, domain.ErrBadParam)
+		return
+	}
+	res, err := h.webhookService.Redeliver(ctx, userID, id, deliveryID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusAccepted, res)
+}
+
+func (h *WebhookHandler) writeJSON(w http.ResponseWriter, statusCode int, res interface{}) {
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("WebhookHandler: can't serialize response", zap.Error(err))
+		h.writeError(w, err)
+		return
+	}
+	if err = WriteResponse(w, statusCode, responseBody); err != nil {
+		h.log.Error("WebhookHandler: can't write response", zap.Error(err))
+	}
+}
+
+func (h *WebhookHandler) writeError(w http.ResponseWriter, err error) {
+	var (
+		statusCode int
+		msg        string
+	)
+	switch {
+	case errors.Is(err, domain.ErrBadParam):
+		statusCode = http.StatusBadRequest
+		msg = "неверный формат запроса"
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		statusCode = http.StatusNotFound
+		msg = "вебхук не найден"
+	case errors.Is(err, domain.ErrWebhookDeliveryNotFound):
+		statusCode = http.StatusNotFound
+		msg = "доставка не найдена"
+	default:
+		statusCode = http.StatusInternalServerError
+		msg = "внутренняя ошибка сервера"
+	}
+	if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+		h.log.Error("WebhookHandler: can't write response", zap.Error(err))
+	}
+}
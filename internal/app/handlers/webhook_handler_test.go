@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/handlers/mocks"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookHandler_CreateWebhook(t *testing.T) {
+	type args struct {
+		body        string
+		contentType string
+		callService bool
+		error       error
+	}
+	type wants struct {
+		responseCode int
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "WebhookHandler. CreateWebhook. Test 1. Positive",
+			args: args{
+				body:        `{"url": "https://example.com/hook", "events": ["order.processed"]}`,
+				contentType: "application/json",
+				callService: true,
+			},
+			wants: wants{responseCode: http.StatusCreated},
+		},
+		{
+			name: "WebhookHandler. CreateWebhook. Test 2. Bad body",
+			args: args{
+				body:        `{"url": `,
+				contentType: "application/json",
+			},
+			wants: wants{responseCode: http.StatusBadRequest},
+		},
+		{
+			name: "WebhookHandler. CreateWebhook. Test 3. Bad url",
+			args: args{
+				body:        `{"url": "example"}`,
+				contentType: "application/json",
+				callService: true,
+				error:       domain.ErrBadParam,
+			},
+			wants: wants{responseCode: http.StatusBadRequest},
+		},
+		{
+			name: "WebhookHandler. CreateWebhook. Test 4. Any error",
+			args: args{
+				body:        `{"url": "https://example.com/hook"}`,
+				contentType: "application/json",
+				callService: true,
+				error:       errors.New("any error"),
+			},
+			wants: wants{responseCode: http.StatusInternalServerError},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	webhookService := mocks.NewMockWebhookService(mockCtrl)
+	target := NewWebhookHandler(webhookService, auth, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.callService {
+				var res *domain.Webhook
+				if tt.args.error == nil {
+					res = &domain.Webhook{ID: 1, URL: "https://example.com/hook", Secret: "secret"}
+				}
+				webhookService.EXPECT().CreateWebhook(gomock.Any(), gomock.Any(), gomock.Any()).Return(res, tt.args.error)
+			}
+			request := httptest.NewRequest("POST", "/api/user/webhooks", bytes.NewBufferString(tt.args.body))
+			request.Header.Set("Content-Type", tt.args.contentType)
+			w := httptest.NewRecorder()
+			h := http.HandlerFunc(target.CreateWebhook)
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, "application/json", res.Header.Get("Content-type"))
+		})
+	}
+}
+
+func TestWebhookHandler_GetDeliveries(t *testing.T) {
+	type args struct {
+		webhookID   string
+		callService bool
+		error       error
+	}
+	type wants struct {
+		responseCode int
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name:  "WebhookHandler. GetDeliveries. Test 1. Positive",
+			args:  args{webhookID: "1", callService: true},
+			wants: wants{responseCode: http.StatusOK},
+		},
+		{
+			name:  "WebhookHandler. GetDeliveries. Test 2. Webhook not found",
+			args:  args{webhookID: "2", callService: true, error: domain.ErrWebhookNotFound},
+			wants: wants{responseCode: http.StatusNotFound},
+		},
+		{
+			name:  "WebhookHandler. GetDeliveries. Test 3. Bad id",
+			args:  args{webhookID: "abc"},
+			wants: wants{responseCode: http.StatusBadRequest},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	webhookService := mocks.NewMockWebhookService(mockCtrl)
+	target := NewWebhookHandler(webhookService, auth, log)
+	router := chi.NewRouter()
+	router.Get("/api/user/webhooks/{id}/deliveries", target.GetDeliveries)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.callService {
+				webhookService.EXPECT().GetDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]domain.WebhookDelivery{{ID: 1, Status: "DELIVERED"}}, tt.args.error)
+			}
+			request := httptest.NewRequest("GET", "/api/user/webhooks/"+tt.args.webhookID+"/deliveries", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+		})
+	}
+}
+
+func TestWebhookHandler_Redeliver(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	webhookService := mocks.NewMockWebhookService(mockCtrl)
+	target := NewWebhookHandler(webhookService, auth, log)
+	router := chi.NewRouter()
+	router.Post("/api/user/webhooks/{id}/deliveries/{deliveryID}/redeliver", target.Redeliver)
+
+	webhookService.EXPECT().Redeliver(gomock.Any(), gomock.Any(), 1, 7).Return(&domain.WebhookDelivery{ID: 8}, nil)
+	request := httptest.NewRequest("POST", "/api/user/webhooks/1/deliveries/7/redeliver", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	webhookService.EXPECT().Redeliver(gomock.Any(), gomock.Any(), 1, 9).Return(nil, domain.ErrWebhookDeliveryNotFound)
+	request = httptest.NewRequest("POST", "/api/user/webhooks/1/deliveries/9/redeliver", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	res = w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type WithdrawalService interface {
+	GetWithdrawals(ctx context.Context, status string) ([]domain.WithdrawalRecord, error)
+	ConfirmWithdrawal(ctx context.Context, id int) (*domain.WithdrawalRecord, error)
+	RejectWithdrawal(ctx context.Context, id int, reason string) (*domain.WithdrawalRecord, error)
+	ReverseWithdrawal(ctx context.Context, id int, reason string) (*domain.WithdrawalRecord, error)
+}
+
+// WithdrawalHandler serves the payment side confirming or cancelling withdrawals.
+type WithdrawalHandler struct {
+	withdrawalService WithdrawalService
+	log               *infrastructure.Logger
+}
+
+func NewWithdrawalHandler(ws WithdrawalService, l *infrastructure.Logger) *WithdrawalHandler {
+	var target WithdrawalHandler
+	target.withdrawalService = ws
+	target.log = l
+	return &target
+}
+
+func (h *WithdrawalHandler) GetWithdrawals(w http.ResponseWriter, r *http.Request) {
+	res, err := h.withdrawalService.GetWithdrawals(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		h.log.Error("WithdrawalHandler:can't get withdrawals", zap.Error(err))
+		statusCode, msg := http.StatusInternalServerError, "внутренняя ошибка сервера"
+		if errors.Is(err, domain.ErrBadParam) {
+			statusCode, msg = http.StatusBadRequest, "неверный формат запроса"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("WithdrawalHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	h.writeJSON(w, res)
+}
+
+func (h *WithdrawalHandler) ConfirmWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.move(w, r, func(ctx context.Context, id int, reason string) (*domain.WithdrawalRecord, error) {
+		return h.withdrawalService.ConfirmWithdrawal(ctx, id)
+	})
+}
+
+func (h *WithdrawalHandler) RejectWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.move(w, r, h.withdrawalService.RejectWithdrawal)
+}
+
+func (h *WithdrawalHandler) ReverseWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.move(w, r, h.withdrawalService.ReverseWithdrawal)
+}
+
+func (h *WithdrawalHandler) move(w http.ResponseWriter, r *http.Request,
+	fn func(ctx context.Context, id int, reason string) (*domain.WithdrawalRecord, error)) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		h.log.Info("WithdrawalHandler:bad withdrawal id", zap.String("id", chi.URLParam(r, "id")))
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("WithdrawalHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	var action domain.WithdrawalAction
+	b, err := getRequestBody(r)
+	if err == nil && len(b) > 0 {
+		err = json.Unmarshal(b, &action)
+	}
+	if err != nil {
+		h.log.Info("WithdrawalHandler:bad request body", zap.Error(err))
+		if err = WriteResponse(w, http.StatusBadRequest, ErrMessage("неверный формат запроса")); err != nil {
+			h.log.Error("WithdrawalHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	res, err := fn(r.Context(), id, action.Reason)
+	if err != nil {
+		var (
+			statusCode int
+			msg        string
+		)
+		h.log.Error("WithdrawalHandler:move error", zap.Int("id", id), zap.Error(err))
+		switch {
+		case errors.Is(err, domain.ErrWithdrawalNotFound):
+			statusCode = http.StatusNotFound
+			msg = "списание не найдено"
+		case errors.Is(err, domain.ErrWithdrawalState):
+			statusCode = http.StatusConflict
+			msg = "недопустимый переход статуса списания"
+		default:
+			statusCode = http.StatusInternalServerError
+			msg = "внутренняя ошибка сервера"
+		}
+		if err = WriteResponse(w, statusCode, ErrMessage(msg)); err != nil {
+			h.log.Error("WithdrawalHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	h.writeJSON(w, res)
+}
+
+func (h *WithdrawalHandler) writeJSON(w http.ResponseWriter, res interface{}) {
+	responseBody, err := json.Marshal(res)
+	if err != nil {
+		h.log.Error("WithdrawalHandler: can't serialize response", zap.Error(err))
+		if err = WriteResponse(w, http.StatusInternalServerError, ErrMessage("внутренняя ошибка сервера")); err != nil {
+			h.log.Error("WithdrawalHandler: can't write response", zap.Error(err))
+		}
+		return
+	}
+	if err = WriteResponse(w, http.StatusOK, responseBody); err != nil {
+		h.log.Error("WithdrawalHandler: can't write response", zap.Error(err))
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/handlers/mocks"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithdrawalHandler_RejectWithdrawal(t *testing.T) {
+	type args struct {
+		id    string
+		body  string
+		call  bool
+		error error
+	}
+	type wants struct {
+		responseCode int
+		contentType  string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "WithdrawalHandler. RejectWithdrawal. Test 1. Positive",
+			args: args{
+				id:   "7",
+				body: `{"reason":"payment declined"}`,
+				call: true,
+			},
+			wants: wants{
+				responseCode: http.StatusOK,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "WithdrawalHandler. RejectWithdrawal. Test 2. Not found",
+			args: args{
+				id:    "7",
+				call:  true,
+				error: domain.ErrWithdrawalNotFound,
+			},
+			wants: wants{
+				responseCode: http.StatusNotFound,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "WithdrawalHandler. RejectWithdrawal. Test 3. Already confirmed",
+			args: args{
+				id:    "7",
+				call:  true,
+				error: domain.ErrWithdrawalState,
+			},
+			wants: wants{
+				responseCode: http.StatusConflict,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "WithdrawalHandler. RejectWithdrawal. Test 4. Bad id",
+			args: args{
+				id: "abc",
+			},
+			wants: wants{
+				responseCode: http.StatusBadRequest,
+				contentType:  "application/json",
+			},
+		},
+		{
+			name: "WithdrawalHandler. RejectWithdrawal. Test 5. Any error",
+			args: args{
+				id:    "7",
+				call:  true,
+				error: errors.New("any error"),
+			},
+			wants: wants{
+				responseCode: http.StatusInternalServerError,
+				contentType:  "application/json",
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	withdrawalService := mocks.NewMockWithdrawalService(mockCtrl)
+	target := NewWithdrawalHandler(withdrawalService, log)
+	router := chi.NewRouter()
+	router.Post("/api/admin/withdrawals/{id}/reject", target.RejectWithdrawal)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.call {
+				var res *domain.WithdrawalRecord
+				if tt.args.error == nil {
+					res = &domain.WithdrawalRecord{ID: 7, Status: "REJECTED", Reason: "payment declined"}
+				}
+				withdrawalService.EXPECT().RejectWithdrawal(gomock.Any(), 7, gomock.Any()).Return(res, tt.args.error)
+			}
+
+			request := httptest.NewRequest("POST", "/api/admin/withdrawals/"+tt.args.id+"/reject", strings.NewReader(tt.args.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			contentType := res.Header.Get("Content-type")
+			assert.Equal(t, tt.wants.responseCode, res.StatusCode, "Expected status %d, got %d", tt.wants.responseCode, res.StatusCode)
+			assert.Equal(t, tt.wants.contentType, contentType, "Expected content type %s, got %s", tt.wants.contentType, contentType)
+		})
+	}
+}
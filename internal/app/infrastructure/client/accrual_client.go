@@ -9,12 +9,15 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
 	AccrualClientRequestTimeout = 25 * time.Second
 	AccrualClientURL            = "/api/orders/"
+	AccrualDefaultRetryAfter    = 60 * time.Second
 )
 
 type AccrualClient struct {
@@ -46,7 +49,7 @@ func (c *AccrualClient) GetAccrual(ctx context.Context, orderNum string) (*domai
 		u.Host = "localhost"
 	}
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		c.log.Error("AccrualClient: GetAccrual. Can't build request", zap.Error(err))
 		return nil, err
@@ -71,12 +74,38 @@ func (c *AccrualClient) GetAccrual(ctx context.Context, orderNum string) (*domai
 			c.log.Error("AccrualClient: GetAccrual. Can't unmarshal request  body", zap.Error(err))
 			return nil, err
 		}
+		accrual.Response = body
 		return &accrual, nil
+	} else if resp.StatusCode == http.StatusNoContent {
+		c.log.Warn("AccrualClient: GetAccrual. Order is not registered", zap.String("orderNum", orderNum))
+		return nil, domain.ErrOrderNotRegistered
 	} else if resp.StatusCode == http.StatusTooManyRequests {
-		c.log.Error("AccrualClient: GetAccrual.Too many requests:", zap.Int("statusCode", resp.StatusCode))
-		return nil, domain.ErrTooManyRequest
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		c.log.Warn("AccrualClient: GetAccrual.Too many requests:", zap.Int("statusCode", resp.StatusCode), zap.Duration("retryAfter", retryAfter))
+		return nil, &domain.TooManyRequestError{RetryAfter: retryAfter}
 	}
 
 	c.log.Error("AccrualClient: GetAccrual.Unexpected response from remote service:", zap.Int("statusCode", resp.StatusCode))
 	return nil, domain.ErrRemoteServiceError
 }
+
+// parseRetryAfter supports both delay-seconds and HTTP-date forms of the Retry-After header.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return AccrualDefaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return AccrualDefaultRetryAfter
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return AccrualDefaultRetryAfter
+}
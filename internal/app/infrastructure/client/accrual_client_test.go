@@ -0,0 +1,49 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{
+			name:  "AccrualClient. parseRetryAfter. Test 1. Seconds",
+			value: "60",
+			want:  60 * time.Second,
+		},
+		{
+			name:  "AccrualClient. parseRetryAfter. Test 2. Empty header",
+			value: "",
+			want:  AccrualDefaultRetryAfter,
+		},
+		{
+			name:  "AccrualClient. parseRetryAfter. Test 3. HTTP date",
+			value: now.Add(90 * time.Second).Format(http.TimeFormat),
+			want:  90 * time.Second,
+		},
+		{
+			name:  "AccrualClient. parseRetryAfter. Test 4. Date in the past",
+			value: now.Add(-time.Minute).Format(http.TimeFormat),
+			want:  0,
+		},
+		{
+			name:  "AccrualClient. parseRetryAfter. Test 5. Garbage",
+			value: "soon",
+			want:  AccrualDefaultRetryAfter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
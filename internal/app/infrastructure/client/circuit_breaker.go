@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+type AccrualGetter interface {
+	GetAccrual(ctx context.Context, orderNum string) (*domain.Accrual, error)
+}
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit.
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before trial calls are let through.
+	CoolDown time.Duration
+	// HalfOpenCalls limits concurrent trial calls in the half-open state.
+	HalfOpenCalls int
+}
+
+// CircuitBreaker fails fast with domain.ErrCircuitOpen while the accrual system keeps failing,
+// instead of waiting for the request timeout on every call.
+type CircuitBreaker struct {
+	next          AccrualGetter
+	log           *infrastructure.Logger
+	config        CircuitBreakerConfig
+	mu            sync.Mutex
+	state         string
+	failures      int
+	openedAt      time.Time
+	halfOpenCalls int
+	now           func() time.Time
+}
+
+func NewCircuitBreaker(next AccrualGetter, log *infrastructure.Logger, config CircuitBreakerConfig) *CircuitBreaker {
+	var target CircuitBreaker
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 1
+	}
+	if config.HalfOpenCalls <= 0 {
+		config.HalfOpenCalls = 1
+	}
+	target.next = next
+	target.log = log
+	target.config = config
+	target.state = domain.CircuitClosed
+	target.now = time.Now
+	return &target
+}
+
+func (b *CircuitBreaker) GetAccrual(ctx context.Context, orderNum string) (*domain.Accrual, error) {
+	allowed, trial := b.allow()
+	if !allowed {
+		return nil, domain.ErrCircuitOpen
+	}
+	accrual, err := b.next.GetAccrual(ctx, orderNum)
+	switch {
+	case err == nil:
+		b.record(false)
+	case isRemoteFailure(err):
+		b.record(true)
+	case trial:
+		b.release()
+	}
+	return accrual, err
+}
+
+// isRemoteFailure tells whether the error means the accrual system is unavailable.
+// Rate limiting, unknown orders and cancelled calls say nothing about its health, so they change nothing.
+func isRemoteFailure(err error) bool {
+	return !errors.Is(err, domain.ErrTooManyRequest) &&
+		!errors.Is(err, domain.ErrOrderNotRegistered) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// allow reports whether the call may go through and whether it is a trial call of the half-open circuit.
+func (b *CircuitBreaker) allow() (bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case domain.CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.config.CoolDown {
+			return false, false
+		}
+		b.setState(domain.CircuitHalfOpen)
+		b.halfOpenCalls = 0
+		fallthrough
+	case domain.CircuitHalfOpen:
+		if b.halfOpenCalls >= b.config.HalfOpenCalls {
+			return false, false
+		}
+		b.halfOpenCalls++
+		return true, true
+	}
+	return true, false
+}
+
+// release gives back the slot of a trial call which said nothing about the health of the accrual system.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == domain.CircuitHalfOpen && b.halfOpenCalls > 0 {
+		b.halfOpenCalls--
+	}
+}
+
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		if b.state != domain.CircuitClosed {
+			b.setState(domain.CircuitClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == domain.CircuitHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.openedAt = b.now()
+		if b.state != domain.CircuitOpen {
+			b.setState(domain.CircuitOpen)
+		}
+	}
+}
+
+func (b *CircuitBreaker) setState(state string) {
+	b.log.Warn("CircuitBreaker: state changed", zap.String("from", b.state), zap.String("to", state), zap.Int("failures", b.failures))
+	b.state = state
+}
+
+func (b *CircuitBreaker) Status() domain.CircuitBreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := domain.CircuitBreakerStatus{
+		State:    b.state,
+		Failures: b.failures,
+	}
+	if b.state != domain.CircuitClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+type accrualGetterStub struct {
+	calls int
+	err   error
+}
+
+func (s *accrualGetterStub) GetAccrual(ctx context.Context, orderNum string) (*domain.Accrual, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.Accrual{Order: orderNum}, nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+	next := &accrualGetterStub{err: domain.ErrRemoteServiceError}
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	target := NewCircuitBreaker(next, logger, CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Minute, HalfOpenCalls: 1})
+	target.now = func() time.Time { return now }
+
+	_, err := target.GetAccrual(ctx, "1")
+	assert.ErrorIs(t, err, domain.ErrRemoteServiceError)
+	assert.Equal(t, domain.CircuitClosed, target.Status().State, "single failure must not open the circuit")
+
+	next.err = &domain.TooManyRequestError{RetryAfter: time.Minute}
+	_, _ = target.GetAccrual(ctx, "1")
+	assert.Equal(t, 1, target.Status().Failures, "too many requests must not reset failures")
+
+	next.err = domain.ErrRemoteServiceError
+	_, _ = target.GetAccrual(ctx, "1")
+	assert.Equal(t, domain.CircuitOpen, target.Status().State, "consecutive failures must open the circuit")
+
+	_, err = target.GetAccrual(ctx, "1")
+	assert.ErrorIs(t, err, domain.ErrCircuitOpen)
+	assert.ErrorIs(t, err, domain.ErrRemoteServiceError)
+	assert.Equal(t, 3, next.calls, "open circuit must fail fast")
+
+	now = now.Add(time.Minute)
+	_, _ = target.GetAccrual(ctx, "1")
+	assert.Equal(t, 4, next.calls, "trial call expected after the cool down")
+	assert.Equal(t, domain.CircuitOpen, target.Status().State, "failed trial call must open the circuit again")
+
+	now = now.Add(time.Minute)
+	next.err = nil
+	accrual, err := target.GetAccrual(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", accrual.Order)
+	assert.Equal(t, domain.CircuitClosed, target.Status().State, "successful trial call must close the circuit")
+}
+
+func TestCircuitBreaker_HalfOpenCalls(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	target := NewCircuitBreaker(&accrualGetterStub{}, logger, CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Minute, HalfOpenCalls: 2})
+	target.now = func() time.Time { return now }
+	target.record(true)
+
+	allowed, _ := target.allow()
+	assert.False(t, allowed, "open circuit must reject calls")
+	now = now.Add(time.Minute)
+	allowed, trial := target.allow()
+	assert.True(t, allowed && trial, "first trial call must be allowed")
+	allowed, trial = target.allow()
+	assert.True(t, allowed && trial, "second trial call must be allowed")
+	allowed, _ = target.allow()
+	assert.False(t, allowed, "trial calls over the limit must be rejected")
+	assert.Equal(t, domain.CircuitHalfOpen, target.Status().State)
+}
+
+func TestCircuitBreaker_HalfOpenNeutralCall(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+	next := &accrualGetterStub{err: &domain.TooManyRequestError{RetryAfter: time.Minute}}
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	target := NewCircuitBreaker(next, logger, CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Minute, HalfOpenCalls: 1})
+	target.now = func() time.Time { return now }
+	target.record(true)
+	now = now.Add(time.Minute)
+
+	_, err := target.GetAccrual(ctx, "1")
+	assert.ErrorIs(t, err, domain.ErrTooManyRequest)
+	assert.Equal(t, domain.CircuitHalfOpen, target.Status().State, "too many requests must not close the circuit")
+	assert.Equal(t, 1, target.Status().Failures)
+
+	next.err = domain.ErrOrderNotRegistered
+	_, err = target.GetAccrual(ctx, "1")
+	assert.ErrorIs(t, err, domain.ErrOrderNotRegistered, "the trial slot must be given back")
+	assert.Equal(t, domain.CircuitHalfOpen, target.Status().State, "unknown order must not close the circuit")
+
+	next.err = nil
+	_, err = target.GetAccrual(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CircuitClosed, target.Status().State, "successful trial call must close the circuit")
+	assert.Equal(t, 3, next.calls)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const WebhookClientRequestTimeout = 10 * time.Second
+
+// WebhookResponseMaxSize limits the part of the response body read before the connection is reused.
+const WebhookResponseMaxSize = 64 << 10
+
+// ErrAddressNotAllowed is returned when the webhook host resolves to an address of the service network.
+var ErrAddressNotAllowed = errors.New("webhook address is not allowed")
+
+type WebhookClient struct {
+	log     *infrastructure.Logger
+	client  *http.Client
+	allowIP func(ip net.IP) bool
+}
+
+// NewWebhookClient creates a client that connects only to public addresses and doesn't follow redirects,
+// so user supplied URLs can't reach internal services.
+func NewWebhookClient(log *infrastructure.Logger) *WebhookClient {
+	var target WebhookClient
+	target.log = log
+	target.allowIP = infrastructure.PublicIP
+	dialer := &net.Dialer{Timeout: WebhookClientRequestTimeout, Control: target.checkAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	target.client = &http.Client{
+		Timeout:   WebhookClientRequestTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &target
+}
+
+// checkAddress is called with the resolved address before connecting, so a host name can't point to an internal one.
+func (c *WebhookClient) checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !c.allowIP(ip) {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+	}
+	return nil
+}
+
+func (c *WebhookClient) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		c.log.Error("WebhookClient: Send. Can't build request", zap.Error(err))
+		return 0, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.log.Warn("WebhookClient: Send. Can't execute request", zap.String("url", url), zap.Error(err))
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, WebhookResponseMaxSize))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookClient_Send(t *testing.T) {
+	var redirected bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	ctx := context.Background()
+
+	t.Run("WebhookClient. Send. Test 1. Internal address", func(t *testing.T) {
+		target := NewWebhookClient(zap.NewNop())
+		_, err := target.Send(ctx, server.URL+"/hook", nil, []byte("{}"))
+		assert.ErrorIs(t, err, ErrAddressNotAllowed)
+	})
+	t.Run("WebhookClient. Send. Test 2. Redirect is not followed", func(t *testing.T) {
+		target := NewWebhookClient(zap.NewNop())
+		target.allowIP = func(ip net.IP) bool { return true }
+		code, err := target.Send(ctx, server.URL+"/hook", nil, []byte("{}"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusFound, code)
+		assert.False(t, redirected)
+	})
+}
+
+func TestWebhookClient_checkAddress(t *testing.T) {
+	target := NewWebhookClient(zap.NewNop())
+	tests := []struct {
+		name    string
+		ip      string
+		allowed bool
+	}{
+		{name: "public", ip: "93.184.216.34", allowed: true},
+		{name: "public IPv6", ip: "2606:2800:220:1:248:1893:25c8:1946", allowed: true},
+		{name: "loopback", ip: "127.0.0.1"},
+		{name: "private", ip: "10.1.2.3"},
+		{name: "link-local", ip: "169.254.169.254"},
+		{name: "unspecified", ip: "0.0.0.0"},
+		{name: "this network", ip: "0.1.2.3"},
+		{name: "carrier-grade NAT", ip: "100.64.0.1"},
+		{name: "carrier-grade NAT end", ip: "100.127.255.254"},
+		{name: "protocol assignments", ip: "192.0.0.8"},
+		{name: "documentation 1", ip: "192.0.2.1"},
+		{name: "6to4 relay", ip: "192.88.99.1"},
+		{name: "benchmarking", ip: "198.19.0.1"},
+		{name: "documentation 2", ip: "198.51.100.1"},
+		{name: "documentation 3", ip: "203.0.113.1"},
+		{name: "multicast", ip: "224.0.0.1"},
+		{name: "reserved", ip: "240.0.0.1"},
+		{name: "broadcast", ip: "255.255.255.255"},
+		{name: "IPv4-mapped private", ip: "::ffff:10.0.0.1"},
+		{name: "IPv6 loopback", ip: "::1"},
+		{name: "IPv6 unique local", ip: "fd00::1"},
+		{name: "IPv6 link-local", ip: "fe80::1"},
+		{name: "IPv6 multicast", ip: "ff02::1"},
+		{name: "NAT64", ip: "64:ff9b::a00:1"},
+		{name: "local NAT64", ip: "64:ff9b:1::1"},
+		{name: "discard", ip: "100::1"},
+		{name: "IETF protocol assignments", ip: "2001::1"},
+		{name: "IPv6 documentation", ip: "2001:db8::1"},
+		{name: "6to4", ip: "2002:a00:1::1"},
+	}
+	for _, tt := range tests {
+		t.Run("WebhookClient. checkAddress. "+tt.name, func(t *testing.T) {
+			err := target.checkAddress("tcp", net.JoinHostPort(tt.ip, "443"), nil)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrAddressNotAllowed)
+			}
+		})
+	}
+}
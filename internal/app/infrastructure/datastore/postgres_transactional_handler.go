@@ -3,19 +3,20 @@ package datastore
 import (
 	"context"
 	"errors"
-	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure"
 	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
-	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
+	"sync"
 	"time"
 )
 
 type PostgresHandlerTX struct {
-	pool *pgxpool.Pool
-	log  *infrastructure.Logger
+	pool  *pgxpool.Pool
+	locks *lockSession
+	log   *infrastructure.Logger
 }
 
 func NewPostgresHandlerTX(ctx context.Context, dataSource string, log *infrastructure.Logger) (*PostgresHandlerTX, error) {
@@ -23,7 +24,7 @@ func NewPostgresHandlerTX(ctx context.Context, dataSource string, log *infrastru
 	if err != nil {
 		return nil, err
 	}
-	poolConfig.MaxConns = 5
+	poolConfig.MaxConns = 10
 	poolConfig.MinConns = 2
 	poolConfig.MaxConnIdleTime = time.Second * 120
 	pool, err := pgxpool.ConnectConfig(ctx, poolConfig)
@@ -32,6 +33,7 @@ func NewPostgresHandlerTX(ctx context.Context, dataSource string, log *infrastru
 	}
 	postgresHandler := new(PostgresHandlerTX)
 	postgresHandler.pool = pool
+	postgresHandler.locks = &lockSession{config: poolConfig.ConnConfig.Copy(), log: log}
 	postgresHandler.log = log
 	return postgresHandler, nil
 }
@@ -106,40 +108,35 @@ func (handler *PostgresHandlerTX) Execute(ctx context.Context, statement string,
 	return err
 }
 
+// ExecuteBatch sends the statement with every argument set in one round trip and stops at the first failed one.
 func (handler *PostgresHandlerTX) ExecuteBatch(ctx context.Context, statement string, args [][]interface{}) error {
-	var (
-		err error
-		ct  pgconn.CommandTag
-		br  pgx.BatchResults
-	)
-
-	batch := &pgx.Batch{}
-	if len(args) > 0 {
-		for _, argset := range args {
-			batch.Queue(statement, argset...)
-		}
-	} else {
+	if len(args) == 0 {
 		return nil
 	}
-	tx, err := handler.getTx(ctx)
+	batch := &pgx.Batch{}
+	for _, argset := range args {
+		batch.Queue(statement, argset...)
+	}
 
+	var br pgx.BatchResults
+	tx, err := handler.getTx(ctx)
 	if err == nil {
-		br = tx.SendBatch(context.Background(), batch)
+		br = tx.SendBatch(ctx, batch)
 	} else {
 		conn, err := handler.pool.Acquire(ctx)
 		if err != nil {
 			return err
 		}
 		defer conn.Release()
-		br = conn.SendBatch(context.Background(), batch)
+		br = conn.SendBatch(ctx, batch)
 	}
-	ct, err = br.Exec()
-
-	if err != nil {
-		return err
+	defer br.Close()
+	for range args {
+		if _, err = br.Exec(); err != nil {
+			return err
+		}
 	}
-	fmt.Println(ct.RowsAffected())
-	return nil
+	return br.Close()
 }
 
 func (handler *PostgresHandlerTX) QueryRow(ctx context.Context, statement string, args ...interface{}) (basedbhandler.Row, error) {
@@ -152,15 +149,10 @@ func (handler *PostgresHandlerTX) QueryRow(ctx context.Context, statement string
 			row = tx.QueryRow(ctx, statement)
 		}
 	} else {
-		conn, err := handler.pool.Acquire(ctx)
-		if err != nil {
-			return nil, err
-		}
-		defer conn.Release()
 		if len(args) > 0 {
-			row = conn.QueryRow(ctx, statement, args...)
+			row = handler.pool.QueryRow(ctx, statement, args...)
 		} else {
-			row = conn.QueryRow(ctx, statement)
+			row = handler.pool.QueryRow(ctx, statement)
 		}
 	}
 	return row, nil
@@ -176,17 +168,11 @@ func (handler *PostgresHandlerTX) Query(ctx context.Context, statement string, a
 			rows, err = tx.Query(ctx, statement)
 		}
 	} else {
-		conn, e := handler.pool.Acquire(ctx)
-		if e != nil {
-			return nil, e
-		}
-		defer conn.Release()
 		if len(args) > 0 {
-			rows, e = conn.Query(ctx, statement, args...)
+			rows, err = handler.pool.Query(ctx, statement, args...)
 		} else {
-			rows, e = conn.Query(ctx, statement)
+			rows, err = handler.pool.Query(ctx, statement)
 		}
-		err = e
 	}
 	if err != nil {
 		return nil, err
@@ -194,8 +180,93 @@ func (handler *PostgresHandlerTX) Query(ctx context.Context, statement string, a
 	return rows, nil
 }
 
+// TryAdvisoryLock takes the lock on the dedicated lock session, so holders of job locks don't keep connections
+// out of the pool.
+func (handler *PostgresHandlerTX) TryAdvisoryLock(ctx context.Context, key int64) (basedbhandler.AdvisoryLock, error) {
+	return handler.locks.tryLock(ctx, key)
+}
+
+var errLockSessionLost = errors.New("advisory lock session lost")
+
+// lockSession is a single connection opened outside the pool which holds advisory locks of all jobs of the instance.
+// Locks are lost with the connection, a new one is opened on the next attempt to take a lock.
+type lockSession struct {
+	config *pgx.ConnConfig
+	log    *infrastructure.Logger
+	mu     sync.Mutex
+	conn   *pgx.Conn
+	// generation changes with every new connection, locks of previous connections are lost.
+	generation int
+}
+
+func (s *lockSession) tryLock(ctx context.Context, key int64) (basedbhandler.AdvisoryLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil || s.conn.IsClosed() {
+		conn, err := pgx.ConnectConfig(ctx, s.config)
+		if err != nil {
+			return nil, err
+		}
+		s.conn = conn
+		s.generation++
+	}
+	var acquired bool
+	err := s.conn.QueryRow(ctx, dbqueries.TryAdvisoryLock, key).Scan(&acquired)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, nil
+	}
+	return &advisoryLock{session: s, key: key, generation: s.generation}, nil
+}
+
+// alive reports whether the lock taken on the connection of the generation is still held, s.mu must be held.
+func (s *lockSession) alive(generation int) bool {
+	return s.conn != nil && !s.conn.IsClosed() && s.generation == generation
+}
+
+func (s *lockSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		_ = s.conn.Close(context.Background())
+		s.conn = nil
+	}
+}
+
+type advisoryLock struct {
+	session    *lockSession
+	key        int64
+	generation int
+}
+
+func (l *advisoryLock) Check(ctx context.Context) error {
+	l.session.mu.Lock()
+	defer l.session.mu.Unlock()
+	if !l.session.alive(l.generation) {
+		return errLockSessionLost
+	}
+	_, err := l.session.conn.Exec(ctx, dbqueries.CheckConnection)
+	return err
+}
+
+func (l *advisoryLock) Release(ctx context.Context) error {
+	l.session.mu.Lock()
+	defer l.session.mu.Unlock()
+	if !l.session.alive(l.generation) {
+		return nil
+	}
+	_, err := l.session.conn.Exec(ctx, dbqueries.AdvisoryUnlock, l.key)
+	if err != nil {
+		l.session.log.Error("PostgresHandlerTX: can't release advisory lock", zap.Int64("key", l.key), zap.Error(err))
+	}
+	return err
+}
+
 func (handler *PostgresHandlerTX) Close() {
 	if handler != nil {
+		handler.locks.close()
 		handler.pool.Close()
 	}
 }
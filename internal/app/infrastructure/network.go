@@ -0,0 +1,50 @@
+package infrastructure
+
+import "net"
+
+// reservedNetworks are special purpose ranges the stdlib predicates don't cover: shared address space of carrier-grade
+// NAT, "this network", protocol assignments, documentation, benchmarking, reserved and broadcast addresses,
+// and IPv6 prefixes that translate to IPv4 ones.
+var reservedNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.88.99.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"100::/64",
+	"2001::/23",
+	"2001:db8::/32",
+	"2002::/16",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	res := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, n)
+	}
+	return res
+}
+
+// PublicIP reports whether the address may be reached on behalf of users. Loopback, private, link-local, multicast,
+// unspecified and other special purpose addresses belong to the host, its network or nobody.
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range reservedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
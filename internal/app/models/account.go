@@ -1,9 +1,21 @@
 package models
 
+import "github.com/da-semenov/gophermart/internal/app/money"
+
 type Account struct {
 	ID      int
 	UserID  int
-	Balance float32
-	Debit   float32
-	Credit  float32
+	Code    string
+	Balance money.Amount
+	Debit   money.Amount
+	Credit  money.Amount
 }
+
+// System accounts are the counterparts of user accounts in journal entries.
+const (
+	SystemAccountAccruals    = "system:accruals"
+	SystemAccountWithdrawals = "system:withdrawals"
+	SystemAccountAdjustments = "system:adjustments"
+	SystemAccountHolds       = "system:holds"
+	SystemAccountExpirations = "system:expirations"
+)
@@ -2,20 +2,56 @@ package models
 
 import (
 	"context"
+	"github.com/da-semenov/gophermart/internal/app/money"
 	"time"
 )
 
 type BalanceRepository interface {
 	FindWithdrawalByUser(ctx context.Context, userID int) ([]Withdrawal, error)
 	LockAccount(ctx context.Context, userID int) (*Account, error)
-	SaveAccount(ctx context.Context, account *Account) error
-	CreateOperation(ctx context.Context, operation *Operation) error
 	GetAccount(ctx context.Context, userID int) (*Account, error)
+	// GetDebtTotal returns outstanding debts of the user.
+	GetDebtTotal(ctx context.Context, userID int) (money.Amount, error)
+	// GetTransferredTotal returns the amount sent from the account by transfers since the given time.
+	GetTransferredTotal(ctx context.Context, accountID int, since time.Time) (money.Amount, error)
+	// FindOperations returns operations of the user account newest first.
+	FindOperations(ctx context.Context, filter OperationFilter) ([]HistoryOperation, error)
+	// ScanOperations calls fn for every operation of the user account within filter.From and filter.To oldest first
+	// without loading them all, other filter fields are ignored.
+	ScanOperations(ctx context.Context, filter OperationFilter, fn func(o *HistoryOperation) error) error
+	// GetBalanceAt returns the balance of the user account before the given time.
+	GetBalanceAt(ctx context.Context, userID int, at time.Time) (money.Amount, error)
+}
+
+type WithdrawalRepository interface {
+	// Create returns UniqueViolation if the user already has a withdrawal for the order.
+	Create(ctx context.Context, w *Withdrawal) error
+	Lock(ctx context.Context, id int) (*Withdrawal, error)
+	UpdateStatus(ctx context.Context, w *Withdrawal) error
+	// FindByStatus returns withdrawals of all statuses if status is empty.
+	FindByStatus(ctx context.Context, status string) ([]Withdrawal, error)
+	GetWithdrawnTotal(ctx context.Context, userID int) (money.Amount, error)
 }
 
 type Withdrawal struct {
-	OrderNum    string
-	Amount      float32
-	Status      string
+	ID        int
+	UserID    int
+	AccountID int
+	OrderNum  string
+	Amount    money.Amount
+	Status    string
+	// EntryID is the journal entry holding the amount.
+	EntryID     int
+	Reason      string
 	ProcessedAt time.Time
+	UpdatedAt   time.Time
 }
+
+// A pending withdrawal holds its amount until the payment side confirms or rejects it,
+// a confirmed withdrawal can later be reversed.
+const (
+	WithdrawalStatusPending   = "PENDING"
+	WithdrawalStatusConfirmed = "CONFIRMED"
+	WithdrawalStatusRejected  = "REJECTED"
+	WithdrawalStatusReversed  = "REVERSED"
+)
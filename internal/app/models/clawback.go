@@ -0,0 +1,27 @@
+package models
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
+
+type ClawbackRepository interface {
+	// GetOrderTotals returns the amount accrued to the user for the order and the amount already clawed back.
+	GetOrderTotals(ctx context.Context, orderID int) (accrued money.Amount, clawedBack money.Amount, err error)
+	AddClawback(ctx context.Context, orderID int, amount money.Amount, at time.Time) error
+	CreateDebt(ctx context.Context, debt *Debt) error
+	LockOutstandingDebts(ctx context.Context, userID int) ([]Debt, error)
+	UpdateDebt(ctx context.Context, debt *Debt) error
+}
+
+// Debt is the part of a clawback the user balance didn't cover.
+type Debt struct {
+	ID        int
+	UserID    int
+	AccountID int
+	OrderNum  string
+	Amount    money.Amount
+	Remaining money.Amount
+	CreatedAt time.Time
+}
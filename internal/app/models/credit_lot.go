@@ -0,0 +1,30 @@
+package models
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
+
+// CreditLotRepository reads credit lots, lots are created and consumed by LedgerRepository.Post.
+// A lot expires when it is older than the expiry period, so lots created before the cutoff time are expired.
+type CreditLotRepository interface {
+	FindUsersWithExpiredLots(ctx context.Context, cutoff time.Time, limit int) ([]int, error)
+	GetExpiredTotal(ctx context.Context, accountID int, cutoff time.Time) (money.Amount, error)
+	// CloseExpired zeroes expired lots the balance didn't cover.
+	CloseExpired(ctx context.Context, accountID int, cutoff time.Time) error
+	FindExpirable(ctx context.Context, userID int, createdBefore time.Time) ([]CreditLot, error)
+}
+
+// CreditLot is a portion of points credited to a user account, debits consume lots in FIFO order.
+type CreditLot struct {
+	ID        int
+	UserID    int
+	AccountID int
+	EntryID   int
+	OrderNum  string
+	Amount    money.Amount
+	Remaining money.Amount
+	Expirable bool
+	CreatedAt time.Time
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type EventRepository interface {
+	// Save returns UniqueViolation if the event of the outbox event is already saved.
+	Save(ctx context.Context, event *UserEvent) error
+	// FindAfter returns up to limit events of the user following the event afterID.
+	FindAfter(ctx context.Context, userID int, afterID int, limit int) ([]UserEvent, error)
+	GetLastID(ctx context.Context, userID int) (int, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int, error)
+}
+
+type UserEvent struct {
+	ID        int
+	UserID    int
+	Type      string
+	Payload   []byte
+	CreatedAt time.Time
+	// OutboxEventID is the outbox event the user event is published from, it is saved once.
+	OutboxEventID int
+}
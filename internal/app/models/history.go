@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
+
+// OperationFilter selects operations of the user account. Zero values don't filter,
+// From is inclusive, To is exclusive.
+type OperationFilter struct {
+	UserID        int
+	From          time.Time
+	To            time.Time
+	EntryTypes    []string
+	OperationType string
+	// AfterProcessedAt and AfterID are the position of the last operation of the previous page.
+	AfterProcessedAt time.Time
+	AfterID          int
+	Limit            int
+}
+
+// HistoryOperation is a posting to the user account with the account balance after it.
+type HistoryOperation struct {
+	ID            int
+	EntryID       int
+	EntryType     string
+	OrderNum      string
+	Description   string
+	OperationType string
+	Amount        money.Amount
+	Balance       money.Amount
+	ProcessedAt   time.Time
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	// Lock serializes requests with the same lock key until the end of the current transaction.
+	Lock(ctx context.Context, lockKey int64) error
+	Get(ctx context.Context, userID int, key string, now time.Time) (*IdempotencyKey, error)
+	Save(ctx context.Context, key *IdempotencyKey) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type IdempotencyKey struct {
+	UserID      int
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
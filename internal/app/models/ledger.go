@@ -0,0 +1,37 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type LedgerRepository interface {
+	GetSystemAccount(ctx context.Context, code string) (*Account, error)
+	// Post records the entry with its postings and updates balances and credit lots of the user accounts.
+	Post(ctx context.Context, entry *JournalEntry) error
+}
+
+// JournalEntry is a set of postings with equal debit and credit totals.
+type JournalEntry struct {
+	ID        int
+	EntryType string
+	// EntryKey identifies the business event, an entry with the same key can be posted only once.
+	EntryKey    string
+	OrderNum    string
+	Description string
+	// RestoresEntryID is the entry whose debited points are returned, credits keep the expiration of its lots.
+	RestoresEntryID int
+	CreatedAt       time.Time
+	Operations      []Operation
+}
+
+const (
+	EntryAccrual    = "ACCRUAL"
+	EntryWithdrawal = "WITHDRAWAL"
+	EntrySettlement = "SETTLEMENT"
+	EntryClawback   = "CLAWBACK"
+	EntryTransfer   = "TRANSFER"
+	EntryExpire     = "EXPIRE"
+	EntryReversal   = "REVERSAL"
+	EntryAdjustment = "ADJUSTMENT"
+)
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type MigrationRepository interface {
+	// List returns all migrations known to this build ordered by version.
+	List() []Migration
+	// Lock serializes schema changes of concurrent replicas until the end of the current transaction.
+	Lock(ctx context.Context) error
+	GetApplied(ctx context.Context) ([]AppliedMigration, error)
+	Execute(ctx context.Context, statement string) error
+	SaveApplied(ctx context.Context, migration *AppliedMigration) error
+	DeleteApplied(ctx context.Context, version int) error
+}
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
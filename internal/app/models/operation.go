@@ -1,15 +1,22 @@
 package models
 
-import "time"
+import (
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"time"
+)
 
+// Operation is a posting of a journal entry to a single account.
 type Operation struct {
 	ID            int
+	EntryID       int
 	AccountID     int
 	OrderID       int
 	OrderNum      string
 	OperationType string
-	Amount        float32
+	Amount        money.Amount
 	ProcessedAt   time.Time
+	// Expirable marks credited points subject to the expiry policy.
+	Expirable bool
 }
 
 const OperationDebit = "DEBIT"
@@ -2,27 +2,71 @@ package models
 
 import (
 	"context"
+	"github.com/da-semenov/gophermart/internal/app/money"
 	"time"
 )
 
 type OrderRepository interface {
 	Save(ctx context.Context, order *Order) error
+	// SaveBatch saves orders in one round trip and returns numbers of the saved ones,
+	// orders with already uploaded numbers are skipped.
+	SaveBatch(ctx context.Context, orders []Order) ([]string, error)
 	GetByID(ctx context.Context, orderID int) (*Order, error)
 	GetByNum(ctx context.Context, num string) (*Order, error)
+	FindByNums(ctx context.Context, nums []string) ([]Order, error)
+	// GetUserOrder returns the order of the user with accruals, NoRowFound if the user has no such order.
+	GetUserOrder(ctx context.Context, userID int, num string) (*Order, error)
 	UpdateStatus(ctx context.Context, order *Order) error
-	FindByUser(ctx context.Context, userID int) ([]Order, error)
+	GetStatusHistory(ctx context.Context, orderID int) ([]OrderStatusChange, error)
+	// FindByUser returns a page of orders of the user, CountByUser counts all orders matching the filter.
+	FindByUser(ctx context.Context, filter OrderFilter) ([]Order, error)
+	CountByUser(ctx context.Context, filter OrderFilter) (int, error)
 	LockOrder(ctx context.Context, OrderNum string) (*Order, error)
-	FindNotProcessed(ctx context.Context) ([]Order, error)
+	FindNotProcessed(ctx context.Context, leaseOwner string, leaseUntil time.Time, limit int) ([]Order, error)
+	ScheduleAttempt(ctx context.Context, order *Order) error
+	MarkStuck(ctx context.Context, order *Order) error
+	MarkStuckOlderThan(ctx context.Context, uploadedBefore time.Time, reason string) (int, error)
+	FindStuck(ctx context.Context) ([]Order, error)
+	Revive(ctx context.Context, num string) (*Order, error)
 }
 
 type Order struct {
-	ID        int
-	UserID    int
-	Num       string
-	Status    string
-	Accrual   float32
+	ID      int
+	UserID  int
+	Num     string
+	Status  string
+	Accrual money.Amount
+	// Clawback is the part of Accrual taken back after the goods were returned.
+	Clawback  money.Amount
 	UploadAt  time.Time
 	UpdatedAt time.Time
+
+	AttemptCount  int
+	LastError     string
+	NextAttemptAt time.Time
+	LeaseOwner    string
+	// AccrualResponse is the raw response of the accrual system that caused the status change, UpdateStatus
+	// keeps it in the status history.
+	AccrualResponse []byte
+}
+
+type OrderStatusChange struct {
+	Status          string
+	AccrualResponse []byte
+	ChangedAt       time.Time
+}
+
+// OrderFilter selects orders of the user. Zero values don't filter, UploadedFrom is inclusive, UploadedTo is exclusive.
+type OrderFilter struct {
+	UserID       int
+	Statuses     []string
+	UploadedFrom time.Time
+	UploadedTo   time.Time
+	Descending   bool
+	// AfterUploadAt and AfterID are the position of the last order of the previous page.
+	AfterUploadAt time.Time
+	AfterID       int
+	Limit         int
 }
 
 const (
@@ -31,6 +75,8 @@ const (
 	OrderStatusInvalid    = "INVALID"
 	OrderStatusProcessed  = "PROCESSED"
 	OrderStatusRegistered = "REGISTERED"
+	// OrderStatusStuck is set for orders the accrual system never resolved, such orders are not polled until revived.
+	OrderStatusStuck = "STUCK"
 )
 
 func IsFinal(status string) bool {
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type OutboxRepository interface {
+	Save(ctx context.Context, event *OutboxEvent) error
+	// FindUnpublished returns up to limit pending events following the event afterID in the order they were written.
+	FindUnpublished(ctx context.Context, afterID int, limit int) ([]OutboxEvent, error)
+	// FindByStatus returns up to limit latest events in the status.
+	FindByStatus(ctx context.Context, status string, limit int) ([]OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []int, publishedAt time.Time) error
+	// RecordFailure counts the failed attempt to publish the event and moves it to the status.
+	RecordFailure(ctx context.Context, id int, status string, lastError string) error
+	// Retry returns the failed event to the pending ones with no attempts, NoRowFound if there is no such failed event.
+	Retry(ctx context.Context, id int) error
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type OutboxEvent struct {
+	ID            int
+	AggregateType string
+	AggregateID   string
+	UserID        int
+	Type          string
+	Payload       []byte
+	CreatedAt     time.Time
+	AttemptCount  int
+	Status        string
+	LastError     string
+}
+
+const (
+	OutboxEventPending   = "PENDING"
+	OutboxEventPublished = "PUBLISHED"
+	OutboxEventFailed    = "FAILED"
+)
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/money"
+)
+
+type ReconciliationRepository interface {
+	FindAccountDrifts(ctx context.Context) ([]AccountTotals, error)
+	LockAccountTotals(ctx context.Context, accountID int) (*AccountTotals, error)
+	FindUnbalancedEntries(ctx context.Context) ([]EntryTotals, error)
+	// SyncAccount overwrites the cached totals of a user account.
+	SyncAccount(ctx context.Context, accountID int, balance, debit, credit money.Amount) error
+}
+
+// AccountTotals holds the cached totals of an account next to the totals of its postings.
+type AccountTotals struct {
+	AccountID    int
+	UserID       int
+	Balance      money.Amount
+	Debit        money.Amount
+	Credit       money.Amount
+	LedgerDebit  money.Amount
+	LedgerCredit money.Amount
+}
+
+func (t *AccountTotals) LedgerBalance() money.Amount {
+	return t.LedgerCredit - t.LedgerDebit
+}
+
+// InSync reports whether the cached totals match the postings.
+func (t *AccountTotals) InSync() bool {
+	return t.Debit == t.LedgerDebit && t.Credit == t.LedgerCredit && t.Balance == t.LedgerBalance()
+}
+
+type EntryTotals struct {
+	EntryID int
+	Debit   money.Amount
+	Credit  money.Amount
+}
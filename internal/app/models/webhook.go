@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *Webhook) error
+	FindByUser(ctx context.Context, userID int) ([]Webhook, error)
+	Get(ctx context.Context, userID int, id int) (*Webhook, error)
+	// Delete returns NoRowFound if the user has no such webhook.
+	Delete(ctx context.Context, userID int, id int) error
+	// Enqueue copies the delivery for every webhook of the user subscribed to its event type and returns their number.
+	Enqueue(ctx context.Context, userID int, delivery *WebhookDelivery) (int, error)
+	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	FindDeliveries(ctx context.Context, userID int, webhookID int, limit int) ([]WebhookDelivery, error)
+	GetDelivery(ctx context.Context, userID int, webhookID int, id int) (*WebhookDelivery, error)
+	FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+}
+
+// Webhook receives events of the user of types EventTypes, of all types if it is empty.
+type Webhook struct {
+	ID         int
+	UserID     int
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedAt  time.Time
+}
+
+type WebhookDelivery struct {
+	ID        int
+	WebhookID int
+	UserID    int
+	// EventID is the same for redeliveries of the event, so receivers can skip events they already handled.
+	EventID       string
+	EventType     string
+	Payload       []byte
+	Status        string
+	AttemptCount  int
+	NextAttemptAt time.Time
+	ResponseCode  int
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	// OutboxEventID is the outbox event the delivery is queued for, redeliveries have none.
+	OutboxEventID int
+
+	// URL and Secret of the webhook are set by FindDueDeliveries.
+	URL    string
+	Secret string
+}
+
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliveryDelivered = "DELIVERED"
+	WebhookDeliveryFailed    = "FAILED"
+)
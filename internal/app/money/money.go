@@ -0,0 +1,123 @@
+// Package money provides an exact representation of bonus amounts.
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Amount is a sum in kopecks. It is rendered as a decimal number of rubles in JSON and in numeric columns.
+type Amount int64
+
+var ErrBadAmount = errors.New("bad money amount")
+
+var hundred = big.NewInt(100)
+
+// Parse reads a decimal number of rubles, like "729.98" or "72998e-2", rounding half away from zero to kopecks.
+func Parse(s string) (Amount, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrBadAmount, s)
+	}
+	num := new(big.Int).Mul(r.Num(), hundred)
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(rem.Sign())))
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrBadAmount, s)
+	}
+	return Amount(quo.Int64()), nil
+}
+
+// Rubles builds an amount from whole rubles and kopecks.
+func Rubles(rubles int64, kopecks int64) Amount {
+	return Amount(rubles*100 + kopecks)
+}
+
+// String renders the amount in rubles without trailing zeros: 729.98, 729.9, 729.
+func (a Amount) String() string {
+	sign := ""
+	v := int64(a)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	rubles, kopecks := v/100, v%100
+	switch {
+	case kopecks == 0:
+		return sign + strconv.FormatInt(rubles, 10)
+	case kopecks%10 == 0:
+		return fmt.Sprintf("%s%d.%d", sign, rubles, kopecks/10)
+	default:
+		return fmt.Sprintf("%s%d.%02d", sign, rubles, kopecks)
+	}
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding a number.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(b, `"`)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	v, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// UnmarshalText allows amounts in environment variables.
+func (a *Amount) UnmarshalText(b []byte) error {
+	return a.parse(string(b))
+}
+
+// Set and Type implement pflag.Value, so amounts can be given as command line flags.
+func (a *Amount) Set(s string) error {
+	return a.parse(s)
+}
+
+func (a *Amount) Type() string {
+	return "amount"
+}
+
+// Scan implements sql.Scanner, NULL is read as zero.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case string:
+		return a.parse(v)
+	case []byte:
+		return a.parse(string(v))
+	case int64:
+		*a = Amount(v * 100)
+	case float64:
+		return a.parse(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("%w: can't scan %T", ErrBadAmount, src)
+	}
+	return nil
+}
+
+func (a *Amount) parse(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// Value implements driver.Valuer, the amount is passed to the database as a decimal string.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
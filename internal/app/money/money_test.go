@@ -0,0 +1,93 @@
+package money
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Amount
+		wantErr bool
+	}{
+		{name: "Money. Parse. Test 1. Decimal", value: "729.98", want: 72998},
+		{name: "Money. Parse. Test 2. Integer", value: "500", want: 50000},
+		{name: "Money. Parse. Test 3. Postgres numeric text", value: "72998e-2", want: 72998},
+		{name: "Money. Parse. Test 4. Round half up", value: "0.125", want: 13},
+		{name: "Money. Parse. Test 5. Round down", value: "0.124999", want: 12},
+		{name: "Money. Parse. Test 6. Negative", value: "-10.005", want: -1001},
+		{name: "Money. Parse. Test 7. Garbage", value: "ten", wantErr: true},
+		{name: "Money. Parse. Test 8. Overflow", value: "1e30", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrBadAmount)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAmount_String(t *testing.T) {
+	assert.Equal(t, "729.98", Amount(72998).String())
+	assert.Equal(t, "729.9", Amount(72990).String())
+	assert.Equal(t, "729", Amount(72900).String())
+	assert.Equal(t, "0.05", Amount(5).String())
+	assert.Equal(t, "-0.5", Amount(-50).String())
+	assert.Equal(t, "-1.01", Amount(-101).String())
+	assert.Equal(t, "-20", Amount(-2000).String())
+}
+
+func TestAmount_JSON(t *testing.T) {
+	var obj struct {
+		Sum Amount `json:"sum"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"sum": 751.1}`), &obj))
+	assert.Equal(t, Amount(75110), obj.Sum)
+	assert.NoError(t, json.Unmarshal([]byte(`{"sum": "0.01"}`), &obj))
+	assert.Equal(t, Amount(1), obj.Sum)
+	assert.Error(t, json.Unmarshal([]byte(`{"sum": true}`), &obj))
+
+	b, err := json.Marshal(obj)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"sum": 0.01}`, string(b))
+}
+
+func TestAmount_Text(t *testing.T) {
+	var a Amount
+	assert.NoError(t, a.UnmarshalText([]byte("1000")))
+	assert.Equal(t, Rubles(1000, 0), a)
+	assert.NoError(t, a.Set("2.5"))
+	assert.Equal(t, Rubles(2, 50), a)
+	assert.Error(t, a.Set("abc"))
+}
+
+func TestAmount_Sum(t *testing.T) {
+	var sum Amount
+	for i := 0; i < 10000; i++ {
+		sum += Rubles(0, 10)
+	}
+	assert.Equal(t, "1000", sum.String(), "sum of amounts must be exact")
+}
+
+func TestAmount_Scan(t *testing.T) {
+	var a Amount
+	assert.NoError(t, a.Scan("12998e-2"))
+	assert.Equal(t, Amount(12998), a)
+	assert.NoError(t, a.Scan(int64(3)))
+	assert.Equal(t, Amount(300), a)
+	assert.NoError(t, a.Scan(nil))
+	assert.Equal(t, Amount(0), a)
+	assert.Error(t, a.Scan(true))
+
+	v, err := Amount(12998).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "129.98", v)
+}
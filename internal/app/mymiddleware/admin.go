@@ -0,0 +1,23 @@
+package mymiddleware
+
+import (
+	"crypto/subtle"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// AdminAuth allows only requests bearing the configured admin token.
+func AdminAuth(token string, log *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				log.Warn("AdminAuth: unauthorized request", zap.String("RequestURI", r.RequestURI))
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package mymiddleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/go-chi/jwtauth/v5"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLength  = 255
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, userID int, key string, requestHash string) (*domain.IdempotentResponse, error)
+	Complete(ctx context.Context, userID int, key string, requestHash string, res domain.IdempotentResponse) error
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotency replays the stored response for a repeated request with the same Idempotency-Key header.
+// It must run inside Transactional, so the key is stored atomically with the effects of the request.
+func Idempotency(service IdempotencyService, log *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			userID := userIDFromToken(ctx)
+			if userID == 0 || len(key) > idempotencyKeyMaxLength {
+				log.Debug("Idempotency: bad key or user", zap.String("key", key), zap.Int("userID", userID))
+				writeError(w, http.StatusBadRequest, "неверный формат запроса")
+				return
+			}
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				log.Error("Idempotency: can't read request body", zap.Error(err))
+				writeError(w, http.StatusInternalServerError, "внутренняя ошибка сервера")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(b))
+			requestHash := hashRequest(r, b)
+
+			stored, err := service.Begin(ctx, userID, key, requestHash)
+			if err != nil {
+				if errors.Is(err, domain.ErrIdempotencyKeyReused) {
+					writeError(w, http.StatusUnprocessableEntity, "ключ идемпотентности использован для другого запроса")
+					return
+				}
+				log.Error("Idempotency: can't check key", zap.Error(err))
+				writeError(w, http.StatusInternalServerError, "внутренняя ошибка сервера")
+				return
+			}
+			if stored != nil {
+				log.Info("Idempotency: response replayed", zap.String("key", key), zap.Int("userID", userID))
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(stored.StatusCode)
+				if _, err = w.Write(stored.Body); err != nil {
+					log.Error("Idempotency: can't write response", zap.Error(err))
+				}
+				return
+			}
+
+			rw := recordingWriter{ResponseWriter: w}
+			next.ServeHTTP(&rw, r)
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+			res := domain.IdempotentResponse{StatusCode: rw.status, Body: rw.body.Bytes()}
+			if err = service.Complete(ctx, userID, key, requestHash, res); err != nil {
+				log.Error("Idempotency: can't store response", zap.String("key", key), zap.Error(err))
+			}
+		})
+	}
+}
+
+func userIDFromToken(ctx context.Context) int {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return 0
+	}
+	if u, ok := claims["user_id"].(float64); ok {
+		return int(u)
+	}
+	return 0
+}
+
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	b, _ := json.Marshal(domain.Error{Msg: msg})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
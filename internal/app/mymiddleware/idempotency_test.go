@@ -0,0 +1,78 @@
+package mymiddleware
+
+import (
+	"bytes"
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// idempotencyServiceStub keeps responses in memory.
+type idempotencyServiceStub struct {
+	hashes    map[string]string
+	responses map[string]domain.IdempotentResponse
+}
+
+func (s *idempotencyServiceStub) Begin(ctx context.Context, userID int, key string, requestHash string) (*domain.IdempotentResponse, error) {
+	hash, ok := s.hashes[key]
+	if !ok {
+		return nil, nil
+	}
+	if hash != requestHash {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	res := s.responses[key]
+	return &res, nil
+}
+
+func (s *idempotencyServiceStub) Complete(ctx context.Context, userID int, key string, requestHash string, res domain.IdempotentResponse) error {
+	s.hashes[key] = requestHash
+	s.responses[key] = res
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	_, tokenString, _ := tokenAuth.Encode(map[string]interface{}{"user_id": 1})
+	stub := &idempotencyServiceStub{hashes: make(map[string]string), responses: make(map[string]domain.IdempotentResponse)}
+	calls := 0
+	handler := jwtauth.Verifier(tokenAuth)(Idempotency(stub, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		_, _ = w.Write([]byte(`{"msg":"на счету недостаточно средств"}`))
+	})))
+	send := func(key string, body string) *http.Response {
+		request := httptest.NewRequest("POST", "/api/user/balance/withdraw", bytes.NewBufferString(body))
+		request.Header.Set("Authorization", "Bearer "+tokenString)
+		request.Header.Set(IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		return w.Result()
+	}
+
+	res := send("key-1", `{"order": "2377225624", "sum": 751}`)
+	res.Body.Close()
+	assert.Equal(t, http.StatusPaymentRequired, res.StatusCode)
+	assert.Equal(t, 1, calls)
+
+	res = send("key-1", `{"order": "2377225624", "sum": 751}`)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusPaymentRequired, res.StatusCode, "original status must be replayed")
+	assert.Equal(t, "true", res.Header.Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls, "repeated request must not reach the handler")
+
+	res = send("key-1", `{"order": "2377225624", "sum": 1}`)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, "key reused with another body must be rejected")
+
+	res = send("", `{"order": "2377225624", "sum": 751}`)
+	res.Body.Close()
+	assert.Equal(t, 2, calls, "request without a key must reach the handler")
+}
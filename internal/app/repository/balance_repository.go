@@ -6,8 +6,10 @@ import (
 	"github.com/da-semenov/gophermart/internal/app/dbqueries"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure"
 	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
 	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
 	"go.uber.org/zap"
+	"time"
 )
 
 type BalanceRepository struct {
@@ -32,6 +34,7 @@ func (r *BalanceRepository) FindWithdrawalByUser(ctx context.Context, userID int
 		r.l.Error("BalanceRepository: request error", zap.String("query", dbqueries.GetWithdrawalByUser), zap.Int("userID", userID), zap.Error(err))
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var o models.Withdrawal
 		err := rows.Scan(&o.OrderNum, &o.Amount, &o.Status, &o.ProcessedAt)
@@ -63,30 +66,6 @@ func (r *BalanceRepository) LockAccount(ctx context.Context, userID int) (*model
 	return &account, nil
 }
 
-func (r *BalanceRepository) SaveAccount(ctx context.Context, account *models.Account) error {
-	err := r.h.Execute(ctx, dbqueries.UpdateAccountForUser, account.UserID, account.Balance, account.Debit, account.Credit)
-	if err != nil {
-		r.l.Error("UserRepository: can't create user", zap.Error(err))
-		return err
-	}
-	return nil
-}
-
-func (r *BalanceRepository) CreateOperation(ctx context.Context, operation *models.Operation) error {
-	err := r.h.Execute(ctx, dbqueries.CreateOperation,
-		operation.AccountID,
-		operation.OrderID,
-		operation.OrderNum,
-		operation.OperationType,
-		operation.Amount,
-		operation.ProcessedAt)
-	if err != nil {
-		r.l.Error("BalanceRepository: can't create operation", zap.Error(err))
-		return err
-	}
-	return nil
-}
-
 func (r *BalanceRepository) GetAccount(ctx context.Context, userID int) (*models.Account, error) {
 	row, err := r.h.QueryRow(ctx, dbqueries.GetAccount, userID)
 	if err != nil {
@@ -105,3 +84,91 @@ func (r *BalanceRepository) GetAccount(ctx context.Context, userID int) (*models
 	}
 	return &account, nil
 }
+
+func (r *BalanceRepository) GetDebtTotal(ctx context.Context, userID int) (money.Amount, error) {
+	row, err := r.h.QueryRow(ctx, dbqueries.GetDebtTotal, userID)
+	if err != nil {
+		r.l.Error("BalanceRepository: can't get debt total", zap.Int("userID", userID), zap.Error(err))
+		return 0, err
+	}
+	var total money.Amount
+	if err = row.Scan(&total); err != nil {
+		r.l.Error("BalanceRepository: can't scan debt total", zap.Int("userID", userID), zap.Error(err))
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *BalanceRepository) GetTransferredTotal(ctx context.Context, accountID int, since time.Time) (money.Amount, error) {
+	row, err := r.h.QueryRow(ctx, dbqueries.GetTransferredTotal, accountID, since)
+	if err != nil {
+		r.l.Error("BalanceRepository: can't get transferred total", zap.Int("accountID", accountID), zap.Error(err))
+		return 0, err
+	}
+	var total money.Amount
+	if err = row.Scan(&total); err != nil {
+		r.l.Error("BalanceRepository: can't scan transferred total", zap.Int("accountID", accountID), zap.Error(err))
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *BalanceRepository) FindOperations(ctx context.Context, filter models.OperationFilter) ([]models.HistoryOperation, error) {
+	entryTypes := filter.EntryTypes
+	if entryTypes == nil {
+		entryTypes = []string{}
+	}
+	rows, err := r.h.Query(ctx, dbqueries.FindUserOperations, filter.UserID, nullTime(filter.From), nullTime(filter.To),
+		entryTypes, filter.OperationType, nullTime(filter.AfterProcessedAt), filter.AfterID, filter.Limit)
+	if err != nil {
+		r.l.Error("BalanceRepository: request error", zap.String("query", dbqueries.FindUserOperations), zap.Int("userID", filter.UserID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.HistoryOperation
+	for rows.Next() {
+		var o models.HistoryOperation
+		err = rows.Scan(&o.ID, &o.EntryID, &o.EntryType, &o.OrderNum, &o.Description, &o.OperationType, &o.Amount, &o.Balance, &o.ProcessedAt)
+		if err != nil {
+			r.l.Error("BalanceRepository: scan rows error", zap.String("query", dbqueries.FindUserOperations), zap.Int("userID", filter.UserID), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, o)
+	}
+	return resArray, nil
+}
+
+func (r *BalanceRepository) ScanOperations(ctx context.Context, filter models.OperationFilter, fn func(o *models.HistoryOperation) error) error {
+	rows, err := r.h.Query(ctx, dbqueries.FindUserOperationsForPeriod, filter.UserID, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		r.l.Error("BalanceRepository: request error", zap.String("query", dbqueries.FindUserOperationsForPeriod), zap.Int("userID", filter.UserID), zap.Error(err))
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o models.HistoryOperation
+		err = rows.Scan(&o.ID, &o.EntryID, &o.EntryType, &o.OrderNum, &o.Description, &o.OperationType, &o.Amount, &o.Balance, &o.ProcessedAt)
+		if err != nil {
+			r.l.Error("BalanceRepository: scan rows error", zap.String("query", dbqueries.FindUserOperationsForPeriod), zap.Int("userID", filter.UserID), zap.Error(err))
+			return err
+		}
+		if err = fn(&o); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *BalanceRepository) GetBalanceAt(ctx context.Context, userID int, at time.Time) (money.Amount, error) {
+	row, err := r.h.QueryRow(ctx, dbqueries.GetUserBalanceAt, userID, at)
+	if err != nil {
+		r.l.Error("BalanceRepository: can't get balance", zap.Int("userID", userID), zap.Time("at", at), zap.Error(err))
+		return 0, err
+	}
+	var balance money.Amount
+	if err = row.Scan(&balance); err != nil {
+		r.l.Error("BalanceRepository: can't scan balance", zap.Int("userID", userID), zap.Time("at", at), zap.Error(err))
+		return 0, err
+	}
+	return balance, nil
+}
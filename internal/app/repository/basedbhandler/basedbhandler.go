@@ -15,6 +15,11 @@ type DBHandler interface {
 
 type TransactionKey string
 
+// WithoutTransaction returns a context whose queries run outside the transaction carried by ctx.
+func WithoutTransaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, TransactionKey("tx"), nil)
+}
+
 type TransactionalDBHandler interface {
 	Execute(ctx context.Context, statement string, args ...interface{}) error
 	ExecuteBatch(ctx context.Context, statement string, args [][]interface{}) error
@@ -29,9 +34,24 @@ type Transactioner interface {
 	NewTx(ctx context.Context) (pgx.Tx, error)
 }
 
+// AdvisoryLocker grants session level locks which Postgres releases by itself when the holding connection dies.
+type AdvisoryLocker interface {
+	// TryAdvisoryLock returns nil lock if it is held by another session.
+	TryAdvisoryLock(ctx context.Context, key int64) (AdvisoryLock, error)
+}
+
+type AdvisoryLock interface {
+	// Check returns an error if the session holding the lock is lost.
+	Check(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
 type Rows interface {
 	Scan(dest ...interface{}) error
 	Next() bool
+	Close()
+	// Err returns the error that stopped Next, if any.
+	Err() error
 }
 
 type Row interface {
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+type ClawbackRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewClawbackRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.ClawbackRepository, error) {
+	var target ClawbackRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init clawback repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *ClawbackRepository) GetOrderTotals(ctx context.Context, orderID int) (money.Amount, money.Amount, error) {
+	row, err := r.h.QueryRow(ctx, dbqueries.GetOrderClawbackTotals, orderID)
+	if err != nil {
+		r.l.Error("ClawbackRepository: can't get order totals", zap.Int("orderID", orderID), zap.Error(err))
+		return 0, 0, err
+	}
+	var accrued, clawedBack money.Amount
+	err = row.Scan(&accrued, &clawedBack)
+	if err != nil && err.Error() == "no rows in result set" {
+		return 0, 0, &models.NoRowFound
+	}
+	if err != nil {
+		r.l.Error("ClawbackRepository: can't scan order totals", zap.Int("orderID", orderID), zap.Error(err))
+		return 0, 0, err
+	}
+	return accrued, clawedBack, nil
+}
+
+func (r *ClawbackRepository) AddClawback(ctx context.Context, orderID int, amount money.Amount, at time.Time) error {
+	err := r.h.Execute(ctx, dbqueries.AddOrderClawback, orderID, amount, at)
+	if err != nil {
+		r.l.Error("ClawbackRepository: can't add order clawback", zap.Int("orderID", orderID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ClawbackRepository) CreateDebt(ctx context.Context, debt *models.Debt) error {
+	row, err := r.h.QueryRow(ctx, dbqueries.CreateDebt, debt.UserID, debt.AccountID, debt.OrderNum, debt.Amount, debt.CreatedAt)
+	if err == nil {
+		err = row.Scan(&debt.ID)
+	}
+	if err != nil {
+		r.l.Error("ClawbackRepository: can't create debt", zap.Int("userID", debt.UserID), zap.Error(err))
+		return err
+	}
+	debt.Remaining = debt.Amount
+	return nil
+}
+
+func (r *ClawbackRepository) LockOutstandingDebts(ctx context.Context, userID int) ([]models.Debt, error) {
+	rows, err := r.h.Query(ctx, dbqueries.GetOutstandingDebtsForUpdate, userID)
+	if err != nil {
+		r.l.Error("ClawbackRepository: request error", zap.String("query", dbqueries.GetOutstandingDebtsForUpdate), zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.Debt
+	for rows.Next() {
+		var d models.Debt
+		err = rows.Scan(&d.ID, &d.UserID, &d.AccountID, &d.OrderNum, &d.Amount, &d.Remaining, &d.CreatedAt)
+		if err != nil {
+			r.l.Error("ClawbackRepository: scan rows error", zap.String("query", dbqueries.GetOutstandingDebtsForUpdate), zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, d)
+	}
+	return resArray, nil
+}
+
+func (r *ClawbackRepository) UpdateDebt(ctx context.Context, debt *models.Debt) error {
+	err := r.h.Execute(ctx, dbqueries.UpdateDebtRemaining, debt.ID, debt.Remaining)
+	if err != nil {
+		r.l.Error("ClawbackRepository: can't update debt", zap.Int("debtID", debt.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+type CreditLotRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewCreditLotRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.CreditLotRepository, error) {
+	var target CreditLotRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init credit lot repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *CreditLotRepository) FindUsersWithExpiredLots(ctx context.Context, cutoff time.Time, limit int) ([]int, error) {
+	rows, err := r.h.Query(ctx, dbqueries.FindUsersWithExpiredLots, cutoff, limit)
+	if err != nil {
+		r.l.Error("CreditLotRepository: request error", zap.String("query", dbqueries.FindUsersWithExpiredLots), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []int
+	for rows.Next() {
+		var userID int
+		if err = rows.Scan(&userID); err != nil {
+			r.l.Error("CreditLotRepository: scan rows error", zap.String("query", dbqueries.FindUsersWithExpiredLots), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, userID)
+	}
+	return resArray, nil
+}
+
+func (r *CreditLotRepository) GetExpiredTotal(ctx context.Context, accountID int, cutoff time.Time) (money.Amount, error) {
+	row, err := r.h.QueryRow(ctx, dbqueries.GetExpiredLotsTotal, accountID, cutoff)
+	if err != nil {
+		r.l.Error("CreditLotRepository: can't get expired total", zap.Int("accountID", accountID), zap.Error(err))
+		return 0, err
+	}
+	var total money.Amount
+	if err = row.Scan(&total); err != nil {
+		r.l.Error("CreditLotRepository: can't scan expired total", zap.Int("accountID", accountID), zap.Error(err))
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *CreditLotRepository) CloseExpired(ctx context.Context, accountID int, cutoff time.Time) error {
+	err := r.h.Execute(ctx, dbqueries.CloseExpiredLots, accountID, cutoff)
+	if err != nil {
+		r.l.Error("CreditLotRepository: can't close expired lots", zap.Int("accountID", accountID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *CreditLotRepository) FindExpirable(ctx context.Context, userID int, createdBefore time.Time) ([]models.CreditLot, error) {
+	rows, err := r.h.Query(ctx, dbqueries.FindExpirableLots, userID, createdBefore)
+	if err != nil {
+		r.l.Error("CreditLotRepository: request error", zap.String("query", dbqueries.FindExpirableLots), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.CreditLot
+	for rows.Next() {
+		var lot models.CreditLot
+		err = rows.Scan(&lot.ID, &lot.UserID, &lot.AccountID, &lot.EntryID, &lot.OrderNum, &lot.Amount, &lot.Remaining, &lot.Expirable, &lot.CreatedAt)
+		if err != nil {
+			r.l.Error("CreditLotRepository: scan rows error", zap.String("query", dbqueries.FindExpirableLots), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, lot)
+	}
+	return resArray, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+type EventRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewEventRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.EventRepository, error) {
+	var target EventRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init event repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *EventRepository) Save(ctx context.Context, event *models.UserEvent) error {
+	row, err := r.h.QueryRow(ctx, dbqueries.CreateUserEvent, event.UserID, event.Type, string(event.Payload), event.CreatedAt,
+		nullID(event.OutboxEventID))
+	if err != nil {
+		r.l.Error("EventRepository: can't save event", zap.Int("userID", event.UserID), zap.Error(err))
+		return err
+	}
+	err = row.Scan(&event.ID)
+	if err != nil && err.Error() == "no rows in result set" {
+		return &models.UniqueViolation
+	}
+	if err != nil {
+		r.l.Error("EventRepository: can't save event", zap.Int("userID", event.UserID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *EventRepository) FindAfter(ctx context.Context, userID int, afterID int, limit int) ([]models.UserEvent, error) {
+	rows, err := r.h.Query(ctx, dbqueries.FindUserEventsAfter, userID, afterID, limit)
+	if err != nil {
+		r.l.Error("EventRepository: request error", zap.String("query", dbqueries.FindUserEventsAfter), zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.UserEvent
+	for rows.Next() {
+		var e models.UserEvent
+		if err = rows.Scan(&e.ID, &e.UserID, &e.Type, &e.Payload, &e.CreatedAt); err != nil {
+			r.l.Error("EventRepository: scan rows error", zap.String("query", dbqueries.FindUserEventsAfter), zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, e)
+	}
+	return resArray, rows.Err()
+}
+
+func (r *EventRepository) GetLastID(ctx context.Context, userID int) (int, error) {
+	var id int
+	row, err := r.h.QueryRow(ctx, dbqueries.GetLastUserEventID, userID)
+	if err != nil {
+		r.l.Error("EventRepository: can't get last event id", zap.Int("userID", userID), zap.Error(err))
+		return 0, err
+	}
+	if err = row.Scan(&id); err != nil {
+		r.l.Error("EventRepository: can't get last event id", zap.Int("userID", userID), zap.Error(err))
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *EventRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int, error) {
+	var count int
+	row, err := r.h.QueryRow(ctx, dbqueries.DeleteUserEventsOlderThan, before)
+	if err != nil {
+		r.l.Error("EventRepository: can't delete old events", zap.Error(err))
+		return 0, err
+	}
+	if err = row.Scan(&count); err != nil {
+		r.l.Error("EventRepository: can't delete old events", zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "time"
+
+// nullTime passes the zero time as null.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullID passes the zero ID as null.
+func nullID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
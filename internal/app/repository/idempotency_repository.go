@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+type IdempotencyRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewIdempotencyRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.IdempotencyRepository, error) {
+	var target IdempotencyRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init idempotency repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *IdempotencyRepository) Lock(ctx context.Context, lockKey int64) error {
+	err := r.h.Execute(ctx, dbqueries.LockIdempotencyKey, lockKey)
+	if err != nil {
+		r.l.Error("IdempotencyRepository: can't lock key", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, userID int, key string, now time.Time) (*models.IdempotencyKey, error) {
+	var res models.IdempotencyKey
+	row, err := r.h.QueryRow(ctx, dbqueries.GetIdempotencyKey, userID, key, now)
+	if err != nil {
+		r.l.Error("IdempotencyRepository: can't get key", zap.Error(err))
+		return nil, err
+	}
+	err = row.Scan(&res.UserID, &res.Key, &res.RequestHash, &res.StatusCode, &res.Response, &res.CreatedAt, &res.ExpiresAt)
+	if err != nil && err.Error() == "no rows in result set" {
+		return nil, &models.NoRowFound
+	}
+	if err != nil {
+		r.l.Error("IdempotencyRepository: can't scan key", zap.Error(err))
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *IdempotencyRepository) Save(ctx context.Context, key *models.IdempotencyKey) error {
+	err := r.h.Execute(ctx, dbqueries.SaveIdempotencyKey,
+		key.UserID,
+		key.Key,
+		key.RequestHash,
+		key.StatusCode,
+		key.Response,
+		key.CreatedAt,
+		key.ExpiresAt)
+	if err != nil {
+		r.l.Error("IdempotencyRepository: can't save key", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	var count int
+	row, err := r.h.QueryRow(ctx, dbqueries.DeleteExpiredIdempotencyKeys, now)
+	if err != nil {
+		r.l.Error("IdempotencyRepository: can't delete expired keys", zap.Error(err))
+		return 0, err
+	}
+	if err = row.Scan(&count); err != nil {
+		r.l.Error("IdempotencyRepository: can't delete expired keys", zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
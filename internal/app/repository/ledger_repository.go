@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+)
+
+type LedgerRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewLedgerRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.LedgerRepository, error) {
+	var target LedgerRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init ledger repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *LedgerRepository) GetSystemAccount(ctx context.Context, code string) (*models.Account, error) {
+	var account models.Account
+	row, err := r.h.QueryRow(ctx, dbqueries.GetSystemAccount, code)
+	if err != nil {
+		r.l.Error("LedgerRepository: can't get system account", zap.String("code", code), zap.Error(err))
+		return nil, err
+	}
+	err = row.Scan(&account.ID, &account.Code)
+	if err != nil && err.Error() == "no rows in result set" {
+		return nil, &models.NoRowFound
+	}
+	if err != nil {
+		r.l.Error("LedgerRepository: can't scan system account", zap.String("code", code), zap.Error(err))
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Post must run in a transaction, postings are applied in the given order. Credits of user accounts first take
+// lots consumed by debits of the entry or of the restored entry, so moved points keep their expiration.
+func (r *LedgerRepository) Post(ctx context.Context, entry *models.JournalEntry) error {
+	var entryKey interface{}
+	if entry.EntryKey != "" {
+		entryKey = entry.EntryKey
+	}
+	row, err := r.h.QueryRow(ctx, dbqueries.CreateJournalEntry, entry.EntryType, entryKey, entry.OrderNum, entry.Description, entry.CreatedAt)
+	if err == nil {
+		err = row.Scan(&entry.ID)
+	}
+	if err != nil && err.Error() == "no rows in result set" {
+		return &models.UniqueViolation
+	}
+	if err != nil {
+		r.l.Error("LedgerRepository: can't create journal entry", zap.String("entryKey", entry.EntryKey), zap.Error(err))
+		return err
+	}
+	var consumed []models.CreditLot
+	if entry.RestoresEntryID != 0 {
+		consumed, err = r.findLots(ctx, dbqueries.FindConsumedCreditLots, entry.RestoresEntryID)
+		if err != nil {
+			r.l.Error("LedgerRepository: can't find consumed credit lots", zap.Int("entryID", entry.RestoresEntryID), zap.Error(err))
+			return err
+		}
+	}
+	for i := range entry.Operations {
+		op := &entry.Operations[i]
+		op.EntryID = entry.ID
+		err = r.h.Execute(ctx, dbqueries.CreatePosting,
+			op.EntryID,
+			op.AccountID,
+			op.OrderID,
+			op.OrderNum,
+			op.OperationType,
+			op.Amount,
+			op.ProcessedAt)
+		if err != nil {
+			r.l.Error("LedgerRepository: can't create posting", zap.Int("entryID", entry.ID), zap.Error(err))
+			return err
+		}
+		debit, credit := op.Amount, op.Amount
+		if op.OperationType == models.OperationDebit {
+			credit = 0
+		} else {
+			debit = 0
+		}
+		err = r.h.Execute(ctx, dbqueries.ApplyPosting, op.AccountID, credit-debit, debit, credit)
+		if err != nil {
+			r.l.Error("LedgerRepository: can't apply posting", zap.Int("accountID", op.AccountID), zap.Error(err))
+			return err
+		}
+		if op.OperationType == models.OperationDebit {
+			var parts []models.CreditLot
+			parts, err = r.consumeLots(ctx, entry.ID, op.AccountID, op.Amount)
+			if err != nil {
+				r.l.Error("LedgerRepository: can't update credit lots", zap.Int("accountID", op.AccountID), zap.Error(err))
+				return err
+			}
+			consumed = append(consumed, parts...)
+		}
+	}
+	for i := range entry.Operations {
+		op := &entry.Operations[i]
+		if op.OperationType != models.OperationCredit {
+			continue
+		}
+		consumed, err = r.createLots(ctx, op, consumed)
+		if err != nil {
+			r.l.Error("LedgerRepository: can't create credit lots", zap.Int("accountID", op.AccountID), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// consumeLots takes the amount from open lots of the account, the account row is already locked by the posting.
+// A debit exceeding open lots leaves the rest uncovered, system accounts have no lots.
+// Consumed parts are recorded for the entry and returned.
+func (r *LedgerRepository) consumeLots(ctx context.Context, entryID int, accountID int, amount money.Amount) ([]models.CreditLot, error) {
+	lots, err := r.findLots(ctx, dbqueries.FindOpenCreditLots, accountID)
+	if err != nil {
+		return nil, err
+	}
+	var parts []models.CreditLot
+	for _, lot := range lots {
+		if amount <= 0 {
+			break
+		}
+		taken := lot.Remaining
+		if taken > amount {
+			taken = amount
+		}
+		if err = r.h.Execute(ctx, dbqueries.UpdateCreditLotRemaining, lot.ID, lot.Remaining-taken); err != nil {
+			return nil, err
+		}
+		if err = r.h.Execute(ctx, dbqueries.CreateCreditLotConsumption, entryID, lot.ID, taken); err != nil {
+			return nil, err
+		}
+		lot.Amount, lot.Remaining = taken, taken
+		parts = append(parts, lot)
+		amount -= taken
+	}
+	return parts, nil
+}
+
+// createLots creates lots of the credit from the consumed parts, the rest of the amount is a new lot.
+// Lots of system accounts are skipped by the query, so parts credited to them are just dropped.
+// Returns the parts left for the next credits.
+func (r *LedgerRepository) createLots(ctx context.Context, op *models.Operation, parts []models.CreditLot) ([]models.CreditLot, error) {
+	amount := op.Amount
+	for len(parts) > 0 && amount > 0 {
+		part := &parts[0]
+		taken := part.Remaining
+		if taken > amount {
+			taken = amount
+		}
+		err := r.h.Execute(ctx, dbqueries.CreateCreditLot, op.AccountID, op.EntryID, op.OrderNum, taken, part.Expirable, part.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		part.Remaining -= taken
+		if part.Remaining <= 0 {
+			parts = parts[1:]
+		}
+		amount -= taken
+	}
+	if amount > 0 {
+		err := r.h.Execute(ctx, dbqueries.CreateCreditLot, op.AccountID, op.EntryID, op.OrderNum, amount, op.Expirable, op.ProcessedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+func (r *LedgerRepository) findLots(ctx context.Context, query string, id int) ([]models.CreditLot, error) {
+	rows, err := r.h.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lots []models.CreditLot
+	for rows.Next() {
+		var lot models.CreditLot
+		err = rows.Scan(&lot.ID, &lot.UserID, &lot.AccountID, &lot.EntryID, &lot.OrderNum, &lot.Amount, &lot.Remaining, &lot.Expirable, &lot.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+	return lots, nil
+}
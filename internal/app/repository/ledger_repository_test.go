@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLedgerRepository_Post_TransferKeepsExpiration(t *testing.T) {
+	ctx := context.Background()
+	initDatabase(ctx, postgresHandler)
+	userRepo, _ := NewUserRepository(postgresHandler, Log)
+	balanceRepo, _ := NewBalanceRepository(postgresHandler, Log)
+	ledgerRepo, _ := NewLedgerRepository(postgresHandler, Log)
+	lotRepo, _ := NewCreditLotRepository(postgresHandler, Log)
+	ledger := service.NewLedgerService(ledgerRepo, Log)
+
+	senderID, err := userRepo.Save(ctx, "sender", "pass")
+	checkNoError(t, err)
+	receiverID, err := userRepo.Save(ctx, "receiver", "pass")
+	checkNoError(t, err)
+	sender, err := balanceRepo.GetAccount(ctx, senderID)
+	checkNoError(t, err)
+	receiver, err := balanceRepo.GetAccount(ctx, receiverID)
+	checkNoError(t, err)
+	accruals, err := ledger.SystemAccountID(ctx, models.SystemAccountAccruals)
+	checkNoError(t, err)
+
+	accruedAt := time.Now().AddDate(0, -2, 0).Truncate(time.Second)
+	err = ledger.Post(ctx, &models.JournalEntry{
+		EntryType: models.EntryAccrual,
+		EntryKey:  "test:accrual",
+		CreatedAt: accruedAt,
+		Operations: []models.Operation{
+			{AccountID: accruals, OperationType: models.OperationDebit, Amount: money.Rubles(100, 0)},
+			{AccountID: sender.ID, OperationType: models.OperationCredit, Amount: money.Rubles(100, 0), Expirable: true},
+		},
+	})
+	checkNoError(t, err)
+	_, err = ledger.Transfer(ctx, service.LedgerTransfer{
+		EntryType: models.EntryTransfer,
+		From:      sender.ID,
+		To:        receiver.ID,
+		Amount:    money.Rubles(60, 0),
+	})
+	checkNoError(t, err)
+
+	expiry := service.NewExpiryService(lotRepo, balanceRepo, ledger, postgresHandler, Log, service.ExpiryConfig{Months: 1})
+	err = expiry.ExpirePoints(ctx)
+	checkNoError(t, err)
+
+	sender, err = balanceRepo.GetAccount(ctx, senderID)
+	checkNoError(t, err)
+	receiver, err = balanceRepo.GetAccount(ctx, receiverID)
+	checkNoError(t, err)
+	assert.Equal(t, money.Amount(0), sender.Balance)
+	assert.Equal(t, money.Amount(0), receiver.Balance, "transferred points must expire with the accrual")
+}
+
+func checkNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"hash/fnv"
+)
+
+var migrations = []models.Migration{
+	{Version: 1, Name: "initial_schema", Up: dbqueries.Migration0001Up, Down: dbqueries.Migration0001Down},
+	{Version: 2, Name: "order_accrual_attempts", Up: dbqueries.Migration0002Up, Down: dbqueries.Migration0002Down},
+	{Version: 3, Name: "idempotency_keys", Up: dbqueries.Migration0003Up, Down: dbqueries.Migration0003Down},
+	{Version: 4, Name: "ledger", Up: dbqueries.Migration0004Up, Down: dbqueries.Migration0004Down},
+	{Version: 5, Name: "withdrawal_lifecycle", Up: dbqueries.Migration0005Up, Down: dbqueries.Migration0005Down},
+	{Version: 6, Name: "clawbacks", Up: dbqueries.Migration0006Up, Down: dbqueries.Migration0006Down},
+	{Version: 7, Name: "credit_lots", Up: dbqueries.Migration0007Up, Down: dbqueries.Migration0007Down},
+	{Version: 8, Name: "order_pagination", Up: dbqueries.Migration0008Up, Down: dbqueries.Migration0008Down},
+	{Version: 9, Name: "order_status_history", Up: dbqueries.Migration0009Up, Down: dbqueries.Migration0009Down},
+	{Version: 10, Name: "user_events", Up: dbqueries.Migration0010Up, Down: dbqueries.Migration0010Down},
+	{Version: 11, Name: "webhooks", Up: dbqueries.Migration0011Up, Down: dbqueries.Migration0011Down},
+	{Version: 12, Name: "outbox_events", Up: dbqueries.Migration0012Up, Down: dbqueries.Migration0012Down},
+	{Version: 13, Name: "credit_lot_consumptions", Up: dbqueries.Migration0013Up, Down: dbqueries.Migration0013Down},
+	{Version: 14, Name: "operation_history_index", Up: dbqueries.Migration0014Up, Down: dbqueries.Migration0014Down},
+	{Version: 15, Name: "stuck_order_status_history", Up: dbqueries.Migration0015Up, Down: dbqueries.Migration0015Down},
+	{Version: 16, Name: "outbox_event_dedup", Up: dbqueries.Migration0016Up, Down: dbqueries.Migration0016Down},
+	{Version: 17, Name: "outbox_event_status", Up: dbqueries.Migration0017Up, Down: dbqueries.Migration0017Down},
+}
+
+type MigrationRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewMigrationRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.MigrationRepository, error) {
+	var target MigrationRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init migration repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *MigrationRepository) List() []models.Migration {
+	return migrations
+}
+
+// Lock also creates the schema_migrations table, so it must be called first in every migration transaction.
+func (r *MigrationRepository) Lock(ctx context.Context) error {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("gophermart:schema_migrations"))
+	if err := r.h.Execute(ctx, dbqueries.LockSchemaMigrations, int64(h.Sum64())); err != nil {
+		r.l.Error("MigrationRepository: can't lock schema migrations", zap.Error(err))
+		return err
+	}
+	if err := r.h.Execute(ctx, dbqueries.CreateSchemaMigrations); err != nil {
+		r.l.Error("MigrationRepository: can't create schema migrations table", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *MigrationRepository) GetApplied(ctx context.Context) ([]models.AppliedMigration, error) {
+	rows, err := r.h.Query(ctx, dbqueries.GetAppliedMigrations)
+	if err != nil {
+		r.l.Error("MigrationRepository: can't get applied migrations", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.AppliedMigration
+	for rows.Next() {
+		var m models.AppliedMigration
+		if err = rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			r.l.Error("MigrationRepository: can't scan applied migration", zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, m)
+	}
+	return resArray, nil
+}
+
+func (r *MigrationRepository) Execute(ctx context.Context, statement string) error {
+	if err := r.h.Execute(ctx, statement); err != nil {
+		r.l.Error("MigrationRepository: can't execute migration", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *MigrationRepository) SaveApplied(ctx context.Context, migration *models.AppliedMigration) error {
+	err := r.h.Execute(ctx, dbqueries.InsertSchemaMigration, migration.Version, migration.Name, migration.AppliedAt)
+	if err != nil {
+		r.l.Error("MigrationRepository: can't save applied migration", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *MigrationRepository) DeleteApplied(ctx context.Context, version int) error {
+	if err := r.h.Execute(ctx, dbqueries.DeleteSchemaMigration, version); err != nil {
+		r.l.Error("MigrationRepository: can't delete applied migration", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"go.uber.org/zap"
+	"time"
 )
 
 type OrderRepository struct {
@@ -38,6 +39,37 @@ func (or *OrderRepository) Save(ctx context.Context, order *models.Order) error
 	return err
 }
 
+func (or *OrderRepository) SaveBatch(ctx context.Context, orders []models.Order) ([]string, error) {
+	var (
+		userIDs             []int
+		nums, statuses      []string
+		uploadAt, updatedAt []time.Time
+	)
+	for _, o := range orders {
+		userIDs = append(userIDs, o.UserID)
+		nums = append(nums, o.Num)
+		statuses = append(statuses, o.Status)
+		uploadAt = append(uploadAt, o.UploadAt)
+		updatedAt = append(updatedAt, o.UpdatedAt)
+	}
+	rows, err := or.h.Query(ctx, dbqueries.CreateOrdersIfAbsent, userIDs, nums, statuses, uploadAt, updatedAt)
+	if err != nil {
+		or.l.Error("OrderRepository: can't save orders", zap.Int("count", len(orders)), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var created []string
+	for rows.Next() {
+		var num string
+		if err = rows.Scan(&num); err != nil {
+			or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.CreateOrdersIfAbsent), zap.Error(err))
+			return nil, err
+		}
+		created = append(created, num)
+	}
+	return created, rows.Err()
+}
+
 func (or *OrderRepository) GetByID(ctx context.Context, orderID int) (*models.Order, error) {
 	var res models.Order
 	row, err := or.h.QueryRow(ctx, dbqueries.GetOrderByID, orderID)
@@ -74,32 +106,122 @@ func (or *OrderRepository) GetByNum(ctx context.Context, num string) (*models.Or
 	return &res, nil
 }
 
+func (or *OrderRepository) FindByNums(ctx context.Context, nums []string) ([]models.Order, error) {
+	rows, err := or.h.Query(ctx, dbqueries.FindOrdersByNums, nums)
+	if err != nil {
+		or.l.Error("OrderRepository: request error", zap.String("query", dbqueries.FindOrdersByNums), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.Order
+	for rows.Next() {
+		var o models.Order
+		err := rows.Scan(&o.ID, &o.UserID, &o.Num, &o.Status, &o.UploadAt, &o.UpdatedAt)
+		if err != nil {
+			or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.FindOrdersByNums), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, o)
+	}
+	return resArray, rows.Err()
+}
+
+func (or *OrderRepository) GetUserOrder(ctx context.Context, userID int, num string) (*models.Order, error) {
+	var res models.Order
+	row, err := or.h.QueryRow(ctx, dbqueries.GetUserOrderByNum, userID, num)
+	if err != nil {
+		or.l.Error("OrderRepository: request error", zap.String("query", dbqueries.GetUserOrderByNum), zap.String("Num", num), zap.Error(err))
+		return nil, err
+	}
+	err = row.Scan(&res.ID, &res.Num, &res.UserID, &res.Status, &res.Accrual, &res.Clawback, &res.UploadAt, &res.UpdatedAt)
+	if err != nil && err.Error() == "no rows in result set" {
+		return nil, &models.NoRowFound
+	}
+	if err != nil {
+		or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.GetUserOrderByNum), zap.String("Num", num), zap.Error(err))
+		return nil, err
+	}
+	return &res, nil
+}
+
 func (or *OrderRepository) UpdateStatus(ctx context.Context, order *models.Order) error {
-	err := or.h.Execute(ctx, dbqueries.UpdateOrderStatus, order.ID, order.Status, order.UpdatedAt)
+	var accrualResponse interface{}
+	if len(order.AccrualResponse) > 0 {
+		accrualResponse = string(order.AccrualResponse)
+	}
+	err := or.h.Execute(ctx, dbqueries.UpdateOrderStatus, order.ID, order.Status, order.UpdatedAt, accrualResponse)
 	return err
 }
 
-func (or *OrderRepository) FindByUser(ctx context.Context, userID int) ([]models.Order, error) {
-	rows, err := or.h.Query(ctx, dbqueries.FindOrdersByUser, userID)
+func (or *OrderRepository) GetStatusHistory(ctx context.Context, orderID int) ([]models.OrderStatusChange, error) {
+	rows, err := or.h.Query(ctx, dbqueries.GetOrderStatusHistory, orderID)
+	if err != nil {
+		or.l.Error("OrderRepository: request error", zap.String("query", dbqueries.GetOrderStatusHistory), zap.Int("orderID", orderID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.OrderStatusChange
+	for rows.Next() {
+		var c models.OrderStatusChange
+		err := rows.Scan(&c.Status, &c.AccrualResponse, &c.ChangedAt)
+		if err != nil {
+			or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.GetOrderStatusHistory), zap.Int("orderID", orderID), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, c)
+	}
+	return resArray, rows.Err()
+}
+
+func (or *OrderRepository) FindByUser(ctx context.Context, filter models.OrderFilter) ([]models.Order, error) {
+	query := dbqueries.FindOrdersByUser
+	if filter.Descending {
+		query = dbqueries.FindOrdersByUserDesc
+	}
+	statuses := filter.Statuses
+	if statuses == nil {
+		statuses = []string{}
+	}
+	rows, err := or.h.Query(ctx, query, filter.UserID, statuses, nullTime(filter.UploadedFrom), nullTime(filter.UploadedTo),
+		nullTime(filter.AfterUploadAt), filter.AfterID, filter.Limit)
 	var resArray []models.Order
 
 	if err != nil {
-		or.l.Error("OrderRepository: request error", zap.String("query", dbqueries.FindOrdersByUser), zap.Int("userID", userID), zap.Error(err))
+		or.l.Error("OrderRepository: request error", zap.String("query", query), zap.Int("userID", filter.UserID), zap.Error(err))
 		return nil, err
 	}
 
+	defer rows.Close()
 	for rows.Next() {
 		var o models.Order
-		err := rows.Scan(&o.ID, &o.Num, &o.UserID, &o.Status, &o.Accrual, &o.UploadAt, &o.UpdatedAt)
+		err := rows.Scan(&o.ID, &o.Num, &o.UserID, &o.Status, &o.Accrual, &o.Clawback, &o.UploadAt, &o.UpdatedAt)
 		if err != nil {
-			or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.FindOrdersByUser), zap.Int("userID", userID), zap.Error(err))
-			break
+			or.l.Error("OrderRepository: scan rows error", zap.String("query", query), zap.Int("userID", filter.UserID), zap.Error(err))
+			return nil, err
 		}
 		resArray = append(resArray, o)
 	}
 	return resArray, nil
 }
 
+func (or *OrderRepository) CountByUser(ctx context.Context, filter models.OrderFilter) (int, error) {
+	statuses := filter.Statuses
+	if statuses == nil {
+		statuses = []string{}
+	}
+	row, err := or.h.QueryRow(ctx, dbqueries.CountOrdersByUser, filter.UserID, statuses, nullTime(filter.UploadedFrom), nullTime(filter.UploadedTo))
+	if err != nil {
+		or.l.Error("OrderRepository: request error", zap.String("query", dbqueries.CountOrdersByUser), zap.Int("userID", filter.UserID), zap.Error(err))
+		return 0, err
+	}
+	var count int
+	if err = row.Scan(&count); err != nil {
+		or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.CountOrdersByUser), zap.Int("userID", filter.UserID), zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
 func (or *OrderRepository) LockOrder(ctx context.Context, orderNum string) (*models.Order, error) {
 	var res models.Order
 	row, err := or.h.QueryRow(ctx, dbqueries.GetOrderByNumForUpdate, orderNum)
@@ -107,7 +229,7 @@ func (or *OrderRepository) LockOrder(ctx context.Context, orderNum string) (*mod
 		or.l.Error("OrderRepository: can't get order for update", zap.Error(err))
 		return nil, err
 	}
-	err = row.Scan(&res.ID, &res.UserID, &res.Num, &res.Status, &res.UploadAt, &res.UpdatedAt)
+	err = row.Scan(&res.ID, &res.UserID, &res.Num, &res.Status, &res.UploadAt, &res.UpdatedAt, &res.AttemptCount, &res.LastError, &res.LeaseOwner)
 
 	if err != nil {
 		or.l.Error("OrderRepository: can't get account for update", zap.Error(err))
@@ -120,22 +242,102 @@ func (or *OrderRepository) LockOrder(ctx context.Context, orderNum string) (*mod
 	return &res, nil
 }
 
-func (or *OrderRepository) FindNotProcessed(ctx context.Context) ([]models.Order, error) {
-	const rowCountLimit = 20
-	rows, err := or.h.Query(ctx, dbqueries.FindOrderByStatuses, models.OrderStatusProcessing, models.OrderStatusNew, models.OrderStatusRegistered, "", "", rowCountLimit)
+// FindNotProcessed leases up to limit due orders to leaseOwner, so concurrent instances never get the same order
+// until the lease expires.
+func (or *OrderRepository) FindNotProcessed(ctx context.Context, leaseOwner string, leaseUntil time.Time, limit int) ([]models.Order, error) {
+	rows, err := or.h.Query(ctx, dbqueries.LeaseOrdersByStatuses, leaseOwner, leaseUntil,
+		models.OrderStatusProcessing, models.OrderStatusNew, models.OrderStatusRegistered, time.Now(), limit)
 	var resArray []models.Order
 	if err != nil {
-		or.l.Error("OrderRepository: request error", zap.String("query", dbqueries.FindOrderByStatuses), zap.Error(err))
+		or.l.Error("OrderRepository: request error", zap.String("query", dbqueries.LeaseOrdersByStatuses), zap.Error(err))
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var o models.Order
-		err := rows.Scan(&o.ID, &o.UserID, &o.Num, &o.Status, &o.UploadAt, &o.UpdatedAt)
+		err := rows.Scan(&o.ID, &o.UserID, &o.Num, &o.Status, &o.UploadAt, &o.UpdatedAt, &o.AttemptCount, &o.NextAttemptAt, &o.LeaseOwner)
 		if err != nil {
-			or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.FindOrderByStatuses), zap.Error(err))
-			break
+			or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.LeaseOrdersByStatuses), zap.Error(err))
+			return nil, err
 		}
 		resArray = append(resArray, o)
 	}
-	return resArray, nil
+	return resArray, rows.Err()
+}
+
+func (or *OrderRepository) ScheduleAttempt(ctx context.Context, order *models.Order) error {
+	var lastError interface{}
+	if order.LastError != "" {
+		lastError = order.LastError
+	}
+	err := or.h.Execute(ctx, dbqueries.UpdateOrderAttempt, order.ID, order.AttemptCount, lastError, order.NextAttemptAt, order.LeaseOwner)
+	if err != nil {
+		or.l.Error("OrderRepository: can't schedule order attempt", zap.Int("orderID", order.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (or *OrderRepository) MarkStuck(ctx context.Context, order *models.Order) error {
+	err := or.h.Execute(ctx, dbqueries.UpdateOrderStuck, order.ID, models.OrderStatusStuck, order.AttemptCount, order.LastError, order.UpdatedAt,
+		models.OrderStatusProcessing, models.OrderStatusNew, models.OrderStatusRegistered, order.LeaseOwner)
+	if err != nil {
+		or.l.Error("OrderRepository: can't mark order as stuck", zap.Int("orderID", order.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (or *OrderRepository) MarkStuckOlderThan(ctx context.Context, uploadedBefore time.Time, reason string) (int, error) {
+	row, err := or.h.QueryRow(ctx, dbqueries.MarkStuckOrdersOlderThan, models.OrderStatusStuck, reason, time.Now(),
+		models.OrderStatusProcessing, models.OrderStatusNew, models.OrderStatusRegistered, uploadedBefore)
+	if err != nil {
+		or.l.Error("OrderRepository: can't mark old orders as stuck", zap.Error(err))
+		return 0, err
+	}
+	var count int
+	err = row.Scan(&count)
+	if err != nil {
+		or.l.Error("OrderRepository: can't mark old orders as stuck", zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
+func (or *OrderRepository) FindStuck(ctx context.Context) ([]models.Order, error) {
+	rows, err := or.h.Query(ctx, dbqueries.FindStuckOrders, models.OrderStatusStuck)
+	var resArray []models.Order
+	if err != nil {
+		or.l.Error("OrderRepository: request error", zap.String("query", dbqueries.FindStuckOrders), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o models.Order
+		err := rows.Scan(&o.ID, &o.UserID, &o.Num, &o.Status, &o.UploadAt, &o.UpdatedAt, &o.AttemptCount, &o.LastError)
+		if err != nil {
+			or.l.Error("OrderRepository: scan rows error", zap.String("query", dbqueries.FindStuckOrders), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, o)
+	}
+	return resArray, rows.Err()
+}
+
+func (or *OrderRepository) Revive(ctx context.Context, num string) (*models.Order, error) {
+	var res models.Order
+	row, err := or.h.QueryRow(ctx, dbqueries.ReviveOrder, num, models.OrderStatusNew, time.Now(), models.OrderStatusStuck)
+	if err != nil {
+		or.l.Error("OrderRepository: can't revive order", zap.String("Num", num), zap.Error(err))
+		return nil, err
+	}
+	err = row.Scan(&res.ID, &res.UserID, &res.Num, &res.Status, &res.UploadAt, &res.UpdatedAt)
+	if err != nil && err.Error() == "no rows in result set" {
+		return nil, &models.NoRowFound
+	}
+	if err != nil {
+		or.l.Error("OrderRepository: can't revive order", zap.String("Num", num), zap.Error(err))
+		return nil, err
+	}
+	return &res, nil
 }
@@ -99,6 +99,62 @@ func TestOrderRepository_UpdateStatus(t *testing.T) {
 	}
 }
 
+func TestOrderRepository_SaveBatch(t *testing.T) {
+	ctx := context.Background()
+	initDatabase(ctx, postgresHandler)
+	target, _ := NewOrderRepository(postgresHandler, Log)
+	uploadAt := time.Now().Truncate(time.Microsecond)
+	err := target.Save(ctx, &models.Order{UserID: 2, Num: "51", Status: models.OrderStatusNew, UploadAt: uploadAt, UpdatedAt: uploadAt})
+	assert.NoError(t, err)
+
+	created, err := target.SaveBatch(ctx, []models.Order{
+		{UserID: 1, Num: "51", Status: models.OrderStatusNew, UploadAt: uploadAt.Add(time.Second), UpdatedAt: uploadAt.Add(time.Second)},
+		{UserID: 1, Num: "52", Status: models.OrderStatusNew, UploadAt: uploadAt.Add(time.Second), UpdatedAt: uploadAt.Add(time.Second)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"52"}, created, "uploaded numbers are skipped")
+	orders, err := target.FindByNums(ctx, []string{"51", "52", "53"})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+	for _, o := range orders {
+		if o.Num == "51" {
+			assert.Equal(t, 2, o.UserID, "the order keeps its owner")
+		} else {
+			assert.Equal(t, 1, o.UserID)
+			history, err := target.GetStatusHistory(ctx, o.ID)
+			assert.NoError(t, err)
+			assert.Len(t, history, 1)
+		}
+	}
+}
+
+func TestOrderRepository_GetStatusHistory(t *testing.T) {
+	ctx := context.Background()
+	initDatabase(ctx, postgresHandler)
+	target, _ := NewOrderRepository(postgresHandler, Log)
+	uploadAt := time.Now().Truncate(time.Microsecond)
+	err := target.Save(ctx, &models.Order{UserID: 3, Num: "31", Status: models.OrderStatusNew, UploadAt: uploadAt, UpdatedAt: uploadAt})
+	assert.NoError(t, err)
+	order, err := target.GetUserOrder(ctx, 3, "31")
+	assert.NoError(t, err)
+	_, err = target.GetUserOrder(ctx, 4, "31")
+	assert.ErrorIs(t, err, &models.NoRowFound, "orders of other users are not found")
+
+	response := []byte(`{"order": "31", "status": "PROCESSED", "accrual": 500}`)
+	order.Status = models.OrderStatusProcessed
+	order.UpdatedAt = uploadAt.Add(time.Minute)
+	order.AccrualResponse = response
+	assert.NoError(t, target.UpdateStatus(ctx, order))
+	assert.NoError(t, target.UpdateStatus(ctx, order), "the same status is not recorded twice")
+
+	history, err := target.GetStatusHistory(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.OrderStatusChange{
+		{Status: models.OrderStatusNew, ChangedAt: uploadAt},
+		{Status: models.OrderStatusProcessed, AccrualResponse: response, ChangedAt: uploadAt.Add(time.Minute)},
+	}, history)
+}
+
 func TestOrderRepository_FindByUser(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -143,13 +199,24 @@ func TestOrderRepository_FindByUser(t *testing.T) {
 			}
 
 			fmt.Println("get saved objects")
-			resArr, err := target.FindByUser(context.Background(), tt.userID)
+			filter := models.OrderFilter{UserID: tt.userID, Statuses: []string{tt.objStatus}, Limit: 100}
+			resArr, err := target.FindByUser(context.Background(), filter)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("FindByUser() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
 			fmt.Println("check got objects")
 			assert.Equal(t, tt.objCount, len(resArr), "Compare error (order.status): expected %s,  got %s", tt.objCount, len(resArr))
+			count, err := target.CountByUser(context.Background(), filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.objCount, count)
+			if len(resArr) > 1 {
+				filter.Limit = 1
+				filter.AfterUploadAt, filter.AfterID = resArr[0].UploadAt, resArr[0].ID
+				page, err := target.FindByUser(context.Background(), filter)
+				assert.NoError(t, err)
+				assert.Equal(t, resArr[1:2], page, "the next page starts after the cursor")
+			}
 		})
 	}
 }
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+type OutboxRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewOutboxRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.OutboxRepository, error) {
+	var target OutboxRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init outbox repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *OutboxRepository) Save(ctx context.Context, event *models.OutboxEvent) error {
+	row, err := r.h.QueryRow(ctx, dbqueries.CreateOutboxEvent,
+		event.AggregateType, event.AggregateID, event.UserID, event.Type, string(event.Payload), event.CreatedAt)
+	if err != nil {
+		r.l.Error("OutboxRepository: can't save event", zap.String("type", event.Type), zap.Error(err))
+		return err
+	}
+	if err = row.Scan(&event.ID); err != nil {
+		r.l.Error("OutboxRepository: can't save event", zap.String("type", event.Type), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *OutboxRepository) FindUnpublished(ctx context.Context, afterID int, limit int) ([]models.OutboxEvent, error) {
+	return r.find(ctx, dbqueries.FindUnpublishedOutboxEvents, models.OutboxEventPending, afterID, limit)
+}
+
+func (r *OutboxRepository) FindByStatus(ctx context.Context, status string, limit int) ([]models.OutboxEvent, error) {
+	return r.find(ctx, dbqueries.FindOutboxEventsByStatus, status, limit)
+}
+
+func (r *OutboxRepository) find(ctx context.Context, query string, args ...interface{}) ([]models.OutboxEvent, error) {
+	rows, err := r.h.Query(ctx, query, args...)
+	if err != nil {
+		r.l.Error("OutboxRepository: request error", zap.String("query", query), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.OutboxEvent
+	for rows.Next() {
+		var e models.OutboxEvent
+		err = rows.Scan(&e.ID, &e.AggregateType, &e.AggregateID, &e.UserID, &e.Type, &e.Payload, &e.CreatedAt, &e.AttemptCount,
+			&e.Status, &e.LastError)
+		if err != nil {
+			r.l.Error("OutboxRepository: scan rows error", zap.String("query", query), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, e)
+	}
+	return resArray, rows.Err()
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, ids []int, publishedAt time.Time) error {
+	if err := r.h.Execute(ctx, dbqueries.MarkOutboxEventsPublished, ids, models.OutboxEventPublished, publishedAt); err != nil {
+		r.l.Error("OutboxRepository: can't mark events as published", zap.Ints("ids", ids), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *OutboxRepository) RecordFailure(ctx context.Context, id int, status string, lastError string) error {
+	if err := r.h.Execute(ctx, dbqueries.RecordOutboxEventFailure, id, status, lastError); err != nil {
+		r.l.Error("OutboxRepository: can't record failure", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *OutboxRepository) Retry(ctx context.Context, id int) error {
+	row, err := r.h.QueryRow(ctx, dbqueries.RetryOutboxEvent, id, models.OutboxEventPending, models.OutboxEventFailed)
+	if err != nil {
+		r.l.Error("OutboxRepository: can't retry event", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+	if err = row.Scan(&id); err != nil {
+		if err.Error() == "no rows in result set" {
+			return &models.NoRowFound
+		}
+		r.l.Error("OutboxRepository: can't retry event", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *OutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int, error) {
+	var count int
+	row, err := r.h.QueryRow(ctx, dbqueries.DeleteOutboxEventsPublishedBefore, before)
+	if err != nil {
+		r.l.Error("OutboxRepository: can't delete published events", zap.Error(err))
+		return 0, err
+	}
+	if err = row.Scan(&count); err != nil {
+		r.l.Error("OutboxRepository: can't delete published events", zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
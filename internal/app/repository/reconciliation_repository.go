@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+)
+
+type ReconciliationRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewReconciliationRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.ReconciliationRepository, error) {
+	var target ReconciliationRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init reconciliation repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *ReconciliationRepository) FindAccountDrifts(ctx context.Context) ([]models.AccountTotals, error) {
+	rows, err := r.h.Query(ctx, dbqueries.GetAccountDrifts)
+	if err != nil {
+		r.l.Error("ReconciliationRepository: request error", zap.String("query", dbqueries.GetAccountDrifts), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.AccountTotals
+	for rows.Next() {
+		var t models.AccountTotals
+		err = rows.Scan(&t.AccountID, &t.UserID, &t.Balance, &t.Debit, &t.Credit, &t.LedgerDebit, &t.LedgerCredit)
+		if err != nil {
+			r.l.Error("ReconciliationRepository: scan rows error", zap.String("query", dbqueries.GetAccountDrifts), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, t)
+	}
+	return resArray, nil
+}
+
+func (r *ReconciliationRepository) LockAccountTotals(ctx context.Context, accountID int) (*models.AccountTotals, error) {
+	row, err := r.h.QueryRow(ctx, dbqueries.LockAccountTotals, accountID)
+	if err != nil {
+		r.l.Error("ReconciliationRepository: can't lock account totals", zap.Int("accountID", accountID), zap.Error(err))
+		return nil, err
+	}
+	var t models.AccountTotals
+	err = row.Scan(&t.AccountID, &t.UserID, &t.Balance, &t.Debit, &t.Credit, &t.LedgerDebit, &t.LedgerCredit)
+	if err != nil && err.Error() == "no rows in result set" {
+		return nil, &models.NoRowFound
+	}
+	if err != nil {
+		r.l.Error("ReconciliationRepository: can't scan account totals", zap.Int("accountID", accountID), zap.Error(err))
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *ReconciliationRepository) FindUnbalancedEntries(ctx context.Context) ([]models.EntryTotals, error) {
+	rows, err := r.h.Query(ctx, dbqueries.GetUnbalancedEntries)
+	if err != nil {
+		r.l.Error("ReconciliationRepository: request error", zap.String("query", dbqueries.GetUnbalancedEntries), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.EntryTotals
+	for rows.Next() {
+		var t models.EntryTotals
+		if err = rows.Scan(&t.EntryID, &t.Debit, &t.Credit); err != nil {
+			r.l.Error("ReconciliationRepository: scan rows error", zap.String("query", dbqueries.GetUnbalancedEntries), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, t)
+	}
+	return resArray, nil
+}
+
+func (r *ReconciliationRepository) SyncAccount(ctx context.Context, accountID int, balance, debit, credit money.Amount) error {
+	err := r.h.Execute(ctx, dbqueries.SyncAccount, accountID, balance, debit, credit)
+	if err != nil {
+		r.l.Error("ReconciliationRepository: can't sync account", zap.Int("accountID", accountID), zap.Error(err))
+		return err
+	}
+	return nil
+}
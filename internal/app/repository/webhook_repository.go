@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+type WebhookRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewWebhookRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.WebhookRepository, error) {
+	var target WebhookRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init webhook repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	eventTypes := webhook.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	row, err := r.h.QueryRow(ctx, dbqueries.CreateWebhook, webhook.UserID, webhook.URL, webhook.Secret, eventTypes, webhook.CreatedAt)
+	if err == nil {
+		err = row.Scan(&webhook.ID)
+	}
+	if err != nil {
+		r.l.Error("WebhookRepository: can't create webhook", zap.Int("userID", webhook.UserID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *WebhookRepository) FindByUser(ctx context.Context, userID int) ([]models.Webhook, error) {
+	rows, err := r.h.Query(ctx, dbqueries.FindWebhooksByUser, userID)
+	if err != nil {
+		r.l.Error("WebhookRepository: request error", zap.String("query", dbqueries.FindWebhooksByUser), zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.Webhook
+	for rows.Next() {
+		var w models.Webhook
+		if err = rows.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &w.EventTypes, &w.CreatedAt); err != nil {
+			r.l.Error("WebhookRepository: scan rows error", zap.String("query", dbqueries.FindWebhooksByUser), zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, w)
+	}
+	return resArray, rows.Err()
+}
+
+func (r *WebhookRepository) Get(ctx context.Context, userID int, id int) (*models.Webhook, error) {
+	var res models.Webhook
+	row, err := r.h.QueryRow(ctx, dbqueries.GetWebhook, id, userID)
+	if err != nil {
+		r.l.Error("WebhookRepository: request error", zap.String("query", dbqueries.GetWebhook), zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	err = row.Scan(&res.ID, &res.UserID, &res.URL, &res.Secret, &res.EventTypes, &res.CreatedAt)
+	if err != nil && err.Error() == "no rows in result set" {
+		return nil, &models.NoRowFound
+	}
+	if err != nil {
+		r.l.Error("WebhookRepository: scan rows error", zap.String("query", dbqueries.GetWebhook), zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, userID int, id int) error {
+	var count int
+	row, err := r.h.QueryRow(ctx, dbqueries.DeleteWebhook, id, userID)
+	if err == nil {
+		err = row.Scan(&count)
+	}
+	if err != nil {
+		r.l.Error("WebhookRepository: can't delete webhook", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+	if count == 0 {
+		return &models.NoRowFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, userID int, delivery *models.WebhookDelivery) (int, error) {
+	var count int
+	row, err := r.h.QueryRow(ctx, dbqueries.EnqueueWebhookDeliveries, userID, delivery.EventID, delivery.EventType,
+		string(delivery.Payload), delivery.Status, delivery.CreatedAt, nullID(delivery.OutboxEventID))
+	if err == nil {
+		err = row.Scan(&count)
+	}
+	if err != nil {
+		r.l.Error("WebhookRepository: can't enqueue deliveries", zap.String("eventID", delivery.EventID), zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	row, err := r.h.QueryRow(ctx, dbqueries.CreateWebhookDelivery, delivery.WebhookID, delivery.UserID, delivery.EventID,
+		delivery.EventType, string(delivery.Payload), delivery.Status, delivery.CreatedAt)
+	if err == nil {
+		err = row.Scan(&delivery.ID)
+	}
+	if err != nil {
+		r.l.Error("WebhookRepository: can't create delivery", zap.String("eventID", delivery.EventID), zap.Error(err))
+		return err
+	}
+	delivery.NextAttemptAt = delivery.CreatedAt
+	delivery.UpdatedAt = delivery.CreatedAt
+	return nil
+}
+
+func (r *WebhookRepository) FindDeliveries(ctx context.Context, userID int, webhookID int, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.h.Query(ctx, dbqueries.FindWebhookDeliveries, webhookID, userID, limit)
+	if err != nil {
+		r.l.Error("WebhookRepository: request error", zap.String("query", dbqueries.FindWebhookDeliveries), zap.Int("webhookID", webhookID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err = rows.Scan(deliveryFields(&d)...); err != nil {
+			r.l.Error("WebhookRepository: scan rows error", zap.String("query", dbqueries.FindWebhookDeliveries), zap.Int("webhookID", webhookID), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, d)
+	}
+	return resArray, rows.Err()
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, userID int, webhookID int, id int) (*models.WebhookDelivery, error) {
+	var res models.WebhookDelivery
+	row, err := r.h.QueryRow(ctx, dbqueries.GetWebhookDelivery, id, webhookID, userID)
+	if err != nil {
+		r.l.Error("WebhookRepository: request error", zap.String("query", dbqueries.GetWebhookDelivery), zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	err = row.Scan(deliveryFields(&res)...)
+	if err != nil && err.Error() == "no rows in result set" {
+		return nil, &models.NoRowFound
+	}
+	if err != nil {
+		r.l.Error("WebhookRepository: scan rows error", zap.String("query", dbqueries.GetWebhookDelivery), zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *WebhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.h.Query(ctx, dbqueries.FindDueWebhookDeliveries, models.WebhookDeliveryPending, now, limit)
+	if err != nil {
+		r.l.Error("WebhookRepository: request error", zap.String("query", dbqueries.FindDueWebhookDeliveries), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err = rows.Scan(append(deliveryFields(&d), &d.URL, &d.Secret)...); err != nil {
+			r.l.Error("WebhookRepository: scan rows error", zap.String("query", dbqueries.FindDueWebhookDeliveries), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, d)
+	}
+	return resArray, rows.Err()
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	var lastError interface{}
+	if delivery.LastError != "" {
+		lastError = delivery.LastError
+	}
+	var responseCode interface{}
+	if delivery.ResponseCode != 0 {
+		responseCode = delivery.ResponseCode
+	}
+	err := r.h.Execute(ctx, dbqueries.UpdateWebhookDelivery, delivery.ID, delivery.Status, delivery.AttemptCount,
+		delivery.NextAttemptAt, responseCode, lastError, delivery.UpdatedAt)
+	if err != nil {
+		r.l.Error("WebhookRepository: can't update delivery", zap.Int("id", delivery.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func deliveryFields(d *models.WebhookDelivery) []interface{} {
+	return []interface{}{&d.ID, &d.WebhookID, &d.UserID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.AttemptCount,
+		&d.NextAttemptAt, &d.ResponseCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/dbqueries"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"go.uber.org/zap"
+)
+
+type WithdrawalRepository struct {
+	h basedbhandler.DBHandler
+	l *infrastructure.Logger
+}
+
+func NewWithdrawalRepository(dbHandler basedbhandler.DBHandler, log *infrastructure.Logger) (models.WithdrawalRepository, error) {
+	var target WithdrawalRepository
+	if dbHandler == nil {
+		return nil, errors.New("can't init withdrawal repository")
+	}
+	target.h = dbHandler
+	target.l = log
+	return &target, nil
+}
+
+func (r *WithdrawalRepository) Create(ctx context.Context, w *models.Withdrawal) error {
+	row, err := r.h.QueryRow(ctx, dbqueries.CreateWithdrawal, w.UserID, w.AccountID, w.OrderNum, w.Amount, w.Status, w.EntryID, w.ProcessedAt)
+	if err == nil {
+		err = row.Scan(&w.ID)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return &models.UniqueViolation
+	}
+	if err != nil {
+		r.l.Error("WithdrawalRepository: can't create withdrawal", zap.String("orderNum", w.OrderNum), zap.Error(err))
+		return err
+	}
+	w.UpdatedAt = w.ProcessedAt
+	return nil
+}
+
+func (r *WithdrawalRepository) Lock(ctx context.Context, id int) (*models.Withdrawal, error) {
+	row, err := r.h.QueryRow(ctx, dbqueries.GetWithdrawalForUpdate, id)
+	if err != nil {
+		r.l.Error("WithdrawalRepository: can't get withdrawal for update", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	w, err := r.scan(row)
+	if err != nil && err.Error() == "no rows in result set" {
+		return nil, &models.NoRowFound
+	}
+	if err != nil {
+		r.l.Error("WithdrawalRepository: can't scan withdrawal", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return w, nil
+}
+
+func (r *WithdrawalRepository) UpdateStatus(ctx context.Context, w *models.Withdrawal) error {
+	err := r.h.Execute(ctx, dbqueries.UpdateWithdrawalStatus, w.ID, w.Status, w.Reason, w.UpdatedAt)
+	if err != nil {
+		r.l.Error("WithdrawalRepository: can't update withdrawal status", zap.Int("id", w.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *WithdrawalRepository) FindByStatus(ctx context.Context, status string) ([]models.Withdrawal, error) {
+	rows, err := r.h.Query(ctx, dbqueries.FindWithdrawalsByStatus, status)
+	if err != nil {
+		r.l.Error("WithdrawalRepository: request error", zap.String("query", dbqueries.FindWithdrawalsByStatus), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var resArray []models.Withdrawal
+	for rows.Next() {
+		w, err := r.scan(rows)
+		if err != nil {
+			r.l.Error("WithdrawalRepository: scan rows error", zap.String("query", dbqueries.FindWithdrawalsByStatus), zap.Error(err))
+			return nil, err
+		}
+		resArray = append(resArray, *w)
+	}
+	return resArray, nil
+}
+
+func (r *WithdrawalRepository) GetWithdrawnTotal(ctx context.Context, userID int) (money.Amount, error) {
+	row, err := r.h.QueryRow(ctx, dbqueries.GetWithdrawnTotal, userID)
+	if err != nil {
+		r.l.Error("WithdrawalRepository: can't get withdrawn total", zap.Int("userID", userID), zap.Error(err))
+		return 0, err
+	}
+	var total money.Amount
+	if err = row.Scan(&total); err != nil {
+		r.l.Error("WithdrawalRepository: can't scan withdrawn total", zap.Int("userID", userID), zap.Error(err))
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *WithdrawalRepository) scan(row basedbhandler.Row) (*models.Withdrawal, error) {
+	var w models.Withdrawal
+	err := row.Scan(&w.ID, &w.UserID, &w.AccountID, &w.OrderNum, &w.Amount, &w.Status, &w.EntryID, &w.Reason, &w.ProcessedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
@@ -13,7 +13,6 @@ import (
 func publicRoutes(
 	r chi.Router,
 	handler *handlers.AuthHandler,
-	accrual *handlers.AccrualHandler,
 	postgresHandlerTx *datastore.PostgresHandlerTX,
 	log *infrastructure.Logger,
 ) {
@@ -24,7 +23,35 @@ func publicRoutes(
 		router.Use(mymiddleware.Transactional(postgresHandlerTx, log))
 		router.Post("/api/user/register", handler.Register)
 		router.Post("/api/user/login", handler.Login)
-		router.Post("/api/accrual/process/{orderNum}", accrual.ProcessOrder)
+	})
+}
+
+func adminRoutes(
+	r chi.Router,
+	adminToken string,
+	postgresHandlerTx *datastore.PostgresHandlerTX,
+	handler *handlers.AdminHandler,
+	withdrawalHandler *handlers.WithdrawalHandler,
+	clawbackHandler *handlers.ClawbackHandler,
+	outboxHandler *handlers.OutboxHandler,
+	log *infrastructure.Logger,
+) {
+	r.Group(func(router chi.Router) {
+		router.Use(middleware.CleanPath)
+		router.Use(middleware.Logger)
+		router.Use(middleware.Recoverer)
+		router.Use(mymiddleware.AdminAuth(adminToken, log))
+		router.Use(mymiddleware.Transactional(postgresHandlerTx, log))
+		router.Get("/api/admin/orders/stuck", handler.GetStuckOrders)
+		router.Post("/api/admin/orders/{number}/revive", handler.ReviveOrder)
+		router.Post("/api/admin/orders/{number}/clawback", clawbackHandler.Clawback)
+		router.Get("/api/admin/accrual/status", handler.GetAccrualStatus)
+		router.Get("/api/admin/withdrawals", withdrawalHandler.GetWithdrawals)
+		router.Post("/api/admin/withdrawals/{id}/confirm", withdrawalHandler.ConfirmWithdrawal)
+		router.Post("/api/admin/withdrawals/{id}/reject", withdrawalHandler.RejectWithdrawal)
+		router.Post("/api/admin/withdrawals/{id}/reverse", withdrawalHandler.ReverseWithdrawal)
+		router.Get("/api/admin/outbox/failed", outboxHandler.GetFailedEvents)
+		router.Post("/api/admin/outbox/{id}/retry", outboxHandler.RetryEvent)
 	})
 }
 
@@ -43,7 +70,47 @@ func protectedOrderRoutes(
 		router.Use(jwtauth.Authenticator)
 		router.Use(mymiddleware.Transactional(postgresHandlerTx, log))
 		router.Post("/api/user/orders", handler.RegisterNewOrder)
+		router.Post("/api/user/orders/bulk", handler.UploadOrders)
 		router.Get("/api/user/orders", handler.GetOrderList)
+		router.Get("/api/user/orders/{number}", handler.GetOrder)
+	})
+}
+
+// protectedEventRoutes are not transactional, a stream lives as long as the client is connected.
+func protectedEventRoutes(
+	r chi.Router,
+	tokenAuth *jwtauth.JWTAuth,
+	handler *handlers.EventHandler,
+) {
+	r.Group(func(router chi.Router) {
+		router.Use(middleware.CleanPath)
+		router.Use(middleware.Logger)
+		router.Use(middleware.Recoverer)
+		router.Use(jwtauth.Verifier(tokenAuth))
+		router.Use(jwtauth.Authenticator)
+		router.Get("/api/user/events", handler.Stream)
+	})
+}
+
+func protectedWebhookRoutes(
+	r chi.Router,
+	tokenAuth *jwtauth.JWTAuth,
+	postgresHandlerTx *datastore.PostgresHandlerTX,
+	handler *handlers.WebhookHandler,
+	log *infrastructure.Logger,
+) {
+	r.Group(func(router chi.Router) {
+		router.Use(middleware.CleanPath)
+		router.Use(middleware.Logger)
+		router.Use(middleware.Recoverer)
+		router.Use(jwtauth.Verifier(tokenAuth))
+		router.Use(jwtauth.Authenticator)
+		router.Use(mymiddleware.Transactional(postgresHandlerTx, log))
+		router.Post("/api/user/webhooks", handler.CreateWebhook)
+		router.Get("/api/user/webhooks", handler.GetWebhooks)
+		router.Delete("/api/user/webhooks/{id}", handler.DeleteWebhook)
+		router.Get("/api/user/webhooks/{id}/deliveries", handler.GetDeliveries)
+		router.Post("/api/user/webhooks/{id}/deliveries/{deliveryID}/redeliver", handler.Redeliver)
 	})
 }
 
@@ -52,6 +119,8 @@ func protectedBalanceRoutes(
 	tokenAuth *jwtauth.JWTAuth,
 	postgresHandlerTx *datastore.PostgresHandlerTX,
 	handler *handlers.BalanceHandler,
+	transferHandler *handlers.TransferHandler,
+	idempotencyService mymiddleware.IdempotencyService,
 	log *infrastructure.Logger,
 ) {
 	r.Group(func(router chi.Router) {
@@ -62,7 +131,10 @@ func protectedBalanceRoutes(
 		router.Use(jwtauth.Authenticator)
 		router.Use(mymiddleware.Transactional(postgresHandlerTx, log))
 		router.Get("/api/user/balance", handler.GetBalance)
-		router.Post("/api/user/balance/withdraw", handler.Withdraw)
+		router.With(mymiddleware.Idempotency(idempotencyService, log)).Post("/api/user/balance/withdraw", handler.Withdraw)
 		router.Get("/api/user/balance/withdrawals", handler.GetWithdrawalsList)
+		router.Get("/api/user/balance/history", handler.GetHistory)
+		router.Get("/api/user/balance/statement", handler.GetStatement)
+		router.With(mymiddleware.Idempotency(idempotencyService, log)).Post("/api/user/balance/transfer", transferHandler.Transfer)
 	})
 }
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// Scheduler runs periodic jobs on a single replica at a time. Every job is guarded by its own Postgres advisory
+// lock: the replica holding the lock runs the job, others keep trying to take the lock over and succeed as soon as
+// the holder releases it or its database session dies.
+type Scheduler struct {
+	locker        basedbhandler.AdvisoryLocker
+	log           *infrastructure.Logger
+	retryInterval time.Duration
+	jobs          []job
+}
+
+func NewScheduler(locker basedbhandler.AdvisoryLocker, log *infrastructure.Logger, retryInterval time.Duration) *Scheduler {
+	var target Scheduler
+	if retryInterval <= 0 {
+		retryInterval = 10 * time.Second
+	}
+	target.locker = locker
+	target.log = log
+	target.retryInterval = retryInterval
+	return &target
+}
+
+// Add registers a job. Jobs must be added before Start.
+func (s *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start runs all registered jobs until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			s.runJob(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) runJob(ctx context.Context, j job) {
+	key := jobLockKey(j.name)
+	for ctx.Err() == nil {
+		lock, err := s.locker.TryAdvisoryLock(ctx, key)
+		if err != nil {
+			s.log.Error("Scheduler: can't acquire job lock", zap.String("job", j.name), zap.Error(err))
+		}
+		if lock != nil {
+			s.log.Info("Scheduler: job leadership acquired", zap.String("job", j.name))
+			s.lead(ctx, j, lock)
+			if err := lock.Release(context.Background()); err != nil {
+				s.log.Error("Scheduler: can't release job lock", zap.String("job", j.name), zap.Error(err))
+			}
+			s.log.Info("Scheduler: job leadership released", zap.String("job", j.name))
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(s.retryInterval):
+		}
+	}
+}
+
+// lead runs the job while the lock is held. It returns when ctx is done or the lock is lost.
+func (s *Scheduler) lead(ctx context.Context, j job, lock basedbhandler.AdvisoryLock) {
+	t := time.NewTicker(j.interval)
+	defer t.Stop()
+	for ctx.Err() == nil {
+		if err := lock.Check(ctx); err != nil {
+			s.log.Warn("Scheduler: job lock lost", zap.String("job", j.name), zap.Error(err))
+			return
+		}
+		started := time.Now()
+		if err := j.run(ctx); err != nil {
+			s.log.Error("Scheduler: job failed", zap.String("job", j.name), zap.Error(err))
+		} else {
+			s.log.Debug("Scheduler: job done", zap.String("job", j.name), zap.Duration("duration", time.Since(started)))
+		}
+		select {
+		case <-ctx.Done():
+		case <-t.C:
+		}
+	}
+}
+
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("gophermart:job:" + name))
+	return int64(h.Sum64())
+}
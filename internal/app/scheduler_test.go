@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockerStub emulates advisory locks shared by several replicas.
+type lockerStub struct {
+	mu    sync.Mutex
+	held  map[int64]*lockStub
+	lost  bool
+	fails int
+}
+
+type lockStub struct {
+	locker *lockerStub
+	key    int64
+}
+
+func (l *lockerStub) TryAdvisoryLock(ctx context.Context, key int64) (basedbhandler.AdvisoryLock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.held[key]; ok {
+		return nil, nil
+	}
+	lock := &lockStub{locker: l, key: key}
+	l.held[key] = lock
+	return lock, nil
+}
+
+func (l *lockStub) Check(ctx context.Context) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	if l.locker.lost {
+		l.locker.fails++
+		return errors.New("connection lost")
+	}
+	return nil
+}
+
+func (l *lockStub) Release(ctx context.Context) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	delete(l.locker.held, l.key)
+	return nil
+}
+
+func TestScheduler_SingleLeader(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	locker := &lockerStub{held: make(map[int64]*lockStub)}
+	var (
+		mu   sync.Mutex
+		runs = make(map[string]int)
+	)
+	newJob := func(replica string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			runs[replica]++
+			mu.Unlock()
+			return nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, replica := range []string{"replica-1", "replica-2"} {
+		s := NewScheduler(locker, logger, time.Millisecond)
+		s.Add("job", time.Millisecond, newJob(replica))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Start(ctx)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, len(runs), "job must run on a single replica")
+	assert.Empty(t, locker.held, "lock must be released on shutdown")
+}
+
+func TestScheduler_Failover(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	locker := &lockerStub{held: make(map[int64]*lockStub), lost: true}
+	runs := 0
+	s := NewScheduler(locker, logger, time.Millisecond)
+	s.Add("job", time.Millisecond, func(ctx context.Context) error {
+		runs++
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	s.Start(ctx)
+	assert.Equal(t, 0, runs, "job must not run without a live lock")
+	assert.Greater(t, locker.fails, 1, "scheduler must retry to acquire a lost lock")
+}
@@ -3,127 +3,525 @@ package service
 import (
 	"context"
 	"errors"
+	"expvar"
 	"github.com/da-semenov/gophermart/internal/app/domain"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure"
 	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
 	"go.uber.org/zap"
+	"math/rand"
+	"sync"
 	"time"
 )
 
+var errLeaseLost = errors.New("order lease is held by another instance")
+
+var (
+	accrualMetrics     = expvar.NewMap("accrual")
+	accrualPaused      = new(expvar.Int)
+	accrualPausedUntil = new(expvar.String)
+)
+
+func init() {
+	accrualMetrics.Set("paused", accrualPaused)
+	accrualMetrics.Set("paused_until", accrualPausedUntil)
+}
+
 type AccrualClient interface {
 	GetAccrual(ctx context.Context, orderNum string) (*domain.Accrual, error)
 }
 
-type GophermartClient interface {
-	ProcessRequest(ctx context.Context, orderNum string) bool
+// DebtRepayer repays debts left by clawbacks once the user gets new points.
+type DebtRepayer interface {
+	RepayDebts(ctx context.Context, userID int) error
+}
+
+// CircuitBreakerStatusProvider is implemented by accrual clients guarded by a circuit breaker.
+type CircuitBreakerStatusProvider interface {
+	Status() domain.CircuitBreakerStatus
+}
+
+type AccrualServiceConfig struct {
+	Enable       bool
+	Workers      int
+	QueueSize    int
+	PollInterval time.Duration
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+	// MaxAttempts and MaxAge move an order to the STUCK state once exceeded, zero disables the limit.
+	MaxAttempts int
+	MaxAge      time.Duration
+	// InstanceID identifies this replica as the lease owner of the orders it processes.
+	InstanceID    string
+	LeaseDuration time.Duration
 }
 
 type AccrualService struct {
-	dbOrder          models.OrderRepository
-	dbBalance        models.BalanceRepository
-	accrualClient    AccrualClient
-	gophermartClient GophermartClient
-	log              *infrastructure.Logger
-	enable           bool
+	dbOrder       models.OrderRepository
+	dbBalance     models.BalanceRepository
+	ledger        *LedgerService
+	debts         DebtRepayer
+	outbox        OutboxWriter
+	accrualClient AccrualClient
+	tx            basedbhandler.Transactioner
+	log           *infrastructure.Logger
+	config        AccrualServiceConfig
+	queue         chan models.Order
+	inFlight      map[string]struct{}
+	mu            sync.Mutex
+	pausedUntil   time.Time
+	pauseMu       sync.Mutex
 }
 
 func NewAccrualService(
 	orderRepo models.OrderRepository,
 	balanceRepo models.BalanceRepository,
+	ledger *LedgerService,
+	debts DebtRepayer,
+	outbox OutboxWriter,
 	accrualClient AccrualClient,
-	gophermartClient GophermartClient,
+	tx basedbhandler.Transactioner,
 	log *infrastructure.Logger,
-	enable bool,
+	config AccrualServiceConfig,
 ) *AccrualService {
 	var target AccrualService
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = config.Workers
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.RetryBase <= 0 {
+		config.RetryBase = config.PollInterval
+	}
+	if config.RetryMax < config.RetryBase {
+		config.RetryMax = config.RetryBase
+	}
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = time.Minute
+	}
 	target.dbOrder = orderRepo
 	target.dbBalance = balanceRepo
+	target.ledger = ledger
+	target.debts = debts
+	target.outbox = outbox
 	target.log = log
 	target.accrualClient = accrualClient
-	target.gophermartClient = gophermartClient
-	target.enable = enable
+	target.tx = tx
+	target.config = config
+	target.queue = make(chan models.Order, config.QueueSize)
+	target.inFlight = make(map[string]struct{})
 	return &target
 }
 
-func (s *AccrualService) StartProcessJob(latency time.Duration) {
-	if !s.enable {
+// StartProcessJob polls not processed orders and feeds them to the worker pool until ctx is done.
+func (s *AccrualService) StartProcessJob(ctx context.Context) {
+	if !s.config.Enable {
 		return
 	}
-	t := time.NewTicker(latency * time.Second)
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.worker(ctx)
+		}()
+	}
+	t := time.NewTicker(s.config.PollInterval)
 	defer t.Stop()
 	for {
-		<-t.C
-		ctx := context.Background()
-		s.process(ctx)
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-t.C:
+			s.process(ctx)
+		}
 	}
 }
 
 func (s *AccrualService) process(ctx context.Context) {
-	orderList, err := s.dbOrder.FindNotProcessed(ctx)
+	if s.isPaused() {
+		return
+	}
+	limit := cap(s.queue) - len(s.queue)
+	if limit <= 0 {
+		s.log.Debug("AccrualService: process. Queue is full", zap.Int("queueSize", s.config.QueueSize))
+		return
+	}
+	orderList, err := s.dbOrder.FindNotProcessed(ctx, s.config.InstanceID, time.Now().Add(s.config.LeaseDuration), limit)
 	if err != nil {
 		s.log.Error("AccrualService: process. Can't get order list", zap.Error(err))
 		return
 	}
 	for _, order := range orderList {
-		s.gophermartClient.ProcessRequest(ctx, order.Num)
+		if !s.enqueue(order) {
+			s.log.Debug("AccrualService: process. Queue is full", zap.Int("queueSize", s.config.QueueSize))
+			return
+		}
+	}
+}
+
+// enqueue puts the order to the work queue unless it is already queued or being processed.
+// It returns false if the queue is full.
+func (s *AccrualService) enqueue(order models.Order) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.inFlight[order.Num]; ok {
+		return true
+	}
+	select {
+	case s.queue <- order:
+		s.inFlight[order.Num] = struct{}{}
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *AccrualService) done(orderNum string) {
+	s.mu.Lock()
+	delete(s.inFlight, orderNum)
+	s.mu.Unlock()
+}
+
+func (s *AccrualService) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case order := <-s.queue:
+			if !s.waitPause(ctx) {
+				s.done(order.Num)
+				return
+			}
+			if err := s.ProcessOrder(ctx, order); err != nil {
+				s.log.Error("AccrualService: worker. Can't process order", zap.String("OrderNum", order.Num), zap.Error(err))
+			}
+			s.done(order.Num)
+		}
 	}
 }
 
-func (s *AccrualService) ProcessOrder(ctx context.Context, orderNum string) error {
-	s.log.Debug("AccrualService: processOrder. Request")
-	accrual, err := s.accrualClient.GetAccrual(context.Background(), orderNum)
+// pause stops all outbound calls to the accrual system for d. An earlier deadline never shortens the current pause.
+func (s *AccrualService) pause(d time.Duration) {
+	until := time.Now().Add(d)
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if !until.After(s.pausedUntil) {
+		return
+	}
+	s.pausedUntil = until
+	accrualMetrics.Add("pauses", 1)
+	accrualPaused.Set(1)
+	accrualPausedUntil.Set(until.Format(time.RFC3339))
+	s.log.Warn("AccrualService: pause. Accrual system requests paused", zap.Time("until", until), zap.Duration("retryAfter", d))
+}
+
+func (s *AccrualService) pauseDeadline() time.Time {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	return s.pausedUntil
+}
+
+func (s *AccrualService) isPaused() bool {
+	return time.Now().Before(s.pauseDeadline())
+}
+
+// waitPause blocks until the pause is over. It returns false if ctx is done first.
+func (s *AccrualService) waitPause(ctx context.Context) bool {
+	for {
+		d := time.Until(s.pauseDeadline())
+		if d <= 0 {
+			s.resume()
+			return true
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return false
+		case <-t.C:
+		}
+	}
+}
+
+func (s *AccrualService) resume() {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if s.pausedUntil.IsZero() {
+		return
+	}
+	s.pausedUntil = time.Time{}
+	accrualPaused.Set(0)
+	s.log.Info("AccrualService: resume. Accrual system requests resumed")
+}
+
+// ProcessOrder requests the accrual for the order and applies it in a separate transaction.
+// A failed attempt is rescheduled with exponential backoff.
+func (s *AccrualService) ProcessOrder(ctx context.Context, order models.Order) error {
+	s.log.Debug("AccrualService: processOrder. Request", zap.String("OrderNum", order.Num))
+	accrualMetrics.Add("requests", 1)
+	accrual, err := s.accrualClient.GetAccrual(ctx, order.Num)
 	if err != nil {
+		accrualMetrics.Add("errors", 1)
+		var tooMany *domain.TooManyRequestError
+		if errors.As(err, &tooMany) {
+			s.pause(tooMany.RetryAfter)
+			// Rate limiting is not a failed attempt of the order, it is released until the accrual system accepts requests.
+			s.postpone(ctx, order, time.Now().Add(tooMany.RetryAfter))
+			return err
+		}
+		if errors.Is(err, domain.ErrCircuitOpen) {
+			// The order was not sent to the accrual system, it is picked up again when the lease expires.
+			s.log.Debug("AccrualService: processOrder. Circuit breaker is open", zap.String("OrderNum", order.Num))
+			return err
+		}
 		s.log.Error("AccrualService: processOrder. Can't get accruals from remote service", zap.Error(err))
+		s.scheduleRetry(ctx, order, err)
 		return err
 	}
-	order, err := s.dbOrder.LockOrder(ctx, orderNum)
+	err = inTransaction(ctx, s.tx, func(ctx context.Context) error {
+		return s.applyAccrual(ctx, order.Num, accrual)
+	})
+	if errors.Is(err, errLeaseLost) {
+		s.log.Warn("AccrualService: processOrder. Order lease lost", zap.String("OrderNum", order.Num))
+		return nil
+	}
 	if err != nil {
-		s.log.Error("AccrualService: processOrder. Can't lock order", zap.Error(err))
+		s.scheduleRetry(ctx, order, err)
 		return err
 	}
-	if accrual.Status == models.OrderStatusProcessed && order.Status != models.OrderStatusProcessed {
-		account, err := s.dbBalance.LockAccount(ctx, order.UserID)
-		if err != nil {
-			s.log.Error("AccrualService: processOrder. Can't lock account", zap.Error(err))
-			return err
-		}
+	s.log.Debug("AccrualService: processOrder. Success", zap.String("OrderNum", order.Num))
+	return nil
+}
+
+func (s *AccrualService) scheduleRetry(ctx context.Context, order models.Order, cause error) {
+	order.AttemptCount++
+	order.LastError = cause.Error()
+	if s.isExhausted(&order) {
+		s.markStuck(ctx, order)
+		return
+	}
+	order.NextAttemptAt = time.Now().Add(retryDelay(order.AttemptCount, s.config.RetryBase, s.config.RetryMax, rand.Float64))
+	if err := s.dbOrder.ScheduleAttempt(ctx, &order); err != nil {
+		s.log.Error("AccrualService: scheduleRetry. Can't schedule next attempt", zap.String("OrderNum", order.Num), zap.Error(err))
+		return
+	}
+	s.log.Debug("AccrualService: scheduleRetry. Next attempt scheduled",
+		zap.String("OrderNum", order.Num),
+		zap.Int("attempt", order.AttemptCount),
+		zap.Time("nextAttemptAt", order.NextAttemptAt),
+	)
+}
+
+// postpone releases the order lease and schedules the next attempt at the given time keeping the attempt count.
+func (s *AccrualService) postpone(ctx context.Context, order models.Order, nextAttemptAt time.Time) {
+	order.NextAttemptAt = nextAttemptAt
+	if err := s.dbOrder.ScheduleAttempt(ctx, &order); err != nil {
+		s.log.Error("AccrualService: postpone. Can't schedule next attempt", zap.String("OrderNum", order.Num), zap.Error(err))
+	}
+}
+
+func (s *AccrualService) isExhausted(order *models.Order) bool {
+	if s.config.MaxAttempts > 0 && order.AttemptCount >= s.config.MaxAttempts {
+		return true
+	}
+	return s.config.MaxAge > 0 && !order.UploadAt.IsZero() && time.Since(order.UploadAt) > s.config.MaxAge
+}
+
+func (s *AccrualService) markStuck(ctx context.Context, order models.Order) {
+	order.UpdatedAt = time.Now().Truncate(time.Second)
+	if err := s.dbOrder.MarkStuck(ctx, &order); err != nil {
+		s.log.Error("AccrualService: markStuck. Can't mark order as stuck", zap.String("OrderNum", order.Num), zap.Error(err))
+		return
+	}
+	accrualMetrics.Add("stuck", 1)
+	s.log.Warn("AccrualService: markStuck. Order moved to dead-letter state",
+		zap.String("OrderNum", order.Num),
+		zap.Int("attempt", order.AttemptCount),
+		zap.String("lastError", order.LastError),
+	)
+}
+
+// SweepStuckOrders moves orders older than MaxAge to the stuck state even if the accrual system keeps answering
+// with a non final status for them.
+func (s *AccrualService) SweepStuckOrders(ctx context.Context) error {
+	if s.config.MaxAge <= 0 {
+		return nil
+	}
+	count, err := s.dbOrder.MarkStuckOlderThan(ctx, time.Now().Add(-s.config.MaxAge), "order age limit exceeded")
+	if err != nil {
+		s.log.Error("AccrualService: SweepStuckOrders. Can't mark orders as stuck", zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		accrualMetrics.Add("stuck", int64(count))
+		s.log.Warn("AccrualService: SweepStuckOrders. Orders moved to dead-letter state", zap.Int("count", count))
+	}
+	return nil
+}
+
+func (s *AccrualService) mapStuckOrderModelToDomain(src *models.Order) domain.StuckOrder {
+	return domain.StuckOrder{
+		Num:          src.Num,
+		UserID:       src.UserID,
+		AttemptCount: src.AttemptCount,
+		LastError:    src.LastError,
+		UploadAt:     src.UploadAt,
+		UpdatedAt:    src.UpdatedAt,
+	}
+}
+
+func (s *AccrualService) GetStuckOrders(ctx context.Context) ([]domain.StuckOrder, error) {
+	orderList, err := s.dbOrder.FindStuck(ctx)
+	if err != nil {
+		s.log.Error("AccrualService: GetStuckOrders. Can't get order list", zap.Error(err))
+		return nil, err
+	}
+	resList := make([]domain.StuckOrder, 0, len(orderList))
+	for i := range orderList {
+		resList = append(resList, s.mapStuckOrderModelToDomain(&orderList[i]))
+	}
+	return resList, nil
+}
+
+// GetAccrualStatus reports whether requests to the accrual system are paused or cut off by the circuit breaker.
+func (s *AccrualService) GetAccrualStatus(ctx context.Context) (*domain.AccrualStatus, error) {
+	var status domain.AccrualStatus
+	if s.isPaused() {
+		pausedUntil := s.pauseDeadline()
+		status.Paused = true
+		status.PausedUntil = &pausedUntil
+	}
+	if breaker, ok := s.accrualClient.(CircuitBreakerStatusProvider); ok {
+		breakerStatus := breaker.Status()
+		status.CircuitBreaker = &breakerStatus
+	}
+	return &status, nil
+}
 
-		operation := models.Operation{
-			AccountID:     account.ID,
-			Amount:        accrual.Accrual,
-			OrderID:       order.ID,
-			OrderNum:      order.Num,
-			OperationType: models.OperationCredit,
-			ProcessedAt:   time.Now().Truncate(time.Second),
+// ReviveOrder returns a stuck order to polling with a reset attempt counter.
+func (s *AccrualService) ReviveOrder(ctx context.Context, orderNum string) error {
+	if orderNum == "" {
+		s.log.Debug("AccrualService: ReviveOrder. Got empty order num")
+		return domain.ErrBadParam
+	}
+	_, err := s.dbOrder.Revive(ctx, orderNum)
+	if err != nil {
+		if errors.Is(err, &models.NoRowFound) {
+			s.log.Debug("AccrualService: ReviveOrder. Stuck order not found", zap.String("OrderNum", orderNum))
+			return domain.ErrOrderNotFound
 		}
-		account.Balance += accrual.Accrual
-		account.Credit += accrual.Accrual
+		s.log.Error("AccrualService: ReviveOrder. Can't revive order", zap.String("OrderNum", orderNum), zap.Error(err))
+		return err
+	}
+	s.log.Info("AccrualService: ReviveOrder. Order revived", zap.String("OrderNum", orderNum))
+	return nil
+}
 
-		order.Status = accrual.Status
-		order.UpdatedAt = time.Now().Truncate(time.Second)
-		err = s.dbBalance.CreateOperation(ctx, &operation)
+// postAccrual credits the user account from the accruals system account.
+func (s *AccrualService) postAccrual(ctx context.Context, order *models.Order, amount money.Amount) error {
+	account, err := s.dbBalance.GetAccount(ctx, order.UserID)
+	if err != nil {
+		s.log.Error("AccrualService: processOrder. Can't get account", zap.Error(err))
+		return err
+	}
+	accruals, err := s.ledger.SystemAccountID(ctx, models.SystemAccountAccruals)
+	if err != nil {
+		return err
+	}
+	_, err = s.ledger.Transfer(ctx, LedgerTransfer{
+		EntryType: models.EntryAccrual,
+		EntryKey:  accrualEntryKey(order.Num),
+		OrderID:   order.ID,
+		OrderNum:  order.Num,
+		From:      accruals,
+		To:        account.ID,
+		Amount:    amount,
+		Expirable: true,
+	})
+	if errors.Is(err, domain.ErrEntryExists) {
+		s.log.Warn("AccrualService: processOrder. Accrual already posted", zap.String("OrderNum", order.Num))
+		return nil
+	}
+	if err != nil {
+		s.log.Error("AccrualService: processOrder. Can't post accrual", zap.Error(err))
+		return err
+	}
+	if s.outbox != nil {
+		err = s.outbox.Write(ctx, domain.AggregateOrder, order.Num, order.UserID, domain.OutboxAccrualPosted,
+			domain.AccrualEvent{Num: order.Num, Amount: amount})
 		if err != nil {
-			s.log.Error("AccrualService: processOrder. Can't create operation", zap.Error(err))
 			return err
 		}
-		err = s.dbBalance.SaveAccount(ctx, account)
-		if err != nil {
-			s.log.Error("AccrualService: processOrder. Can't save account", zap.Error(err))
+	}
+	if s.debts != nil {
+		if err = s.debts.RepayDebts(ctx, order.UserID); err != nil {
+			s.log.Error("AccrualService: processOrder. Can't repay debts", zap.Error(err))
 			return err
 		}
-		err = s.dbOrder.UpdateStatus(ctx, order)
+	}
+	return nil
+}
+
+func (s *AccrualService) applyAccrual(ctx context.Context, orderNum string, accrual *domain.Accrual) error {
+	order, err := s.dbOrder.LockOrder(ctx, orderNum)
+	if err != nil {
+		s.log.Error("AccrualService: processOrder. Can't lock order", zap.Error(err))
+		return err
+	}
+	if models.IsFinal(order.Status) {
+		s.log.Debug("AccrualService: processOrder. Order already processed", zap.String("OrderNum", order.Num))
+		return nil
+	}
+	if order.LeaseOwner != "" && order.LeaseOwner != s.config.InstanceID {
+		return errLeaseLost
+	}
+	prevStatus := order.Status
+	switch accrual.Status {
+	case models.OrderStatusProcessed:
+		order.Status = accrual.Status
+		order.UpdatedAt = time.Now().Truncate(time.Second)
+		if accrual.Accrual > 0 {
+			if err = s.postAccrual(ctx, order, accrual.Accrual); err != nil {
+				return err
+			}
+		}
+	case models.OrderStatusProcessing, models.OrderStatusRegistered:
+		order.Status = accrual.Status
+		order.UpdatedAt = time.Now().Truncate(time.Second)
+		order.NextAttemptAt = time.Now().Add(s.config.RetryBase)
+		err = s.dbOrder.ScheduleAttempt(ctx, order)
 		if err != nil {
-			s.log.Error("AccrualService: processOrder. Can't save order", zap.Error(err))
+			s.log.Error("AccrualService: processOrder. Can't schedule next attempt", zap.Error(err))
 			return err
 		}
-	} else if accrual.Status == models.OrderStatusProcessing || accrual.Status == models.OrderStatusRegistered || accrual.Status == models.OrderStatusInvalid {
+	case models.OrderStatusInvalid:
 		order.Status = accrual.Status
 		order.UpdatedAt = time.Now().Truncate(time.Second)
-		s.dbOrder.Save(ctx, order)
-	} else {
+	default:
 		s.log.Error("AccrualService: processOrder. Received unexpected status", zap.String("OrderNum", order.Num), zap.String("Status", accrual.Status))
 		return errors.New("received unexpected status")
 	}
-	s.log.Debug("AccrualService: processOrder. Success")
+	order.AccrualResponse = accrual.Response
+	err = s.dbOrder.UpdateStatus(ctx, order)
+	if err != nil {
+		s.log.Error("AccrualService: processOrder. Can't save order", zap.Error(err))
+		return err
+	}
+	if s.outbox != nil && order.Status != prevStatus {
+		event := domain.OrderStatusEvent{Num: order.Num, Status: order.Status}
+		if order.Status == models.OrderStatusProcessed {
+			event.Accrual = accrual.Accrual
+		}
+		if err = s.outbox.Write(ctx, domain.AggregateOrder, order.Num, order.UserID, domain.OutboxOrderStatusChanged, event); err != nil {
+			return err
+		}
+	}
 	return nil
 }
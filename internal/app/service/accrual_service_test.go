@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAccrualService_enqueue(t *testing.T) {
+	target := NewAccrualService(nil, nil, nil, nil, nil, nil, nil, log, AccrualServiceConfig{Enable: true, Workers: 1, QueueSize: 2})
+
+	assert.True(t, target.enqueue(models.Order{Num: "1"}), "first order must be queued")
+	assert.True(t, target.enqueue(models.Order{Num: "1"}), "order in flight must not be reported as queue overflow")
+	assert.Equal(t, 1, len(target.queue), "order in flight must not be queued twice")
+	assert.True(t, target.enqueue(models.Order{Num: "2"}), "second order must be queued")
+	assert.False(t, target.enqueue(models.Order{Num: "3"}), "queue overflow expected")
+
+	<-target.queue
+	target.done("1")
+	assert.True(t, target.enqueue(models.Order{Num: "1"}), "processed order must be queued again")
+}
+
+func TestAccrualService_pause(t *testing.T) {
+	target := NewAccrualService(nil, nil, nil, nil, nil, nil, nil, log, AccrualServiceConfig{Enable: true})
+	assert.False(t, target.isPaused(), "new service must not be paused")
+
+	target.pause(time.Hour)
+	assert.True(t, target.isPaused(), "service must be paused")
+	deadline := target.pauseDeadline()
+	target.pause(time.Minute)
+	assert.Equal(t, deadline, target.pauseDeadline(), "shorter pause must not move the deadline")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, target.waitPause(ctx), "waitPause must return on context cancellation")
+
+	target.pausedUntil = time.Now().Add(5 * time.Millisecond)
+	assert.True(t, target.waitPause(context.Background()), "waitPause must return after the deadline")
+	assert.False(t, target.isPaused(), "service must be resumed")
+}
+
+type accrualClientStub struct {
+	accrual *domain.Accrual
+	err     error
+}
+
+func (c *accrualClientStub) GetAccrual(ctx context.Context, orderNum string) (*domain.Accrual, error) {
+	return c.accrual, c.err
+}
+
+func TestAccrualService_ProcessOrder_Retry(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	client := &accrualClientStub{err: domain.ErrRemoteServiceError}
+	target := NewAccrualService(orderRepository, nil, nil, nil, nil, client, nil, log,
+		AccrualServiceConfig{Enable: true, RetryBase: time.Second, RetryMax: time.Minute})
+
+	start := time.Now()
+	orderRepository.EXPECT().ScheduleAttempt(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *models.Order) error {
+			assert.Equal(t, 3, order.AttemptCount, "attempt count must be incremented")
+			assert.Equal(t, domain.ErrRemoteServiceError.Error(), order.LastError, "last error must be recorded")
+			assert.False(t, order.NextAttemptAt.Before(start.Add(2*time.Second)), "next attempt must be delayed")
+			return nil
+		},
+	)
+	err := target.ProcessOrder(ctx, models.Order{ID: 1, Num: "1", AttemptCount: 2})
+	assert.ErrorIs(t, err, domain.ErrRemoteServiceError)
+
+	client.err = domain.ErrCircuitOpen
+	err = target.ProcessOrder(ctx, models.Order{ID: 1, Num: "1"})
+	assert.ErrorIs(t, err, domain.ErrCircuitOpen, "open circuit must not count as a failed attempt")
+
+	client.err = &domain.TooManyRequestError{RetryAfter: time.Minute}
+	orderRepository.EXPECT().ScheduleAttempt(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *models.Order) error {
+			assert.Equal(t, 4, order.AttemptCount, "rate limiting must not count as a failed attempt")
+			assert.False(t, order.NextAttemptAt.Before(start.Add(time.Minute)), "next attempt must wait for Retry-After")
+			return nil
+		},
+	)
+	err = target.ProcessOrder(ctx, models.Order{ID: 1, Num: "1", AttemptCount: 4})
+	assert.ErrorIs(t, err, domain.ErrTooManyRequest)
+	assert.True(t, target.isPaused(), "service must be paused on too many requests")
+}
+
+func TestAccrualService_ProcessOrder_Stuck(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	client := &accrualClientStub{err: domain.ErrOrderNotRegistered}
+	target := NewAccrualService(orderRepository, nil, nil, nil, nil, client, nil, log,
+		AccrualServiceConfig{Enable: true, MaxAttempts: 3, MaxAge: time.Hour})
+
+	orderRepository.EXPECT().MarkStuck(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *models.Order) error {
+			assert.Equal(t, "2", order.Num, "order with exhausted attempts must be marked as stuck")
+			assert.Equal(t, domain.ErrOrderNotRegistered.Error(), order.LastError, "last error must be recorded")
+			return nil
+		},
+	)
+	orderRepository.EXPECT().MarkStuck(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *models.Order) error {
+			assert.Equal(t, "3", order.Num, "too old order must be marked as stuck")
+			return nil
+		},
+	)
+	orderRepository.EXPECT().ScheduleAttempt(ctx, gomock.Any()).Return(nil)
+
+	_ = target.ProcessOrder(ctx, models.Order{ID: 2, Num: "2", AttemptCount: 2, UploadAt: time.Now()})
+	_ = target.ProcessOrder(ctx, models.Order{ID: 3, Num: "3", UploadAt: time.Now().Add(-2 * time.Hour)})
+	_ = target.ProcessOrder(ctx, models.Order{ID: 4, Num: "4", UploadAt: time.Now()})
+}
+
+func TestAccrualService_process_Lease(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	target := NewAccrualService(orderRepository, nil, nil, nil, nil, nil, nil, log,
+		AccrualServiceConfig{Enable: true, QueueSize: 3, InstanceID: "instance-1", LeaseDuration: time.Minute})
+
+	start := time.Now()
+	orderRepository.EXPECT().FindNotProcessed(ctx, "instance-1", gomock.Any(), 3).DoAndReturn(
+		func(ctx context.Context, owner string, leaseUntil time.Time, limit int) ([]models.Order, error) {
+			assert.False(t, leaseUntil.Before(start.Add(time.Minute)), "lease must last for the configured duration")
+			return []models.Order{{Num: "1", LeaseOwner: owner}, {Num: "2", LeaseOwner: owner}}, nil
+		},
+	)
+	orderRepository.EXPECT().FindNotProcessed(ctx, "instance-1", gomock.Any(), 1).Return(nil, nil)
+	target.process(ctx)
+	assert.Equal(t, 2, len(target.queue), "leased orders must be queued")
+	target.process(ctx)
+}
+
+func TestAccrualService_ProcessOrder_Processed(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	client := &accrualClientStub{accrual: &domain.Accrual{Order: "1", Status: models.OrderStatusProcessed, Accrual: money.Rubles(729, 98)}}
+	tx := &transactionerStub{}
+	outbox := &outboxWriterStub{}
+	target := NewAccrualService(orderRepository, balanceRepository, NewLedgerService(ledgerRepository, log), nil, outbox, client, tx, log,
+		AccrualServiceConfig{Enable: true})
+
+	orderRepository.EXPECT().LockOrder(gomock.Any(), "1").Return(&models.Order{ID: 5, UserID: 7, Num: "1", Status: models.OrderStatusNew}, nil)
+	balanceRepository.EXPECT().GetAccount(gomock.Any(), 7).Return(&models.Account{ID: 3, UserID: 7}, nil)
+	ledgerRepository.EXPECT().GetSystemAccount(gomock.Any(), models.SystemAccountAccruals).Return(&models.Account{ID: 1}, nil)
+	ledgerRepository.EXPECT().Post(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, entry *models.JournalEntry) error {
+			assert.Equal(t, "ACCRUAL:1", entry.EntryKey)
+			assert.Equal(t, models.OperationDebit, entry.Operations[0].OperationType)
+			assert.Equal(t, 1, entry.Operations[0].AccountID, "accruals account must be debited")
+			assert.Equal(t, 3, entry.Operations[1].AccountID, "user account must be credited")
+			assert.Equal(t, money.Rubles(729, 98), entry.Operations[1].Amount)
+			assert.Equal(t, 5, entry.Operations[1].OrderID)
+			return nil
+		},
+	)
+	orderRepository.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *models.Order) error {
+			assert.Equal(t, models.OrderStatusProcessed, order.Status)
+			return nil
+		},
+	)
+	assert.NoError(t, target.ProcessOrder(ctx, models.Order{ID: 5, UserID: 7, Num: "1"}))
+	assert.Equal(t, 1, tx.commits)
+	assert.Equal(t, []string{domain.OutboxAccrualPosted, domain.OutboxOrderStatusChanged}, outbox.types)
+	assert.Equal(t, domain.OrderStatusEvent{Num: "1", Status: models.OrderStatusProcessed, Accrual: money.Rubles(729, 98)}, outbox.data[1])
+	assert.Equal(t, []string{"1", "1"}, outbox.aggregateIDs, "events of the order must share the aggregate")
+}
+
+type outboxWriterStub struct {
+	aggregateIDs []string
+	types        []string
+	data         []interface{}
+}
+
+func (s *outboxWriterStub) Write(ctx context.Context, aggregateType string, aggregateID string, userID int, eventType string, data interface{}) error {
+	s.aggregateIDs = append(s.aggregateIDs, aggregateID)
+	s.types = append(s.types, eventType)
+	s.data = append(s.data, data)
+	return nil
+}
+
+func TestAccrualService_ProcessOrder_Processing(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	client := &accrualClientStub{accrual: &domain.Accrual{Order: "1", Status: models.OrderStatusProcessing}}
+	target := NewAccrualService(orderRepository, nil, nil, nil, nil, client, &transactionerStub{}, log,
+		AccrualServiceConfig{Enable: true})
+
+	orderRepository.EXPECT().LockOrder(gomock.Any(), "1").Return(
+		&models.Order{ID: 5, UserID: 7, Num: "1", Status: models.OrderStatusRegistered, AttemptCount: 3, LastError: "timeout"}, nil)
+	orderRepository.EXPECT().ScheduleAttempt(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *models.Order) error {
+			assert.Equal(t, 3, order.AttemptCount, "attempt count must be kept")
+			assert.Equal(t, "timeout", order.LastError, "last error must be kept")
+			return nil
+		},
+	)
+	orderRepository.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).Return(nil)
+	assert.NoError(t, target.ProcessOrder(ctx, models.Order{ID: 5, UserID: 7, Num: "1"}))
+}
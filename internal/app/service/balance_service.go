@@ -2,21 +2,36 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/da-semenov/gophermart/internal/app/domain"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure"
 	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/statement"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
 type BalanceService struct {
-	dbBalance models.BalanceRepository
-	log       *infrastructure.Logger
+	dbBalance    models.BalanceRepository
+	dbWithdrawal models.WithdrawalRepository
+	ledger       *LedgerService
+	expiry       *ExpiryService
+	outbox       OutboxWriter
+	log          *infrastructure.Logger
 }
 
-func NewBalanceService(balanceRepo models.BalanceRepository, log *infrastructure.Logger) *BalanceService {
+// NewBalanceService creates the service, expiry may be nil if points never expire
+// and outbox may be nil if withdrawal events are not published.
+func NewBalanceService(balanceRepo models.BalanceRepository, withdrawalRepo models.WithdrawalRepository, ledger *LedgerService,
+	expiry *ExpiryService, outbox OutboxWriter, log *infrastructure.Logger) *BalanceService {
 	var target BalanceService
 	target.dbBalance = balanceRepo
+	target.dbWithdrawal = withdrawalRepo
+	target.ledger = ledger
+	target.expiry = expiry
+	target.outbox = outbox
 	target.log = log
 	return &target
 }
@@ -48,10 +63,30 @@ func (s *BalanceService) GetCurrentBalance(ctx context.Context, userID int) (*do
 		s.log.Debug("BalanceService: GetCurrentBalance. Can't get current balance")
 		return nil, err
 	}
+	withdrawn, err := s.dbWithdrawal.GetWithdrawnTotal(ctx, userID)
+	if err != nil {
+		s.log.Error("BalanceService: GetCurrentBalance. Can't get withdrawn total", zap.Error(err))
+		return nil, err
+	}
+	debt, err := s.dbBalance.GetDebtTotal(ctx, userID)
+	if err != nil {
+		s.log.Error("BalanceService: GetCurrentBalance. Can't get debt total", zap.Error(err))
+		return nil, err
+	}
+
+	var expirations []domain.Expiration
+	if s.expiry != nil {
+		expirations, err = s.expiry.GetUpcomingExpirations(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &domain.Balance{
-		Current:   account.Balance,
-		Withdrawn: account.Debit,
+		Current:     account.Balance,
+		Withdrawn:   withdrawn,
+		Debt:        debt,
+		Expirations: expirations,
 	}, nil
 
 }
@@ -65,6 +100,10 @@ func (s *BalanceService) Withdraw(ctx context.Context, obj *domain.Withdraw, use
 		s.log.Debug("BalanceService: Withdraw. Got nil order")
 		return domain.ErrBadParam
 	}
+	if obj.Amount <= 0 {
+		s.log.Debug("BalanceService: Withdraw. Got non-positive amount", zap.Stringer("amount", obj.Amount))
+		return domain.ErrBadParam
+	}
 	if !CheckOrderNum(obj.OrderNum) {
 		s.log.Debug("BalanceService: Withdraw. Order num validation error", zap.String("orderNum", obj.OrderNum))
 		return domain.ErrBadOrderNum
@@ -80,26 +119,51 @@ func (s *BalanceService) Withdraw(ctx context.Context, obj *domain.Withdraw, use
 		return domain.ErrNotEnoughFunds
 	}
 
-	operation := models.Operation{
-		AccountID:     account.ID,
-		Amount:        obj.Amount,
-		OrderNum:      obj.OrderNum,
-		OperationType: models.OperationDebit,
-		ProcessedAt:   time.Now().Truncate(time.Second),
+	holds, err := s.ledger.SystemAccountID(ctx, models.SystemAccountHolds)
+	if err != nil {
+		return err
+	}
+	entry, err := s.ledger.Transfer(ctx, LedgerTransfer{
+		EntryType: models.EntryWithdrawal,
+		EntryKey:  withdrawalEntryKey(userID, obj.OrderNum),
+		OrderNum:  obj.OrderNum,
+		From:      account.ID,
+		To:        holds,
+		Amount:    obj.Amount,
+	})
+	if errors.Is(err, domain.ErrEntryExists) {
+		s.log.Debug("BalanceService: Withdraw. Withdrawal for the order already exists", zap.String("orderNum", obj.OrderNum))
+		return domain.ErrWithdrawalExists
 	}
-	err = s.dbBalance.CreateOperation(ctx, &operation)
-
 	if err != nil {
-		s.log.Error("BalanceService: Withdraw. Can't save operation", zap.Error(err))
+		s.log.Error("BalanceService: Withdraw. Can't post withdrawal", zap.Error(err))
 		return err
 	}
-	account.Balance -= obj.Amount
-	account.Debit += obj.Amount
-	err = s.dbBalance.SaveAccount(ctx, account)
+	withdrawal := models.Withdrawal{
+		UserID:      userID,
+		AccountID:   account.ID,
+		OrderNum:    obj.OrderNum,
+		Amount:      obj.Amount,
+		Status:      models.WithdrawalStatusPending,
+		EntryID:     entry.ID,
+		ProcessedAt: entry.CreatedAt,
+	}
+	err = s.dbWithdrawal.Create(ctx, &withdrawal)
+	if errors.Is(err, &models.UniqueViolation) {
+		s.log.Debug("BalanceService: Withdraw. Withdrawal for the order already exists", zap.String("orderNum", obj.OrderNum))
+		return domain.ErrWithdrawalExists
+	}
 	if err != nil {
-		s.log.Error("BalanceService: Withdraw. Can't save account", zap.Error(err))
+		s.log.Error("BalanceService: Withdraw. Can't create withdrawal", zap.Error(err))
 		return err
 	}
+	if s.outbox != nil {
+		err = s.outbox.Write(ctx, domain.AggregateWithdrawal, withdrawal.OrderNum, userID, domain.OutboxWithdrawalCreated,
+			s.mapWithdrawalModelToDomain(withdrawal))
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -120,3 +184,156 @@ func (s *BalanceService) GetWithdrawalsList(ctx context.Context, userID int) ([]
 	resList := s.mapWithdrawalListModelToDomain(withdrawalList)
 	return resList, nil
 }
+
+const (
+	historyDefaultLimit = 50
+	historyMaxLimit     = 100
+)
+
+var historyEntryTypes = map[string]bool{
+	models.EntryAccrual:    true,
+	models.EntryWithdrawal: true,
+	models.EntrySettlement: true,
+	models.EntryClawback:   true,
+	models.EntryTransfer:   true,
+	models.EntryExpire:     true,
+	models.EntryReversal:   true,
+	models.EntryAdjustment: true,
+}
+
+// GetHistory returns a page of balance changes of the user newest first.
+func (s *BalanceService) GetHistory(ctx context.Context, userID int, filter domain.HistoryFilter) (*domain.BalanceHistory, error) {
+	if userID == 0 {
+		s.log.Debug("BalanceService: GetHistory. Got nil userID")
+		return nil, domain.ErrBadParam
+	}
+	f, err := s.mapHistoryFilterToModel(userID, filter)
+	if err != nil {
+		s.log.Debug("BalanceService: GetHistory. Bad filter", zap.Error(err))
+		return nil, err
+	}
+	// one extra row tells whether there is a next page
+	f.Limit++
+	opList, err := s.dbBalance.FindOperations(ctx, f)
+	if err != nil {
+		s.log.Error("BalanceService: GetHistory. Can't get operations", zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	res := domain.BalanceHistory{Operations: []domain.HistoryOperation{}}
+	if len(opList) == f.Limit {
+		opList = opList[:len(opList)-1]
+		last := opList[len(opList)-1]
+		res.NextCursor = encodeCursor(last.ProcessedAt, last.ID)
+	}
+	for _, o := range opList {
+		res.Operations = append(res.Operations, s.mapHistoryOperationModelToDomain(o))
+	}
+	return &res, nil
+}
+
+// WriteStatement writes operations of the period between from and to, the zero to means now. Operations are passed
+// to out as they are read. The opening balance is taken from the first operation, so it agrees with operation
+// balances, and is queried separately only for a period without operations.
+func (s *BalanceService) WriteStatement(ctx context.Context, userID int, from time.Time, to time.Time, out statement.Writer) error {
+	if userID == 0 {
+		s.log.Debug("BalanceService: WriteStatement. Got nil userID")
+		return domain.ErrBadParam
+	}
+	now := time.Now()
+	if to.IsZero() {
+		to = now
+	}
+	if !from.IsZero() && !from.Before(to) {
+		s.log.Debug("BalanceService: WriteStatement. Empty period", zap.Time("from", from), zap.Time("to", to))
+		return domain.ErrBadParam
+	}
+	header := domain.StatementHeader{From: from, To: to, GeneratedAt: now}
+	var totals domain.StatementTotals
+	begun := false
+	err := s.dbBalance.ScanOperations(ctx, models.OperationFilter{UserID: userID, From: from, To: to}, func(o *models.HistoryOperation) error {
+		op := s.mapHistoryOperationModelToDomain(*o)
+		if !begun {
+			header.OpeningBalance = o.Balance
+			if o.OperationType == models.OperationCredit {
+				header.OpeningBalance -= o.Amount
+			} else {
+				header.OpeningBalance += o.Amount
+			}
+			if err := out.Begin(header); err != nil {
+				return err
+			}
+			begun = true
+		}
+		totals.Operations++
+		if o.OperationType == models.OperationCredit {
+			totals.Credit += o.Amount
+		} else {
+			totals.Debit += o.Amount
+		}
+		totals.ClosingBalance = o.Balance
+		return out.Operation(op)
+	})
+	if err != nil {
+		s.log.Error("BalanceService: WriteStatement. Can't write operations", zap.Int("userID", userID), zap.Error(err))
+		return err
+	}
+	if !begun {
+		if !from.IsZero() {
+			header.OpeningBalance, err = s.dbBalance.GetBalanceAt(ctx, userID, from)
+			if err != nil {
+				s.log.Error("BalanceService: WriteStatement. Can't get opening balance", zap.Int("userID", userID), zap.Error(err))
+				return err
+			}
+		}
+		if err = out.Begin(header); err != nil {
+			return err
+		}
+		totals.ClosingBalance = header.OpeningBalance
+	}
+	return out.End(totals)
+}
+
+func (s *BalanceService) mapHistoryOperationModelToDomain(src models.HistoryOperation) domain.HistoryOperation {
+	return domain.HistoryOperation{
+		ID:            src.ID,
+		Type:          src.EntryType,
+		OperationType: src.OperationType,
+		OrderNum:      src.OrderNum,
+		Description:   src.Description,
+		Amount:        src.Amount,
+		Balance:       src.Balance,
+		ProcessedAt:   src.ProcessedAt,
+	}
+}
+
+func (s *BalanceService) mapHistoryFilterToModel(userID int, filter domain.HistoryFilter) (models.OperationFilter, error) {
+	f := models.OperationFilter{UserID: userID, From: filter.From, To: filter.To, Limit: filter.Limit}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return f, fmt.Errorf("%w: empty date range", domain.ErrBadParam)
+	}
+	if f.Limit == 0 {
+		f.Limit = historyDefaultLimit
+	}
+	if f.Limit < 0 || f.Limit > historyMaxLimit {
+		return f, fmt.Errorf("%w: limit %d", domain.ErrBadParam, f.Limit)
+	}
+	for _, t := range filter.Types {
+		t = strings.ToUpper(t)
+		if !historyEntryTypes[t] {
+			return f, fmt.Errorf("%w: unknown operation type %q", domain.ErrBadParam, t)
+		}
+		f.EntryTypes = append(f.EntryTypes, t)
+	}
+	f.OperationType = strings.ToUpper(filter.OperationType)
+	if f.OperationType != "" && f.OperationType != models.OperationCredit && f.OperationType != models.OperationDebit {
+		return f, fmt.Errorf("%w: unknown operation side %q", domain.ErrBadParam, filter.OperationType)
+	}
+	if filter.Cursor != "" {
+		var err error
+		f.AfterProcessedAt, f.AfterID, err = decodeCursor(filter.Cursor)
+		if err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBalanceService_Withdraw(t *testing.T) {
+	type args struct {
+		withdraw  *domain.Withdraw
+		balance   money.Amount
+		createErr error
+	}
+	type wants struct {
+		error error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "BalanceService. Withdraw. Test 1. Whole balance",
+			args: args{
+				withdraw: &domain.Withdraw{OrderNum: "12345678903", Amount: money.Rubles(729, 98)},
+				balance:  money.Rubles(729, 98),
+			},
+			wants: wants{
+				error: nil,
+			},
+		},
+		{
+			name: "BalanceService. Withdraw. Test 2. Not enough funds",
+			args: args{
+				withdraw: &domain.Withdraw{OrderNum: "12345678903", Amount: money.Rubles(729, 99)},
+				balance:  money.Rubles(729, 98),
+			},
+			wants: wants{
+				error: domain.ErrNotEnoughFunds,
+			},
+		},
+		{
+			name: "BalanceService. Withdraw. Test 3. Non-positive amount",
+			args: args{
+				withdraw: &domain.Withdraw{OrderNum: "12345678903", Amount: 0},
+				balance:  money.Rubles(729, 98),
+			},
+			wants: wants{
+				error: domain.ErrBadParam,
+			},
+		},
+		{
+			name: "BalanceService. Withdraw. Test 4. Repeated withdrawal for the order",
+			args: args{
+				withdraw:  &domain.Withdraw{OrderNum: "12345678903", Amount: money.Rubles(1, 0)},
+				balance:   money.Rubles(729, 98),
+				createErr: &models.UniqueViolation,
+			},
+			wants: wants{
+				error: domain.ErrWithdrawalExists,
+			},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	withdrawalRepository := mocks.NewMockWithdrawalRepository(mockCtrl)
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	ledgerRepository.EXPECT().GetSystemAccount(ctx, models.SystemAccountHolds).Return(&models.Account{ID: 100}, nil)
+	target := NewBalanceService(balanceRepository, withdrawalRepository, NewLedgerService(ledgerRepository, log), nil, nil, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := &models.Account{ID: 1, UserID: 1, Balance: tt.args.balance}
+			if tt.args.withdraw.Amount > 0 {
+				balanceRepository.EXPECT().LockAccount(ctx, 1).Return(account, nil)
+			}
+			if tt.wants.error == nil || tt.args.createErr != nil {
+				ledgerRepository.EXPECT().Post(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, entry *models.JournalEntry) error {
+						assert.Equal(t, models.EntryWithdrawal, entry.EntryType)
+						assert.Equal(t, "WITHDRAWAL:1:"+tt.args.withdraw.OrderNum, entry.EntryKey)
+						assert.Equal(t, []models.Operation{
+							{AccountID: 1, OrderNum: tt.args.withdraw.OrderNum, OperationType: models.OperationDebit, Amount: tt.args.withdraw.Amount, ProcessedAt: entry.CreatedAt},
+							{AccountID: 100, OrderNum: tt.args.withdraw.OrderNum, OperationType: models.OperationCredit, Amount: tt.args.withdraw.Amount, ProcessedAt: entry.CreatedAt},
+						}, entry.Operations, "the amount must be held until the withdrawal is confirmed")
+						entry.ID = 10
+						return tt.args.createErr
+					},
+				)
+			}
+			if tt.wants.error == nil {
+				withdrawalRepository.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, w *models.Withdrawal) error {
+						assert.Equal(t, models.WithdrawalStatusPending, w.Status)
+						assert.Equal(t, 10, w.EntryID)
+						assert.Equal(t, tt.args.withdraw.Amount, w.Amount)
+						return nil
+					},
+				)
+			}
+			err := target.Withdraw(ctx, tt.args.withdraw, 1)
+			assert.ErrorIs(t, err, tt.wants.error)
+		})
+	}
+}
+
+func TestBalanceService_GetCurrentBalance(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	withdrawalRepository := mocks.NewMockWithdrawalRepository(mockCtrl)
+	target := NewBalanceService(balanceRepository, withdrawalRepository, nil, nil, nil, log)
+
+	balanceRepository.EXPECT().GetAccount(ctx, 1).Return(&models.Account{ID: 1, UserID: 1, Balance: money.Rubles(500, 0), Debit: money.Rubles(300, 0)}, nil)
+	withdrawalRepository.EXPECT().GetWithdrawnTotal(ctx, 1).Return(money.Rubles(200, 0), nil)
+	balanceRepository.EXPECT().GetDebtTotal(ctx, 1).Return(money.Rubles(15, 0), nil)
+	balance, err := target.GetCurrentBalance(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Rubles(500, 0), balance.Current)
+	assert.Equal(t, money.Rubles(200, 0), balance.Withdrawn, "reversed withdrawals must not count as withdrawn")
+	assert.Equal(t, money.Rubles(15, 0), balance.Debt)
+}
+
+func TestBalanceService_GetHistory(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	target := NewBalanceService(balanceRepository, nil, nil, nil, nil, log)
+	processedAt := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	balanceRepository.EXPECT().FindOperations(ctx, models.OperationFilter{
+		UserID: 1, EntryTypes: []string{models.EntryAccrual, models.EntryReversal}, OperationType: models.OperationCredit, Limit: 3,
+	}).Return([]models.HistoryOperation{
+		{ID: 5, EntryType: models.EntryReversal, OperationType: models.OperationCredit, Amount: money.Rubles(10, 0), Balance: money.Rubles(60, 0), ProcessedAt: processedAt},
+		{ID: 4, EntryType: models.EntryAccrual, OperationType: models.OperationCredit, Amount: money.Rubles(20, 0), Balance: money.Rubles(50, 0), ProcessedAt: processedAt},
+		{ID: 2, EntryType: models.EntryAccrual, OperationType: models.OperationCredit, Amount: money.Rubles(30, 0), Balance: money.Rubles(30, 0), ProcessedAt: processedAt.Add(-time.Hour)},
+	}, nil)
+	page, err := target.GetHistory(ctx, 1, domain.HistoryFilter{Types: []string{"accrual", "REVERSAL"}, OperationType: "credit", Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Operations, 2)
+	assert.Equal(t, money.Rubles(50, 0), page.Operations[1].Balance)
+	assert.NotEmpty(t, page.NextCursor)
+
+	balanceRepository.EXPECT().FindOperations(ctx, models.OperationFilter{
+		UserID: 1, AfterProcessedAt: processedAt, AfterID: 4, Limit: historyDefaultLimit + 1,
+	}).Return([]models.HistoryOperation{
+		{ID: 2, EntryType: models.EntryAccrual, OperationType: models.OperationCredit, Amount: money.Rubles(30, 0), Balance: money.Rubles(30, 0), ProcessedAt: processedAt.Add(-time.Hour)},
+	}, nil)
+	page, err = target.GetHistory(ctx, 1, domain.HistoryFilter{Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, page.Operations, 1)
+	assert.Empty(t, page.NextCursor, "the last page has no cursor")
+
+	for _, filter := range []domain.HistoryFilter{
+		{Types: []string{"UNKNOWN"}},
+		{OperationType: "HOLD"},
+		{Limit: historyMaxLimit + 1},
+		{From: processedAt, To: processedAt},
+		{Cursor: "not a cursor"},
+	} {
+		_, err = target.GetHistory(ctx, 1, filter)
+		assert.ErrorIs(t, err, domain.ErrBadParam)
+	}
+}
+
+type statementRecorder struct {
+	header     domain.StatementHeader
+	operations []domain.HistoryOperation
+	totals     domain.StatementTotals
+}
+
+func (r *statementRecorder) Begin(h domain.StatementHeader) error {
+	r.header = h
+	return nil
+}
+
+func (r *statementRecorder) Operation(op domain.HistoryOperation) error {
+	r.operations = append(r.operations, op)
+	return nil
+}
+
+func (r *statementRecorder) End(t domain.StatementTotals) error {
+	r.totals = t
+	return nil
+}
+
+func TestBalanceService_WriteStatement(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	target := NewBalanceService(balanceRepository, nil, nil, nil, nil, log)
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+	filter := models.OperationFilter{UserID: 1, From: from, To: to}
+
+	balanceRepository.EXPECT().ScanOperations(ctx, filter, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, filter models.OperationFilter, fn func(o *models.HistoryOperation) error) error {
+			for _, o := range []models.HistoryOperation{
+				{ID: 1, EntryType: models.EntryAccrual, OperationType: models.OperationCredit, Amount: money.Rubles(50, 0), Balance: money.Rubles(150, 0)},
+				{ID: 2, EntryType: models.EntryWithdrawal, OperationType: models.OperationDebit, Amount: money.Rubles(70, 0), Balance: money.Rubles(80, 0)},
+			} {
+				if err := fn(&o); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	var out statementRecorder
+	err := target.WriteStatement(ctx, 1, from, to, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Rubles(100, 0), out.header.OpeningBalance, "the opening balance is the balance before the first operation")
+	assert.Len(t, out.operations, 2)
+	assert.Equal(t, domain.StatementTotals{Operations: 2, Credit: money.Rubles(50, 0), Debit: money.Rubles(70, 0), ClosingBalance: money.Rubles(80, 0)}, out.totals)
+
+	balanceRepository.EXPECT().ScanOperations(ctx, filter, gomock.Any()).Return(nil)
+	balanceRepository.EXPECT().GetBalanceAt(ctx, 1, from).Return(money.Rubles(30, 0), nil)
+	out = statementRecorder{}
+	err = target.WriteStatement(ctx, 1, from, to, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Rubles(30, 0), out.header.OpeningBalance)
+	assert.Equal(t, domain.StatementTotals{ClosingBalance: money.Rubles(30, 0)}, out.totals)
+
+	err = target.WriteStatement(ctx, 1, to, from, &out)
+	assert.ErrorIs(t, err, domain.ErrBadParam)
+}
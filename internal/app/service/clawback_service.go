@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"go.uber.org/zap"
+	"time"
+)
+
+type ClawbackService struct {
+	dbClawback models.ClawbackRepository
+	dbOrder    models.OrderRepository
+	dbBalance  models.BalanceRepository
+	ledger     *LedgerService
+	log        *infrastructure.Logger
+	policy     string
+}
+
+func NewClawbackService(clawbackRepo models.ClawbackRepository, orderRepo models.OrderRepository, balanceRepo models.BalanceRepository,
+	ledger *LedgerService, log *infrastructure.Logger, policy string) *ClawbackService {
+	var target ClawbackService
+	target.dbClawback = clawbackRepo
+	target.dbOrder = orderRepo
+	target.dbBalance = balanceRepo
+	target.ledger = ledger
+	target.log = log
+	target.policy = policy
+	return &target
+}
+
+// Clawback takes back points accrued for the order, must run in a transaction.
+func (s *ClawbackService) Clawback(ctx context.Context, orderNum string, obj *domain.Clawback) (*domain.ClawbackResult, error) {
+	if obj == nil || (obj.Amount != nil && *obj.Amount <= 0) {
+		s.log.Debug("ClawbackService: Clawback. Bad amount", zap.String("orderNum", orderNum))
+		return nil, domain.ErrBadParam
+	}
+	order, err := s.dbOrder.LockOrder(ctx, orderNum)
+	if errors.Is(err, &models.NoRowFound) {
+		return nil, domain.ErrOrderNotFound
+	}
+	if err != nil {
+		s.log.Error("ClawbackService: Clawback. Can't lock order", zap.String("orderNum", orderNum), zap.Error(err))
+		return nil, err
+	}
+	accrued, clawedBack, err := s.dbClawback.GetOrderTotals(ctx, order.ID)
+	if err != nil {
+		s.log.Error("ClawbackService: Clawback. Can't get order totals", zap.String("orderNum", orderNum), zap.Error(err))
+		return nil, err
+	}
+	available := accrued - clawedBack
+	if available <= 0 {
+		s.log.Debug("ClawbackService: Clawback. Nothing to claw back", zap.String("orderNum", orderNum))
+		return nil, domain.ErrNothingToClawBack
+	}
+	amount := available
+	if obj.Amount != nil {
+		amount = *obj.Amount
+	}
+	if amount > available {
+		s.log.Debug("ClawbackService: Clawback. Amount exceeds accrual",
+			zap.String("orderNum", orderNum), zap.Stringer("amount", amount), zap.Stringer("available", available))
+		return nil, domain.ErrClawbackExceedsAccrual
+	}
+
+	account, err := s.dbBalance.LockAccount(ctx, order.UserID)
+	if err != nil {
+		s.log.Error("ClawbackService: Clawback. Can't lock account", zap.Int("userID", order.UserID), zap.Error(err))
+		return nil, err
+	}
+	debited, debt := amount, money.Amount(0)
+	if s.policy == domain.ClawbackPolicyDebt && account.Balance < amount {
+		debited = account.Balance
+		if debited < 0 {
+			debited = 0
+		}
+		debt = amount - debited
+	}
+	now := time.Now().Truncate(time.Second)
+	if debited > 0 {
+		if err = s.debit(ctx, account.ID, order.ID, order.Num, debited, obj.Reason); err != nil {
+			return nil, err
+		}
+	}
+	if debt > 0 {
+		err = s.dbClawback.CreateDebt(ctx, &models.Debt{
+			UserID:    order.UserID,
+			AccountID: account.ID,
+			OrderNum:  order.Num,
+			Amount:    debt,
+			CreatedAt: now,
+		})
+		if err != nil {
+			s.log.Error("ClawbackService: Clawback. Can't create debt", zap.String("orderNum", orderNum), zap.Error(err))
+			return nil, err
+		}
+	}
+	if err = s.dbClawback.AddClawback(ctx, order.ID, amount, now); err != nil {
+		s.log.Error("ClawbackService: Clawback. Can't save order clawback", zap.String("orderNum", orderNum), zap.Error(err))
+		return nil, err
+	}
+	s.log.Info("ClawbackService: Clawback. Points clawed back",
+		zap.String("orderNum", orderNum), zap.Stringer("debited", debited), zap.Stringer("debt", debt))
+	return &domain.ClawbackResult{OrderNum: order.Num, Amount: amount, Debited: debited, Debt: debt}, nil
+}
+
+// RepayDebts repays outstanding debts of the user from the balance, oldest first.
+func (s *ClawbackService) RepayDebts(ctx context.Context, userID int) error {
+	account, err := s.dbBalance.LockAccount(ctx, userID)
+	if err != nil {
+		s.log.Error("ClawbackService: RepayDebts. Can't lock account", zap.Int("userID", userID), zap.Error(err))
+		return err
+	}
+	if account.Balance <= 0 {
+		return nil
+	}
+	debtList, err := s.dbClawback.LockOutstandingDebts(ctx, userID)
+	if err != nil {
+		s.log.Error("ClawbackService: RepayDebts. Can't get debts", zap.Int("userID", userID), zap.Error(err))
+		return err
+	}
+	balance := account.Balance
+	for i := range debtList {
+		if balance <= 0 {
+			break
+		}
+		debt := &debtList[i]
+		payment := debt.Remaining
+		if payment > balance {
+			payment = balance
+		}
+		if err = s.debit(ctx, account.ID, 0, debt.OrderNum, payment, fmt.Sprintf("debt %d repayment", debt.ID)); err != nil {
+			return err
+		}
+		debt.Remaining -= payment
+		balance -= payment
+		if err = s.dbClawback.UpdateDebt(ctx, debt); err != nil {
+			s.log.Error("ClawbackService: RepayDebts. Can't update debt", zap.Int("debtID", debt.ID), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// debit returns clawed back points to the accruals system account.
+func (s *ClawbackService) debit(ctx context.Context, accountID int, orderID int, orderNum string, amount money.Amount, reason string) error {
+	accruals, err := s.ledger.SystemAccountID(ctx, models.SystemAccountAccruals)
+	if err != nil {
+		return err
+	}
+	_, err = s.ledger.Transfer(ctx, LedgerTransfer{
+		EntryType:   models.EntryClawback,
+		OrderID:     orderID,
+		OrderNum:    orderNum,
+		Description: reason,
+		From:        accountID,
+		To:          accruals,
+		Amount:      amount,
+	})
+	if err != nil {
+		s.log.Error("ClawbackService: debit. Can't post clawback", zap.String("orderNum", orderNum), zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func amountPtr(a money.Amount) *money.Amount {
+	return &a
+}
+
+func TestClawbackService_Clawback(t *testing.T) {
+	type args struct {
+		policy     string
+		amount     *money.Amount
+		accrued    money.Amount
+		clawedBack money.Amount
+		balance    money.Amount
+	}
+	type wants struct {
+		debited money.Amount
+		debt    money.Amount
+		error   error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name:  "ClawbackService. Clawback. Test 1. Whole accrual",
+			args:  args{policy: domain.ClawbackPolicyNegative, accrued: money.Rubles(100, 0), balance: money.Rubles(500, 0)},
+			wants: wants{debited: money.Rubles(100, 0)},
+		},
+		{
+			name:  "ClawbackService. Clawback. Test 2. Partial clawback",
+			args:  args{policy: domain.ClawbackPolicyNegative, amount: amountPtr(money.Rubles(30, 0)), accrued: money.Rubles(100, 0), clawedBack: money.Rubles(50, 0), balance: money.Rubles(500, 0)},
+			wants: wants{debited: money.Rubles(30, 0)},
+		},
+		{
+			name:  "ClawbackService. Clawback. Test 3. Exceeds the rest of accrual",
+			args:  args{policy: domain.ClawbackPolicyNegative, amount: amountPtr(money.Rubles(60, 0)), accrued: money.Rubles(100, 0), clawedBack: money.Rubles(50, 0)},
+			wants: wants{error: domain.ErrClawbackExceedsAccrual},
+		},
+		{
+			name:  "ClawbackService. Clawback. Test 4. Already clawed back",
+			args:  args{policy: domain.ClawbackPolicyNegative, accrued: money.Rubles(100, 0), clawedBack: money.Rubles(100, 0)},
+			wants: wants{error: domain.ErrNothingToClawBack},
+		},
+		{
+			name:  "ClawbackService. Clawback. Test 5. Spent balance goes negative",
+			args:  args{policy: domain.ClawbackPolicyNegative, accrued: money.Rubles(100, 0), balance: money.Rubles(40, 0)},
+			wants: wants{debited: money.Rubles(100, 0)},
+		},
+		{
+			name:  "ClawbackService. Clawback. Test 6. Spent balance becomes a debt",
+			args:  args{policy: domain.ClawbackPolicyDebt, accrued: money.Rubles(100, 0), balance: money.Rubles(40, 0)},
+			wants: wants{debited: money.Rubles(40, 0), debt: money.Rubles(60, 0)},
+		},
+		{
+			name:  "ClawbackService. Clawback. Test 7. Zero balance",
+			args:  args{policy: domain.ClawbackPolicyDebt, accrued: money.Rubles(100, 0)},
+			wants: wants{debt: money.Rubles(100, 0)},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	clawbackRepository := mocks.NewMockClawbackRepository(mockCtrl)
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	ledgerRepository.EXPECT().GetSystemAccount(ctx, models.SystemAccountAccruals).Return(&models.Account{ID: 100}, nil).AnyTimes()
+	ledger := NewLedgerService(ledgerRepository, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := NewClawbackService(clawbackRepository, orderRepository, balanceRepository, ledger, log, tt.args.policy)
+			orderRepository.EXPECT().LockOrder(ctx, "12345678903").Return(&models.Order{ID: 5, UserID: 7, Num: "12345678903"}, nil)
+			clawbackRepository.EXPECT().GetOrderTotals(ctx, 5).Return(tt.args.accrued, tt.args.clawedBack, nil)
+			if tt.wants.error == nil {
+				balanceRepository.EXPECT().LockAccount(ctx, 7).Return(&models.Account{ID: 3, UserID: 7, Balance: tt.args.balance}, nil)
+				if tt.wants.debited > 0 {
+					ledgerRepository.EXPECT().Post(ctx, gomock.Any()).DoAndReturn(
+						func(ctx context.Context, entry *models.JournalEntry) error {
+							assert.Equal(t, models.EntryClawback, entry.EntryType)
+							assert.Equal(t, models.Operation{AccountID: 3, OrderID: 5, OrderNum: "12345678903",
+								OperationType: models.OperationDebit, Amount: tt.wants.debited, ProcessedAt: entry.CreatedAt}, entry.Operations[0])
+							return nil
+						},
+					)
+				}
+				if tt.wants.debt > 0 {
+					clawbackRepository.EXPECT().CreateDebt(ctx, gomock.Any()).DoAndReturn(
+						func(ctx context.Context, debt *models.Debt) error {
+							assert.Equal(t, tt.wants.debt, debt.Amount)
+							return nil
+						},
+					)
+				}
+				clawbackRepository.EXPECT().AddClawback(ctx, 5, tt.wants.debited+tt.wants.debt, gomock.Any()).Return(nil)
+			}
+			res, err := target.Clawback(ctx, "12345678903", &domain.Clawback{Amount: tt.args.amount, Reason: "goods returned"})
+			assert.ErrorIs(t, err, tt.wants.error)
+			if tt.wants.error == nil {
+				assert.Equal(t, tt.wants.debited, res.Debited)
+				assert.Equal(t, tt.wants.debt, res.Debt)
+			}
+		})
+	}
+}
+
+func TestClawbackService_RepayDebts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	clawbackRepository := mocks.NewMockClawbackRepository(mockCtrl)
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	ledgerRepository.EXPECT().GetSystemAccount(ctx, models.SystemAccountAccruals).Return(&models.Account{ID: 100}, nil).AnyTimes()
+	target := NewClawbackService(clawbackRepository, nil, balanceRepository, NewLedgerService(ledgerRepository, log), log, domain.ClawbackPolicyDebt)
+
+	balanceRepository.EXPECT().LockAccount(ctx, 7).Return(&models.Account{ID: 3, UserID: 7, Balance: money.Rubles(50, 0)}, nil)
+	clawbackRepository.EXPECT().LockOutstandingDebts(ctx, 7).Return([]models.Debt{
+		{ID: 1, OrderNum: "1", Remaining: money.Rubles(30, 0)},
+		{ID: 2, OrderNum: "2", Remaining: money.Rubles(30, 0)},
+		{ID: 3, OrderNum: "3", Remaining: money.Rubles(30, 0)},
+	}, nil)
+	var payments []money.Amount
+	ledgerRepository.EXPECT().Post(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, entry *models.JournalEntry) error {
+			payments = append(payments, entry.Operations[0].Amount)
+			return nil
+		},
+	).Times(2)
+	var remaining []money.Amount
+	clawbackRepository.EXPECT().UpdateDebt(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, debt *models.Debt) error {
+			remaining = append(remaining, debt.Remaining)
+			return nil
+		},
+	).Times(2)
+
+	assert.NoError(t, target.RepayDebts(ctx, 7))
+	assert.Equal(t, []money.Amount{money.Rubles(30, 0), money.Rubles(20, 0)}, payments, "oldest debts must be repaid first")
+	assert.Equal(t, []money.Amount{0, money.Rubles(10, 0)}, remaining)
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"time"
+)
+
+// A cursor is the position of the last row of a page: its time and ID.
+func encodeCursor(at time.Time, id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", at.UnixNano(), id)))
+}
+
+func decodeCursor(cursor string) (time.Time, int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: bad cursor", domain.ErrBadParam)
+	}
+	var nanos int64
+	var id int
+	if _, err = fmt.Sscanf(string(b), "%d:%d", &nanos, &id); err != nil || id <= 0 {
+		return time.Time{}, 0, fmt.Errorf("%w: bad cursor", domain.ErrBadParam)
+	}
+	return time.Unix(0, nanos).UTC(), id, nil
+}
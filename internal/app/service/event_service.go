@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const eventsPageSize = 100
+
+// EventService keeps the event log of users and wakes up streams of this instance when events are published.
+// Streams of other instances find new events by polling the log.
+type EventService struct {
+	db          models.EventRepository
+	log         *infrastructure.Logger
+	retention   time.Duration
+	mu          sync.Mutex
+	subscribers map[int]map[chan struct{}]struct{}
+	now         func() time.Time
+}
+
+func NewEventService(eventRepo models.EventRepository, log *infrastructure.Logger, retention time.Duration) *EventService {
+	var target EventService
+	target.db = eventRepo
+	target.log = log
+	target.retention = retention
+	target.subscribers = make(map[int]map[chan struct{}]struct{})
+	target.now = time.Now
+	return &target
+}
+
+// Publish adds the event to the log, subscribers are woken up by Notify.
+func (s *EventService) Publish(ctx context.Context, outboxEventID int, userID int, eventType string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		s.log.Error("EventService: Publish. Can't serialize payload", zap.String("type", eventType), zap.Error(err))
+		return err
+	}
+	event := models.UserEvent{UserID: userID, Type: eventType, Payload: b, CreatedAt: s.now(), OutboxEventID: outboxEventID}
+	err = s.db.Save(ctx, &event)
+	if errors.Is(err, &models.UniqueViolation) {
+		s.log.Debug("EventService: Publish. Event already published", zap.Int("outboxEventID", outboxEventID))
+		return nil
+	}
+	if err != nil {
+		s.log.Error("EventService: Publish. Can't save event", zap.Int("userID", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Notify wakes up streams of the user, a stream busy writing events is woken up once.
+func (s *EventService) Notify(userID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel signalling new events of the user and a function to unsubscribe.
+func (s *EventService) Subscribe(userID int) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[chan struct{}]struct{})
+	}
+	s.subscribers[userID][ch] = struct{}{}
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers[userID], ch)
+		if len(s.subscribers[userID]) == 0 {
+			delete(s.subscribers, userID)
+		}
+	}
+}
+
+// GetEvents returns the next events of the user following the event afterID.
+func (s *EventService) GetEvents(ctx context.Context, userID int, afterID int) ([]domain.Event, error) {
+	events, err := s.db.FindAfter(ctx, userID, afterID, eventsPageSize)
+	if err != nil {
+		s.log.Error("EventService: GetEvents. Can't get events", zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	var resList []domain.Event
+	for _, e := range events {
+		resList = append(resList, domain.Event{ID: e.ID, Type: e.Type, Data: e.Payload})
+	}
+	return resList, nil
+}
+
+// GetLastEventID returns the id of the latest event of the user, a stream without Last-Event-ID starts after it.
+func (s *EventService) GetLastEventID(ctx context.Context, userID int) (int, error) {
+	id, err := s.db.GetLastID(ctx, userID)
+	if err != nil {
+		s.log.Error("EventService: GetLastEventID. Can't get last event id", zap.Int("userID", userID), zap.Error(err))
+		return 0, err
+	}
+	return id, nil
+}
+
+// DeleteOldEvents removes events older than the retention period, streams can't be resumed from them anymore.
+func (s *EventService) DeleteOldEvents(ctx context.Context) error {
+	count, err := s.db.DeleteOlderThan(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		s.log.Error("EventService: DeleteOldEvents. Can't delete events", zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		s.log.Info("EventService: DeleteOldEvents. Old events deleted", zap.Int("count", count))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEventService_Publish(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	eventRepository := mocks.NewMockEventRepository(mockCtrl)
+	target := NewEventService(eventRepository, log, time.Hour)
+	now := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	target.now = func() time.Time { return now }
+
+	eventRepository.EXPECT().Save(ctx, &models.UserEvent{
+		UserID: 1, Type: domain.EventAccrual, Payload: []byte(`{"order":"12345678903","sum":500.5}`), CreatedAt: now, OutboxEventID: 9,
+	}).Return(nil)
+	err := target.Publish(ctx, 9, 1, domain.EventAccrual, domain.AccrualEvent{Num: "12345678903", Amount: money.Rubles(500, 50)})
+	assert.NoError(t, err)
+
+	eventRepository.EXPECT().Save(ctx, gomock.Any()).Return(&models.UniqueViolation)
+	err = target.Publish(ctx, 9, 1, domain.EventAccrual, domain.AccrualEvent{Num: "12345678903", Amount: money.Rubles(500, 50)})
+	assert.NoError(t, err, "an outbox event published again is skipped")
+
+	eventRepository.EXPECT().DeleteOlderThan(ctx, now.Add(-time.Hour)).Return(3, nil)
+	assert.NoError(t, target.DeleteOldEvents(ctx))
+}
+
+func TestEventService_Subscribe(t *testing.T) {
+	target := NewEventService(nil, log, time.Hour)
+	first, unsubscribeFirst := target.Subscribe(1)
+	second, unsubscribeSecond := target.Subscribe(1)
+	other, unsubscribeOther := target.Subscribe(2)
+	defer unsubscribeOther()
+
+	target.Notify(1)
+	target.Notify(1)
+	assert.Len(t, first, 1, "a pending notification is not repeated")
+	assert.Len(t, second, 1)
+	assert.Len(t, other, 0, "streams of other users are not woken up")
+
+	<-first
+	unsubscribeFirst()
+	unsubscribeSecond()
+	target.Notify(1)
+	assert.Len(t, first, 0)
+	assert.Empty(t, target.subscribers[1])
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+// expiryBatchSize limits users handled by one query of the expiration job.
+const expiryBatchSize = 100
+
+type ExpiryConfig struct {
+	// Months after the accrual when points expire, 0 disables expiration.
+	Months int
+	// NoticePeriod is how far ahead upcoming expirations are shown in the balance.
+	NoticePeriod time.Duration
+}
+
+// ExpiryService expires accrued points. Points are tracked by credit lots, a lot expires Months after its accrual,
+// the part of the lot left after debits is written off to the expirations system account.
+type ExpiryService struct {
+	dbLots    models.CreditLotRepository
+	dbBalance models.BalanceRepository
+	ledger    *LedgerService
+	tx        basedbhandler.Transactioner
+	log       *infrastructure.Logger
+	cfg       ExpiryConfig
+	now       func() time.Time
+}
+
+func NewExpiryService(lotsRepo models.CreditLotRepository, balanceRepo models.BalanceRepository, ledger *LedgerService,
+	tx basedbhandler.Transactioner, log *infrastructure.Logger, cfg ExpiryConfig) *ExpiryService {
+	var target ExpiryService
+	target.dbLots = lotsRepo
+	target.dbBalance = balanceRepo
+	target.ledger = ledger
+	target.tx = tx
+	target.log = log
+	target.cfg = cfg
+	target.now = time.Now
+	return &target
+}
+
+func (s *ExpiryService) Enabled() bool {
+	return s.cfg.Months > 0
+}
+
+// ExpirePoints writes off expired points of all users, every user is handled in its own transaction.
+func (s *ExpiryService) ExpirePoints(ctx context.Context) error {
+	if !s.Enabled() {
+		return nil
+	}
+	cutoff := s.now().AddDate(0, -s.cfg.Months, 0)
+	var expired int
+	for {
+		userList, err := s.dbLots.FindUsersWithExpiredLots(ctx, cutoff, expiryBatchSize)
+		if err != nil {
+			s.log.Error("ExpiryService: ExpirePoints. Can't find users with expired points", zap.Error(err))
+			return err
+		}
+		for _, userID := range userList {
+			err = inTransaction(ctx, s.tx, func(ctx context.Context) error {
+				return s.expireUserPoints(ctx, userID, cutoff)
+			})
+			if err != nil {
+				s.log.Error("ExpiryService: ExpirePoints. Can't expire points", zap.Int("userID", userID), zap.Error(err))
+				return err
+			}
+		}
+		expired += len(userList)
+		if len(userList) < expiryBatchSize {
+			break
+		}
+	}
+	if expired > 0 {
+		s.log.Info("ExpiryService: ExpirePoints. Done", zap.Int("users", expired), zap.Time("cutoff", cutoff))
+	}
+	return nil
+}
+
+func (s *ExpiryService) expireUserPoints(ctx context.Context, userID int, cutoff time.Time) error {
+	account, err := s.dbBalance.LockAccount(ctx, userID)
+	if err != nil {
+		return err
+	}
+	total, err := s.dbLots.GetExpiredTotal(ctx, account.ID, cutoff)
+	if err != nil {
+		return err
+	}
+	// a balance lowered by a clawback can't cover all expired points, the rest of the lots is just closed
+	amount := total
+	if account.Balance < amount {
+		amount = account.Balance
+	}
+	if amount > 0 {
+		expirations, err := s.ledger.SystemAccountID(ctx, models.SystemAccountExpirations)
+		if err != nil {
+			return err
+		}
+		_, err = s.ledger.Transfer(ctx, LedgerTransfer{
+			EntryType:   models.EntryExpire,
+			EntryKey:    fmt.Sprintf("%s:%d:%d", models.EntryExpire, userID, cutoff.Unix()),
+			Description: "points expired",
+			From:        account.ID,
+			To:          expirations,
+			Amount:      amount,
+		})
+		if err != nil {
+			return err
+		}
+		s.log.Info("ExpiryService: expireUserPoints. Points expired", zap.Int("userID", userID), zap.Stringer("amount", amount))
+	}
+	return s.dbLots.CloseExpired(ctx, account.ID, cutoff)
+}
+
+// GetUpcomingExpirations returns amounts of points expiring within the notice period grouped by date.
+func (s *ExpiryService) GetUpcomingExpirations(ctx context.Context, userID int) ([]domain.Expiration, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+	createdBefore := s.now().Add(s.cfg.NoticePeriod).AddDate(0, -s.cfg.Months, 0)
+	lotList, err := s.dbLots.FindExpirable(ctx, userID, createdBefore)
+	if err != nil {
+		s.log.Error("ExpiryService: GetUpcomingExpirations. Can't find expirable points", zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	var resList []domain.Expiration
+	for _, lot := range lotList {
+		expiresAt := lot.CreatedAt.AddDate(0, s.cfg.Months, 0).UTC()
+		date := time.Date(expiresAt.Year(), expiresAt.Month(), expiresAt.Day(), 0, 0, 0, 0, time.UTC)
+		if n := len(resList); n > 0 && resList[n-1].Date.Equal(date) {
+			resList[n-1].Amount += lot.Remaining
+			continue
+		}
+		resList = append(resList, domain.Expiration{Date: date, Amount: lot.Remaining})
+	}
+	return resList, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExpiryService_ExpirePoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		balance    money.Amount
+		expired    money.Amount
+		wantAmount money.Amount
+	}{
+		{
+			name:       "ExpiryService. ExpirePoints. Test 1. Balance covers expired points",
+			balance:    money.Rubles(100, 0),
+			expired:    money.Rubles(30, 0),
+			wantAmount: money.Rubles(30, 0),
+		},
+		{
+			name:       "ExpiryService. ExpirePoints. Test 2. Balance lowered by a clawback",
+			balance:    money.Rubles(10, 0),
+			expired:    money.Rubles(30, 0),
+			wantAmount: money.Rubles(10, 0),
+		},
+		{
+			name:    "ExpiryService. ExpirePoints. Test 3. Negative balance",
+			balance: money.Rubles(-5, 0),
+			expired: money.Rubles(30, 0),
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	now := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	cutoff := time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+	lotRepository := mocks.NewMockCreditLotRepository(mockCtrl)
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	ledgerRepository.EXPECT().GetSystemAccount(gomock.Any(), models.SystemAccountExpirations).Return(&models.Account{ID: 100}, nil).AnyTimes()
+	tx := &transactionerStub{}
+	target := NewExpiryService(lotRepository, balanceRepository, NewLedgerService(ledgerRepository, log), tx, log,
+		ExpiryConfig{Months: 12, NoticePeriod: 720 * time.Hour})
+	target.now = func() time.Time { return now }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lotRepository.EXPECT().FindUsersWithExpiredLots(ctx, cutoff, expiryBatchSize).Return([]int{7}, nil)
+			balanceRepository.EXPECT().LockAccount(gomock.Any(), 7).Return(&models.Account{ID: 3, UserID: 7, Balance: tt.balance}, nil)
+			lotRepository.EXPECT().GetExpiredTotal(gomock.Any(), 3, cutoff).Return(tt.expired, nil)
+			if tt.wantAmount > 0 {
+				ledgerRepository.EXPECT().Post(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, entry *models.JournalEntry) error {
+						assert.Equal(t, models.EntryExpire, entry.EntryType)
+						for _, op := range entry.Operations {
+							assert.Equal(t, tt.wantAmount, op.Amount)
+							if op.OperationType == models.OperationDebit {
+								assert.Equal(t, 3, op.AccountID)
+							} else {
+								assert.Equal(t, 100, op.AccountID)
+							}
+						}
+						return nil
+					})
+			}
+			lotRepository.EXPECT().CloseExpired(gomock.Any(), 3, cutoff).Return(nil)
+
+			err := target.ExpirePoints(ctx)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestExpiryService_GetUpcomingExpirations(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	now := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	lotRepository := mocks.NewMockCreditLotRepository(mockCtrl)
+	target := NewExpiryService(lotRepository, nil, nil, nil, log, ExpiryConfig{Months: 12, NoticePeriod: 720 * time.Hour})
+	target.now = func() time.Time { return now }
+
+	lotRepository.EXPECT().FindExpirable(ctx, 7, time.Date(2020, 7, 15, 12, 0, 0, 0, time.UTC)).Return([]models.CreditLot{
+		{ID: 1, Remaining: money.Rubles(10, 0), Expirable: true, CreatedAt: time.Date(2020, 6, 20, 8, 0, 0, 0, time.UTC)},
+		{ID: 2, Remaining: money.Rubles(5, 50), Expirable: true, CreatedAt: time.Date(2020, 6, 20, 18, 0, 0, 0, time.UTC)},
+		{ID: 3, Remaining: money.Rubles(7, 0), Expirable: true, CreatedAt: time.Date(2020, 7, 1, 9, 0, 0, 0, time.UTC)},
+	}, nil)
+
+	res, err := target.GetUpcomingExpirations(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Expiration{
+		{Date: time.Date(2021, 6, 20, 0, 0, 0, 0, time.UTC), Amount: money.Rubles(15, 50)},
+		{Date: time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC), Amount: money.Rubles(7, 0)},
+	}, res)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"strconv"
+	"time"
+)
+
+type IdempotencyService struct {
+	db  models.IdempotencyRepository
+	log *infrastructure.Logger
+	ttl time.Duration
+}
+
+func NewIdempotencyService(repo models.IdempotencyRepository, log *infrastructure.Logger, ttl time.Duration) *IdempotencyService {
+	var target IdempotencyService
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	target.db = repo
+	target.log = log
+	target.ttl = ttl
+	return &target
+}
+
+// Begin locks the key till the end of the request transaction and returns the stored response
+// of the request already made with this key, nil if the key is new.
+func (s *IdempotencyService) Begin(ctx context.Context, userID int, key string, requestHash string) (*domain.IdempotentResponse, error) {
+	if userID == 0 || key == "" {
+		s.log.Debug("IdempotencyService: Begin. Got empty user or key")
+		return nil, domain.ErrBadParam
+	}
+	if err := s.db.Lock(ctx, idempotencyLockKey(userID, key)); err != nil {
+		s.log.Error("IdempotencyService: Begin. Can't lock key", zap.Error(err))
+		return nil, err
+	}
+	stored, err := s.db.Get(ctx, userID, key, time.Now())
+	if err != nil {
+		if errors.Is(err, &models.NoRowFound) {
+			return nil, nil
+		}
+		s.log.Error("IdempotencyService: Begin. Can't get key", zap.Error(err))
+		return nil, err
+	}
+	if stored.RequestHash != requestHash {
+		s.log.Debug("IdempotencyService: Begin. Key reused", zap.Int("userID", userID), zap.String("key", key))
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	return &domain.IdempotentResponse{StatusCode: stored.StatusCode, Body: stored.Response}, nil
+}
+
+// Complete stores the response for the key. A successful response is saved in the request transaction together
+// with its effects. A client error is saved outside of it since the transaction is rolled back. A server error
+// is not saved, so the request can be retried with the same key.
+func (s *IdempotencyService) Complete(ctx context.Context, userID int, key string, requestHash string, res domain.IdempotentResponse) error {
+	if res.StatusCode >= 500 {
+		return nil
+	}
+	if res.StatusCode > 204 {
+		ctx = basedbhandler.WithoutTransaction(ctx)
+	}
+	now := time.Now()
+	err := s.db.Save(ctx, &models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		StatusCode:  res.StatusCode,
+		Response:    res.Body,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	})
+	if err != nil {
+		s.log.Error("IdempotencyService: Complete. Can't save key", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteExpiredKeys is the scheduler job removing keys past their TTL.
+func (s *IdempotencyService) DeleteExpiredKeys(ctx context.Context) error {
+	count, err := s.db.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		s.log.Error("IdempotencyService: DeleteExpiredKeys. Can't delete keys", zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		s.log.Info("IdempotencyService: DeleteExpiredKeys. Expired keys deleted", zap.Int("count", count))
+	}
+	return nil
+}
+
+func idempotencyLockKey(userID int, key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("gophermart:idempotency:" + strconv.Itoa(userID) + ":" + key))
+	return int64(h.Sum64())
+}
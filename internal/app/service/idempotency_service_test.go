@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	repo := mocks.NewMockIdempotencyRepository(mockCtrl)
+	target := NewIdempotencyService(repo, log, time.Hour)
+
+	repo.EXPECT().Lock(ctx, idempotencyLockKey(1, "key")).Return(nil).Times(3)
+	repo.EXPECT().Get(ctx, 1, "key", gomock.Any()).Return(nil, &models.NoRowFound)
+	res, err := target.Begin(ctx, 1, "key", "hash")
+	assert.NoError(t, err)
+	assert.Nil(t, res, "new key must not have a stored response")
+
+	stored := &models.IdempotencyKey{UserID: 1, Key: "key", RequestHash: "hash", StatusCode: 402, Response: []byte(`{"msg":"x"}`)}
+	repo.EXPECT().Get(ctx, 1, "key", gomock.Any()).Return(stored, nil).Times(2)
+	res, err = target.Begin(ctx, 1, "key", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.IdempotentResponse{StatusCode: 402, Body: stored.Response}, res, "stored response must be replayed")
+
+	_, err = target.Begin(ctx, 1, "key", "another hash")
+	assert.ErrorIs(t, err, domain.ErrIdempotencyKeyReused)
+
+	_, err = target.Begin(ctx, 0, "key", "hash")
+	assert.ErrorIs(t, err, domain.ErrBadParam)
+}
+
+func TestIdempotencyService_Complete(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.WithValue(context.Background(), basedbhandler.TransactionKey("tx"), "tx")
+	repo := mocks.NewMockIdempotencyRepository(mockCtrl)
+	target := NewIdempotencyService(repo, log, time.Hour)
+
+	start := time.Now()
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key *models.IdempotencyKey) error {
+			assert.NotNil(t, ctx.Value(basedbhandler.TransactionKey("tx")), "success must be saved in the request transaction")
+			assert.Equal(t, http.StatusOK, key.StatusCode)
+			assert.False(t, key.ExpiresAt.Before(start.Add(time.Hour)), "key must expire after TTL")
+			return nil
+		},
+	)
+	assert.NoError(t, target.Complete(ctx, 1, "key", "hash", domain.IdempotentResponse{StatusCode: http.StatusOK}))
+
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key *models.IdempotencyKey) error {
+			assert.Nil(t, ctx.Value(basedbhandler.TransactionKey("tx")), "client error must be saved outside of the rolled back transaction")
+			return nil
+		},
+	)
+	assert.NoError(t, target.Complete(ctx, 1, "key", "hash", domain.IdempotentResponse{StatusCode: 402}))
+
+	assert.NoError(t, target.Complete(ctx, 1, "key", "hash", domain.IdempotentResponse{StatusCode: 500}), "server error must not be saved")
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LedgerTransfer describes an entry moving Amount from the From account (debited) to the To account (credited).
+type LedgerTransfer struct {
+	EntryType   string
+	EntryKey    string
+	OrderID     int
+	OrderNum    string
+	Description string
+	From        int
+	To          int
+	Amount      money.Amount
+	// Expirable marks credited points as subject to expiration.
+	Expirable bool
+	// RestoresEntryID is the entry whose debited points are returned by the transfer.
+	RestoresEntryID int
+}
+
+// LedgerService is the only way to change balances: every change is a balanced journal entry
+// between user and system accounts.
+type LedgerService struct {
+	db             models.LedgerRepository
+	log            *infrastructure.Logger
+	mu             sync.Mutex
+	systemAccounts map[string]int
+}
+
+func NewLedgerService(repo models.LedgerRepository, log *infrastructure.Logger) *LedgerService {
+	var target LedgerService
+	target.db = repo
+	target.log = log
+	target.systemAccounts = make(map[string]int)
+	return &target
+}
+
+// SystemAccountID returns the ID of the system account, system accounts never change so IDs are cached.
+func (s *LedgerService) SystemAccountID(ctx context.Context, code string) (int, error) {
+	s.mu.Lock()
+	id, ok := s.systemAccounts[code]
+	s.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+	account, err := s.db.GetSystemAccount(ctx, code)
+	if err != nil {
+		s.log.Error("LedgerService: SystemAccountID. Can't get system account", zap.String("code", code), zap.Error(err))
+		return 0, err
+	}
+	s.mu.Lock()
+	s.systemAccounts[code] = account.ID
+	s.mu.Unlock()
+	return account.ID, nil
+}
+
+// Post validates and records the entry. Postings are applied in the order of account IDs,
+// so concurrent entries lock accounts in the same order.
+func (s *LedgerService) Post(ctx context.Context, entry *models.JournalEntry) error {
+	if err := validateEntry(entry); err != nil {
+		s.log.Error("LedgerService: Post. Invalid entry", zap.String("entryType", entry.EntryType), zap.Error(err))
+		return err
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().Truncate(time.Second)
+	}
+	for i := range entry.Operations {
+		if entry.Operations[i].ProcessedAt.IsZero() {
+			entry.Operations[i].ProcessedAt = entry.CreatedAt
+		}
+	}
+	sort.SliceStable(entry.Operations, func(i, j int) bool {
+		return entry.Operations[i].AccountID < entry.Operations[j].AccountID
+	})
+	err := s.db.Post(ctx, entry)
+	if errors.Is(err, &models.UniqueViolation) {
+		s.log.Debug("LedgerService: Post. Entry already posted", zap.String("entryKey", entry.EntryKey))
+		return domain.ErrEntryExists
+	}
+	if err != nil {
+		s.log.Error("LedgerService: Post. Can't post entry", zap.String("entryKey", entry.EntryKey), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Transfer posts a two-sided entry.
+func (s *LedgerService) Transfer(ctx context.Context, t LedgerTransfer) (*models.JournalEntry, error) {
+	now := time.Now().Truncate(time.Second)
+	entry := models.JournalEntry{
+		EntryType:       t.EntryType,
+		EntryKey:        t.EntryKey,
+		OrderNum:        t.OrderNum,
+		Description:     t.Description,
+		RestoresEntryID: t.RestoresEntryID,
+		CreatedAt:       now,
+		Operations: []models.Operation{
+			{AccountID: t.From, OrderID: t.OrderID, OrderNum: t.OrderNum, OperationType: models.OperationDebit, Amount: t.Amount, ProcessedAt: now},
+			{AccountID: t.To, OrderID: t.OrderID, OrderNum: t.OrderNum, OperationType: models.OperationCredit, Amount: t.Amount, ProcessedAt: now, Expirable: t.Expirable},
+		},
+	}
+	if err := s.Post(ctx, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func validateEntry(entry *models.JournalEntry) error {
+	if entry.EntryType == "" || len(entry.Operations) < 2 {
+		return domain.ErrUnbalancedEntry
+	}
+	var debit, credit money.Amount
+	for _, op := range entry.Operations {
+		if op.Amount <= 0 {
+			return fmt.Errorf("%w: non-positive posting amount %s", domain.ErrUnbalancedEntry, op.Amount)
+		}
+		switch op.OperationType {
+		case models.OperationDebit:
+			debit += op.Amount
+		case models.OperationCredit:
+			credit += op.Amount
+		default:
+			return fmt.Errorf("%w: unknown posting side %q", domain.ErrUnbalancedEntry, op.OperationType)
+		}
+	}
+	if debit != credit {
+		return fmt.Errorf("%w: debit %s, credit %s", domain.ErrUnbalancedEntry, debit, credit)
+	}
+	return nil
+}
+
+func accrualEntryKey(orderNum string) string {
+	return models.EntryAccrual + ":" + orderNum
+}
+
+func withdrawalEntryKey(userID int, orderNum string) string {
+	return fmt.Sprintf("%s:%d:%s", models.EntryWithdrawal, userID, orderNum)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLedgerService_Post(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []models.Operation
+		error      error
+	}{
+		{
+			name: "LedgerService. Post. Test 1. Balanced entry",
+			operations: []models.Operation{
+				{AccountID: 2, OperationType: models.OperationDebit, Amount: money.Rubles(10, 0)},
+				{AccountID: 1, OperationType: models.OperationCredit, Amount: money.Rubles(7, 50)},
+				{AccountID: 3, OperationType: models.OperationCredit, Amount: money.Rubles(2, 50)},
+			},
+			error: nil,
+		},
+		{
+			name: "LedgerService. Post. Test 2. Unbalanced entry",
+			operations: []models.Operation{
+				{AccountID: 1, OperationType: models.OperationDebit, Amount: money.Rubles(10, 0)},
+				{AccountID: 2, OperationType: models.OperationCredit, Amount: money.Rubles(9, 99)},
+			},
+			error: domain.ErrUnbalancedEntry,
+		},
+		{
+			name: "LedgerService. Post. Test 3. Single posting",
+			operations: []models.Operation{
+				{AccountID: 1, OperationType: models.OperationDebit, Amount: money.Rubles(10, 0)},
+			},
+			error: domain.ErrUnbalancedEntry,
+		},
+		{
+			name: "LedgerService. Post. Test 4. Zero amount",
+			operations: []models.Operation{
+				{AccountID: 1, OperationType: models.OperationDebit, Amount: 0},
+				{AccountID: 2, OperationType: models.OperationCredit, Amount: 0},
+			},
+			error: domain.ErrUnbalancedEntry,
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	target := NewLedgerService(ledgerRepository, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.error == nil {
+				ledgerRepository.EXPECT().Post(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, entry *models.JournalEntry) error {
+						for i := 1; i < len(entry.Operations); i++ {
+							assert.Less(t, entry.Operations[i-1].AccountID, entry.Operations[i].AccountID, "postings must be ordered by account")
+						}
+						assert.False(t, entry.Operations[0].ProcessedAt.IsZero(), "posting time must be set")
+						return nil
+					},
+				)
+			}
+			err := target.Post(ctx, &models.JournalEntry{EntryType: models.EntryAdjustment, Operations: tt.operations})
+			assert.ErrorIs(t, err, tt.error)
+		})
+	}
+}
+
+func TestLedgerService_Transfer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	target := NewLedgerService(ledgerRepository, log)
+
+	ledgerRepository.EXPECT().GetSystemAccount(ctx, models.SystemAccountAccruals).Return(&models.Account{ID: 100}, nil).Times(1)
+	id, err := target.SystemAccountID(ctx, models.SystemAccountAccruals)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, id)
+	id, _ = target.SystemAccountID(ctx, models.SystemAccountAccruals)
+	assert.Equal(t, 100, id, "system account ID must be cached")
+
+	ledgerRepository.EXPECT().Post(ctx, gomock.Any()).Return(&models.UniqueViolation)
+	_, err = target.Transfer(ctx, LedgerTransfer{EntryType: models.EntryAccrual, EntryKey: "ACCRUAL:1", From: 100, To: 1, Amount: 1})
+	assert.ErrorIs(t, err, domain.ErrEntryExists, "entry key must be posted once")
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+// MigrationService applies schema migrations one by one, each in its own transaction holding the migration lock,
+// so replicas starting at the same time apply every migration exactly once.
+type MigrationService struct {
+	db  models.MigrationRepository
+	tx  basedbhandler.Transactioner
+	log *infrastructure.Logger
+}
+
+func NewMigrationService(repo models.MigrationRepository, tx basedbhandler.Transactioner, log *infrastructure.Logger) *MigrationService {
+	var target MigrationService
+	target.db = repo
+	target.tx = tx
+	target.log = log
+	return &target
+}
+
+// Up applies all pending migrations and returns their number.
+func (s *MigrationService) Up(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		var applied *models.Migration
+		err := inTransaction(ctx, s.tx, func(ctx context.Context) error {
+			appliedList, err := s.lockAndGetApplied(ctx)
+			if err != nil {
+				return err
+			}
+			m := s.nextPending(appliedList)
+			if m == nil {
+				return nil
+			}
+			if err = s.db.Execute(ctx, m.Up); err != nil {
+				return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+			}
+			if err = s.db.SaveApplied(ctx, &models.AppliedMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}); err != nil {
+				return err
+			}
+			applied = m
+			return nil
+		})
+		if err != nil {
+			s.log.Error("MigrationService: Up. Can't apply migration", zap.Error(err))
+			return count, err
+		}
+		if applied == nil {
+			return count, nil
+		}
+		count++
+		s.log.Info("MigrationService: Up. Migration applied", zap.Int("version", applied.Version), zap.String("name", applied.Name))
+	}
+}
+
+// Down reverts up to steps last applied migrations and returns their number.
+func (s *MigrationService) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	for ; count < steps; count++ {
+		var reverted *models.Migration
+		err := inTransaction(ctx, s.tx, func(ctx context.Context) error {
+			appliedList, err := s.lockAndGetApplied(ctx)
+			if err != nil {
+				return err
+			}
+			if len(appliedList) == 0 {
+				return nil
+			}
+			last := appliedList[len(appliedList)-1]
+			m := s.find(last.Version)
+			if m == nil {
+				return fmt.Errorf("migration %d %s is unknown to this build", last.Version, last.Name)
+			}
+			if err = s.db.Execute(ctx, m.Down); err != nil {
+				return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+			}
+			if err = s.db.DeleteApplied(ctx, m.Version); err != nil {
+				return err
+			}
+			reverted = m
+			return nil
+		})
+		if err != nil {
+			s.log.Error("MigrationService: Down. Can't revert migration", zap.Error(err))
+			return count, err
+		}
+		if reverted == nil {
+			break
+		}
+		s.log.Info("MigrationService: Down. Migration reverted", zap.Int("version", reverted.Version), zap.String("name", reverted.Name))
+	}
+	return count, nil
+}
+
+// Reset reverts all applied migrations destroying all data and applies them again.
+func (s *MigrationService) Reset(ctx context.Context) error {
+	if _, err := s.Down(ctx, len(s.db.List())); err != nil {
+		return err
+	}
+	_, err := s.Up(ctx)
+	return err
+}
+
+// Status lists known migrations with their application time and migrations applied by a newer build.
+func (s *MigrationService) Status(ctx context.Context) ([]domain.MigrationStatus, error) {
+	var appliedList []models.AppliedMigration
+	err := inTransaction(ctx, s.tx, func(ctx context.Context) error {
+		var err error
+		appliedList, err = s.lockAndGetApplied(ctx)
+		return err
+	})
+	if err != nil {
+		s.log.Error("MigrationService: Status. Can't get applied migrations", zap.Error(err))
+		return nil, err
+	}
+	applied := make(map[int]models.AppliedMigration, len(appliedList))
+	for _, a := range appliedList {
+		applied[a.Version] = a
+	}
+	var resList []domain.MigrationStatus
+	for _, m := range s.db.List() {
+		status := domain.MigrationStatus{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			appliedAt := a.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(applied, m.Version)
+		}
+		resList = append(resList, status)
+	}
+	for _, a := range appliedList {
+		if _, ok := applied[a.Version]; ok {
+			appliedAt := a.AppliedAt
+			resList = append(resList, domain.MigrationStatus{Version: a.Version, Name: a.Name, AppliedAt: &appliedAt, Unknown: true})
+		}
+	}
+	return resList, nil
+}
+
+func (s *MigrationService) lockAndGetApplied(ctx context.Context) ([]models.AppliedMigration, error) {
+	if err := s.db.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return s.db.GetApplied(ctx)
+}
+
+func (s *MigrationService) nextPending(appliedList []models.AppliedMigration) *models.Migration {
+	applied := make(map[int]bool, len(appliedList))
+	for _, a := range appliedList {
+		applied[a.Version] = true
+	}
+	for _, m := range s.db.List() {
+		if !applied[m.Version] {
+			m := m
+			return &m
+		}
+	}
+	return nil
+}
+
+func (s *MigrationService) find(version int) *models.Migration {
+	for _, m := range s.db.List() {
+		if m.Version == version {
+			m := m
+			return &m
+		}
+	}
+	return nil
+}
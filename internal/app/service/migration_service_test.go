@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// transactionerStub counts transactions without a database.
+type transactionerStub struct {
+	commits   int
+	rollbacks int
+}
+
+func (s *transactionerStub) NewTx(ctx context.Context) (pgx.Tx, error) {
+	return nil, nil
+}
+
+func (s *transactionerStub) Commit(ctx context.Context) error {
+	s.commits++
+	return nil
+}
+
+func (s *transactionerStub) Rollback(ctx context.Context) error {
+	s.rollbacks++
+	return nil
+}
+
+var testMigrations = []models.Migration{
+	{Version: 1, Name: "first", Up: "up 1", Down: "down 1"},
+	{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+	{Version: 3, Name: "third", Up: "up 3", Down: "down 3"},
+}
+
+func TestMigrationService_Up(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	repo := mocks.NewMockMigrationRepository(mockCtrl)
+	tx := &transactionerStub{}
+	target := NewMigrationService(repo, tx, log)
+
+	applied := []models.AppliedMigration{{Version: 1, Name: "first"}}
+	repo.EXPECT().List().Return(testMigrations).AnyTimes()
+	repo.EXPECT().Lock(gomock.Any()).Return(nil).Times(3)
+	repo.EXPECT().GetApplied(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]models.AppliedMigration, error) {
+		return applied, nil
+	}).Times(3)
+	repo.EXPECT().Execute(gomock.Any(), "up 2").Return(nil)
+	repo.EXPECT().Execute(gomock.Any(), "up 3").Return(nil)
+	repo.EXPECT().SaveApplied(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, m *models.AppliedMigration) error {
+		applied = append(applied, *m)
+		return nil
+	}).Times(2)
+
+	count, err := target.Up(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count, "pending migrations must be applied")
+	assert.Equal(t, 3, tx.commits, "every migration must be applied in its own transaction")
+}
+
+func TestMigrationService_Up_Error(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	repo := mocks.NewMockMigrationRepository(mockCtrl)
+	tx := &transactionerStub{}
+	target := NewMigrationService(repo, tx, log)
+
+	repo.EXPECT().List().Return(testMigrations).AnyTimes()
+	repo.EXPECT().Lock(gomock.Any()).Return(nil)
+	repo.EXPECT().GetApplied(gomock.Any()).Return(nil, nil)
+	repo.EXPECT().Execute(gomock.Any(), "up 1").Return(errors.New("syntax error"))
+
+	count, err := target.Up(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, tx.rollbacks, "failed migration must be rolled back")
+}
+
+func TestMigrationService_Down(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	repo := mocks.NewMockMigrationRepository(mockCtrl)
+	target := NewMigrationService(repo, &transactionerStub{}, log)
+
+	applied := []models.AppliedMigration{{Version: 1, Name: "first"}, {Version: 2, Name: "second"}}
+	repo.EXPECT().List().Return(testMigrations).AnyTimes()
+	repo.EXPECT().Lock(gomock.Any()).Return(nil).Times(3)
+	repo.EXPECT().GetApplied(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]models.AppliedMigration, error) {
+		return applied, nil
+	}).Times(3)
+	repo.EXPECT().Execute(gomock.Any(), "down 2").Return(nil)
+	repo.EXPECT().Execute(gomock.Any(), "down 1").Return(nil)
+	repo.EXPECT().DeleteApplied(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, version int) error {
+		assert.Equal(t, applied[len(applied)-1].Version, version, "last applied migration must be reverted first")
+		applied = applied[:len(applied)-1]
+		return nil
+	}).Times(2)
+
+	count, err := target.Down(ctx, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count, "only applied migrations can be reverted")
+}
+
+func TestMigrationService_Status(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	repo := mocks.NewMockMigrationRepository(mockCtrl)
+	target := NewMigrationService(repo, &transactionerStub{}, log)
+
+	repo.EXPECT().List().Return(testMigrations).AnyTimes()
+	repo.EXPECT().Lock(gomock.Any()).Return(nil)
+	repo.EXPECT().GetApplied(gomock.Any()).Return([]models.AppliedMigration{{Version: 1, Name: "first"}, {Version: 4, Name: "future"}}, nil)
+
+	statusList, err := target.Status(ctx)
+	assert.NoError(t, err)
+	if assert.Equal(t, 4, len(statusList)) {
+		assert.NotNil(t, statusList[0].AppliedAt, "applied migration expected")
+		assert.Nil(t, statusList[1].AppliedAt, "pending migration expected")
+		assert.True(t, statusList[3].Unknown, "migration of a newer build expected")
+	}
+}
@@ -7,8 +7,10 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/da-semenov/gophermart/internal/app/models"
+	money "github.com/da-semenov/gophermart/internal/app/money"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,18 +37,19 @@ func (m *MockBalanceRepository) EXPECT() *MockBalanceRepositoryMockRecorder {
 	return m.recorder
 }
 
-// CreateOperation mocks base method.
-func (m *MockBalanceRepository) CreateOperation(arg0 context.Context, arg1 *models.Operation) error {
+// FindOperations mocks base method.
+func (m *MockBalanceRepository) FindOperations(arg0 context.Context, arg1 models.OperationFilter) ([]models.HistoryOperation, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOperation", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "FindOperations", arg0, arg1)
+	ret0, _ := ret[0].([]models.HistoryOperation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// CreateOperation indicates an expected call of CreateOperation.
-func (mr *MockBalanceRepositoryMockRecorder) CreateOperation(arg0, arg1 interface{}) *gomock.Call {
+// FindOperations indicates an expected call of FindOperations.
+func (mr *MockBalanceRepositoryMockRecorder) FindOperations(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOperation", reflect.TypeOf((*MockBalanceRepository)(nil).CreateOperation), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOperations", reflect.TypeOf((*MockBalanceRepository)(nil).FindOperations), arg0, arg1)
 }
 
 // FindWithdrawalByUser mocks base method.
@@ -79,6 +82,51 @@ func (mr *MockBalanceRepositoryMockRecorder) GetAccount(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockBalanceRepository)(nil).GetAccount), arg0, arg1)
 }
 
+// GetBalanceAt mocks base method.
+func (m *MockBalanceRepository) GetBalanceAt(arg0 context.Context, arg1 int, arg2 time.Time) (money.Amount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceAt", arg0, arg1, arg2)
+	ret0, _ := ret[0].(money.Amount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceAt indicates an expected call of GetBalanceAt.
+func (mr *MockBalanceRepositoryMockRecorder) GetBalanceAt(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceAt", reflect.TypeOf((*MockBalanceRepository)(nil).GetBalanceAt), arg0, arg1, arg2)
+}
+
+// GetDebtTotal mocks base method.
+func (m *MockBalanceRepository) GetDebtTotal(arg0 context.Context, arg1 int) (money.Amount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDebtTotal", arg0, arg1)
+	ret0, _ := ret[0].(money.Amount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDebtTotal indicates an expected call of GetDebtTotal.
+func (mr *MockBalanceRepositoryMockRecorder) GetDebtTotal(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDebtTotal", reflect.TypeOf((*MockBalanceRepository)(nil).GetDebtTotal), arg0, arg1)
+}
+
+// GetTransferredTotal mocks base method.
+func (m *MockBalanceRepository) GetTransferredTotal(arg0 context.Context, arg1 int, arg2 time.Time) (money.Amount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferredTotal", arg0, arg1, arg2)
+	ret0, _ := ret[0].(money.Amount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferredTotal indicates an expected call of GetTransferredTotal.
+func (mr *MockBalanceRepositoryMockRecorder) GetTransferredTotal(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferredTotal", reflect.TypeOf((*MockBalanceRepository)(nil).GetTransferredTotal), arg0, arg1, arg2)
+}
+
 // LockAccount mocks base method.
 func (m *MockBalanceRepository) LockAccount(arg0 context.Context, arg1 int) (*models.Account, error) {
 	m.ctrl.T.Helper()
@@ -94,17 +142,16 @@ func (mr *MockBalanceRepositoryMockRecorder) LockAccount(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAccount", reflect.TypeOf((*MockBalanceRepository)(nil).LockAccount), arg0, arg1)
 }
 
-// SaveAccount mocks base method.
-func (m *MockBalanceRepository) SaveAccount(arg0 context.Context, arg1 *models.Account) error {
+// ScanOperations mocks base method.
+func (m *MockBalanceRepository) ScanOperations(arg0 context.Context, arg1 models.OperationFilter, arg2 func(*models.HistoryOperation) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveAccount", arg0, arg1)
+	ret := m.ctrl.Call(m, "ScanOperations", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SaveAccount indicates an expected call of SaveAccount.
-func (mr *MockBalanceRepositoryMockRecorder) SaveAccount(arg0, arg1 interface{}) *gomock.Call {
+// ScanOperations indicates an expected call of ScanOperations.
+func (mr *MockBalanceRepositoryMockRecorder) ScanOperations(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAccount", reflect.TypeOf((*MockBalanceRepository)(nil).SaveAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanOperations", reflect.TypeOf((*MockBalanceRepository)(nil).ScanOperations), arg0, arg1, arg2)
 }
-
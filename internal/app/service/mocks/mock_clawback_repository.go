@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/models (interfaces: ClawbackRepository)
+
+// Package mock_models is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/da-semenov/gophermart/internal/app/models"
+	money "github.com/da-semenov/gophermart/internal/app/money"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockClawbackRepository is a mock of ClawbackRepository interface.
+type MockClawbackRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockClawbackRepositoryMockRecorder
+}
+
+// MockClawbackRepositoryMockRecorder is the mock recorder for MockClawbackRepository.
+type MockClawbackRepositoryMockRecorder struct {
+	mock *MockClawbackRepository
+}
+
+// NewMockClawbackRepository creates a new mock instance.
+func NewMockClawbackRepository(ctrl *gomock.Controller) *MockClawbackRepository {
+	mock := &MockClawbackRepository{ctrl: ctrl}
+	mock.recorder = &MockClawbackRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClawbackRepository) EXPECT() *MockClawbackRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddClawback mocks base method.
+func (m *MockClawbackRepository) AddClawback(arg0 context.Context, arg1 int, arg2 money.Amount, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddClawback", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddClawback indicates an expected call of AddClawback.
+func (mr *MockClawbackRepositoryMockRecorder) AddClawback(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClawback", reflect.TypeOf((*MockClawbackRepository)(nil).AddClawback), arg0, arg1, arg2, arg3)
+}
+
+// CreateDebt mocks base method.
+func (m *MockClawbackRepository) CreateDebt(arg0 context.Context, arg1 *models.Debt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDebt", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDebt indicates an expected call of CreateDebt.
+func (mr *MockClawbackRepositoryMockRecorder) CreateDebt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDebt", reflect.TypeOf((*MockClawbackRepository)(nil).CreateDebt), arg0, arg1)
+}
+
+// GetOrderTotals mocks base method.
+func (m *MockClawbackRepository) GetOrderTotals(arg0 context.Context, arg1 int) (money.Amount, money.Amount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderTotals", arg0, arg1)
+	ret0, _ := ret[0].(money.Amount)
+	ret1, _ := ret[1].(money.Amount)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetOrderTotals indicates an expected call of GetOrderTotals.
+func (mr *MockClawbackRepositoryMockRecorder) GetOrderTotals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderTotals", reflect.TypeOf((*MockClawbackRepository)(nil).GetOrderTotals), arg0, arg1)
+}
+
+// LockOutstandingDebts mocks base method.
+func (m *MockClawbackRepository) LockOutstandingDebts(arg0 context.Context, arg1 int) ([]models.Debt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockOutstandingDebts", arg0, arg1)
+	ret0, _ := ret[0].([]models.Debt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockOutstandingDebts indicates an expected call of LockOutstandingDebts.
+func (mr *MockClawbackRepositoryMockRecorder) LockOutstandingDebts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockOutstandingDebts", reflect.TypeOf((*MockClawbackRepository)(nil).LockOutstandingDebts), arg0, arg1)
+}
+
+// UpdateDebt mocks base method.
+func (m *MockClawbackRepository) UpdateDebt(arg0 context.Context, arg1 *models.Debt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDebt", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDebt indicates an expected call of UpdateDebt.
+func (mr *MockClawbackRepositoryMockRecorder) UpdateDebt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDebt", reflect.TypeOf((*MockClawbackRepository)(nil).UpdateDebt), arg0, arg1)
+}
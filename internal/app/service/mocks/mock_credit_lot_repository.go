@@ -0,0 +1,97 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/models (interfaces: CreditLotRepository)
+
+// Package mock_models is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/da-semenov/gophermart/internal/app/models"
+	money "github.com/da-semenov/gophermart/internal/app/money"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCreditLotRepository is a mock of CreditLotRepository interface.
+type MockCreditLotRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCreditLotRepositoryMockRecorder
+}
+
+// MockCreditLotRepositoryMockRecorder is the mock recorder for MockCreditLotRepository.
+type MockCreditLotRepositoryMockRecorder struct {
+	mock *MockCreditLotRepository
+}
+
+// NewMockCreditLotRepository creates a new mock instance.
+func NewMockCreditLotRepository(ctrl *gomock.Controller) *MockCreditLotRepository {
+	mock := &MockCreditLotRepository{ctrl: ctrl}
+	mock.recorder = &MockCreditLotRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCreditLotRepository) EXPECT() *MockCreditLotRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CloseExpired mocks base method.
+func (m *MockCreditLotRepository) CloseExpired(arg0 context.Context, arg1 int, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseExpired", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseExpired indicates an expected call of CloseExpired.
+func (mr *MockCreditLotRepositoryMockRecorder) CloseExpired(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseExpired", reflect.TypeOf((*MockCreditLotRepository)(nil).CloseExpired), arg0, arg1, arg2)
+}
+
+// FindExpirable mocks base method.
+func (m *MockCreditLotRepository) FindExpirable(arg0 context.Context, arg1 int, arg2 time.Time) ([]models.CreditLot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExpirable", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.CreditLot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExpirable indicates an expected call of FindExpirable.
+func (mr *MockCreditLotRepositoryMockRecorder) FindExpirable(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExpirable", reflect.TypeOf((*MockCreditLotRepository)(nil).FindExpirable), arg0, arg1, arg2)
+}
+
+// FindUsersWithExpiredLots mocks base method.
+func (m *MockCreditLotRepository) FindUsersWithExpiredLots(arg0 context.Context, arg1 time.Time, arg2 int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsersWithExpiredLots", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsersWithExpiredLots indicates an expected call of FindUsersWithExpiredLots.
+func (mr *MockCreditLotRepositoryMockRecorder) FindUsersWithExpiredLots(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsersWithExpiredLots", reflect.TypeOf((*MockCreditLotRepository)(nil).FindUsersWithExpiredLots), arg0, arg1, arg2)
+}
+
+// GetExpiredTotal mocks base method.
+func (m *MockCreditLotRepository) GetExpiredTotal(arg0 context.Context, arg1 int, arg2 time.Time) (money.Amount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredTotal", arg0, arg1, arg2)
+	ret0, _ := ret[0].(money.Amount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredTotal indicates an expected call of GetExpiredTotal.
+func (mr *MockCreditLotRepositoryMockRecorder) GetExpiredTotal(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredTotal", reflect.TypeOf((*MockCreditLotRepository)(nil).GetExpiredTotal), arg0, arg1, arg2)
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/models (interfaces: EventRepository)
+
+// Package mock_models is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/da-semenov/gophermart/internal/app/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockEventRepository is a mock of EventRepository interface.
+type MockEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRepositoryMockRecorder
+}
+
+// MockEventRepositoryMockRecorder is the mock recorder for MockEventRepository.
+type MockEventRepositoryMockRecorder struct {
+	mock *MockEventRepository
+}
+
+// NewMockEventRepository creates a new mock instance.
+func NewMockEventRepository(ctrl *gomock.Controller) *MockEventRepository {
+	mock := &MockEventRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteOlderThan mocks base method.
+func (m *MockEventRepository) DeleteOlderThan(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOlderThan", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOlderThan indicates an expected call of DeleteOlderThan.
+func (mr *MockEventRepositoryMockRecorder) DeleteOlderThan(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOlderThan", reflect.TypeOf((*MockEventRepository)(nil).DeleteOlderThan), arg0, arg1)
+}
+
+// FindAfter mocks base method.
+func (m *MockEventRepository) FindAfter(arg0 context.Context, arg1, arg2, arg3 int) ([]models.UserEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAfter", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]models.UserEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAfter indicates an expected call of FindAfter.
+func (mr *MockEventRepositoryMockRecorder) FindAfter(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAfter", reflect.TypeOf((*MockEventRepository)(nil).FindAfter), arg0, arg1, arg2, arg3)
+}
+
+// GetLastID mocks base method.
+func (m *MockEventRepository) GetLastID(arg0 context.Context, arg1 int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastID", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastID indicates an expected call of GetLastID.
+func (mr *MockEventRepositoryMockRecorder) GetLastID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastID", reflect.TypeOf((*MockEventRepository)(nil).GetLastID), arg0, arg1)
+}
+
+// Save mocks base method.
+func (m *MockEventRepository) Save(arg0 context.Context, arg1 *models.UserEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockEventRepositoryMockRecorder) Save(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockEventRepository)(nil).Save), arg0, arg1)
+}
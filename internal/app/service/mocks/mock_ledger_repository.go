@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/models (interfaces: LedgerRepository)
+
+// Package mock_models is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/da-semenov/gophermart/internal/app/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLedgerRepository is a mock of LedgerRepository interface.
+type MockLedgerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerRepositoryMockRecorder
+}
+
+// MockLedgerRepositoryMockRecorder is the mock recorder for MockLedgerRepository.
+type MockLedgerRepositoryMockRecorder struct {
+	mock *MockLedgerRepository
+}
+
+// NewMockLedgerRepository creates a new mock instance.
+func NewMockLedgerRepository(ctrl *gomock.Controller) *MockLedgerRepository {
+	mock := &MockLedgerRepository{ctrl: ctrl}
+	mock.recorder = &MockLedgerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedgerRepository) EXPECT() *MockLedgerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetSystemAccount mocks base method.
+func (m *MockLedgerRepository) GetSystemAccount(arg0 context.Context, arg1 string) (*models.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSystemAccount", arg0, arg1)
+	ret0, _ := ret[0].(*models.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSystemAccount indicates an expected call of GetSystemAccount.
+func (mr *MockLedgerRepositoryMockRecorder) GetSystemAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSystemAccount", reflect.TypeOf((*MockLedgerRepository)(nil).GetSystemAccount), arg0, arg1)
+}
+
+// Post mocks base method.
+func (m *MockLedgerRepository) Post(arg0 context.Context, arg1 *models.JournalEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Post", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Post indicates an expected call of Post.
+func (mr *MockLedgerRepositoryMockRecorder) Post(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockLedgerRepository)(nil).Post), arg0, arg1)
+}
@@ -0,0 +1,121 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/models (interfaces: MigrationRepository)
+
+// Package mock_models is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/da-semenov/gophermart/internal/app/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMigrationRepository is a mock of MigrationRepository interface.
+type MockMigrationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMigrationRepositoryMockRecorder
+}
+
+// MockMigrationRepositoryMockRecorder is the mock recorder for MockMigrationRepository.
+type MockMigrationRepositoryMockRecorder struct {
+	mock *MockMigrationRepository
+}
+
+// NewMockMigrationRepository creates a new mock instance.
+func NewMockMigrationRepository(ctrl *gomock.Controller) *MockMigrationRepository {
+	mock := &MockMigrationRepository{ctrl: ctrl}
+	mock.recorder = &MockMigrationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMigrationRepository) EXPECT() *MockMigrationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteApplied mocks base method.
+func (m *MockMigrationRepository) DeleteApplied(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApplied", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteApplied indicates an expected call of DeleteApplied.
+func (mr *MockMigrationRepositoryMockRecorder) DeleteApplied(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApplied", reflect.TypeOf((*MockMigrationRepository)(nil).DeleteApplied), arg0, arg1)
+}
+
+// Execute mocks base method.
+func (m *MockMigrationRepository) Execute(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Execute", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Execute indicates an expected call of Execute.
+func (mr *MockMigrationRepositoryMockRecorder) Execute(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockMigrationRepository)(nil).Execute), arg0, arg1)
+}
+
+// GetApplied mocks base method.
+func (m *MockMigrationRepository) GetApplied(arg0 context.Context) ([]models.AppliedMigration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplied", arg0)
+	ret0, _ := ret[0].([]models.AppliedMigration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplied indicates an expected call of GetApplied.
+func (mr *MockMigrationRepositoryMockRecorder) GetApplied(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplied", reflect.TypeOf((*MockMigrationRepository)(nil).GetApplied), arg0)
+}
+
+// List mocks base method.
+func (m *MockMigrationRepository) List() []models.Migration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]models.Migration)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockMigrationRepositoryMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockMigrationRepository)(nil).List))
+}
+
+// Lock mocks base method.
+func (m *MockMigrationRepository) Lock(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockMigrationRepositoryMockRecorder) Lock(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockMigrationRepository)(nil).Lock), arg0)
+}
+
+// SaveApplied mocks base method.
+func (m *MockMigrationRepository) SaveApplied(arg0 context.Context, arg1 *models.AppliedMigration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveApplied", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveApplied indicates an expected call of SaveApplied.
+func (mr *MockMigrationRepositoryMockRecorder) SaveApplied(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveApplied", reflect.TypeOf((*MockMigrationRepository)(nil).SaveApplied), arg0, arg1)
+}
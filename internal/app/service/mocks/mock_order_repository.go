@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/da-semenov/gophermart/internal/app/models"
 	gomock "github.com/golang/mock/gomock"
@@ -35,8 +36,38 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CountByUser mocks base method.
+func (m *MockOrderRepository) CountByUser(arg0 context.Context, arg1 models.OrderFilter) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByUser", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByUser indicates an expected call of CountByUser.
+func (mr *MockOrderRepositoryMockRecorder) CountByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByUser", reflect.TypeOf((*MockOrderRepository)(nil).CountByUser), arg0, arg1)
+}
+
+// FindByNums mocks base method.
+func (m *MockOrderRepository) FindByNums(arg0 context.Context, arg1 []string) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByNums", arg0, arg1)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByNums indicates an expected call of FindByNums.
+func (mr *MockOrderRepositoryMockRecorder) FindByNums(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByNums", reflect.TypeOf((*MockOrderRepository)(nil).FindByNums), arg0, arg1)
+}
+
 // FindByUser mocks base method.
-func (m *MockOrderRepository) FindByUser(arg0 context.Context, arg1 int) ([]models.Order, error) {
+func (m *MockOrderRepository) FindByUser(arg0 context.Context, arg1 models.OrderFilter) ([]models.Order, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindByUser", arg0, arg1)
 	ret0, _ := ret[0].([]models.Order)
@@ -51,18 +82,33 @@ func (mr *MockOrderRepositoryMockRecorder) FindByUser(arg0, arg1 interface{}) *g
 }
 
 // FindNotProcessed mocks base method.
-func (m *MockOrderRepository) FindNotProcessed(arg0 context.Context) ([]models.Order, error) {
+func (m *MockOrderRepository) FindNotProcessed(arg0 context.Context, arg1 string, arg2 time.Time, arg3 int) ([]models.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindNotProcessed", arg0)
+	ret := m.ctrl.Call(m, "FindNotProcessed", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]models.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindNotProcessed indicates an expected call of FindNotProcessed.
-func (mr *MockOrderRepositoryMockRecorder) FindNotProcessed(arg0 interface{}) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) FindNotProcessed(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindNotProcessed", reflect.TypeOf((*MockOrderRepository)(nil).FindNotProcessed), arg0, arg1, arg2, arg3)
+}
+
+// FindStuck mocks base method.
+func (m *MockOrderRepository) FindStuck(arg0 context.Context) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStuck", arg0)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStuck indicates an expected call of FindStuck.
+func (mr *MockOrderRepositoryMockRecorder) FindStuck(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindNotProcessed", reflect.TypeOf((*MockOrderRepository)(nil).FindNotProcessed), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStuck", reflect.TypeOf((*MockOrderRepository)(nil).FindStuck), arg0)
 }
 
 // GetByID mocks base method.
@@ -95,6 +141,36 @@ func (mr *MockOrderRepositoryMockRecorder) GetByNum(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByNum", reflect.TypeOf((*MockOrderRepository)(nil).GetByNum), arg0, arg1)
 }
 
+// GetStatusHistory mocks base method.
+func (m *MockOrderRepository) GetStatusHistory(arg0 context.Context, arg1 int) ([]models.OrderStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusHistory", arg0, arg1)
+	ret0, _ := ret[0].([]models.OrderStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusHistory indicates an expected call of GetStatusHistory.
+func (mr *MockOrderRepositoryMockRecorder) GetStatusHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusHistory", reflect.TypeOf((*MockOrderRepository)(nil).GetStatusHistory), arg0, arg1)
+}
+
+// GetUserOrder mocks base method.
+func (m *MockOrderRepository) GetUserOrder(arg0 context.Context, arg1 int, arg2 string) (*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrder indicates an expected call of GetUserOrder.
+func (mr *MockOrderRepositoryMockRecorder) GetUserOrder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrder", reflect.TypeOf((*MockOrderRepository)(nil).GetUserOrder), arg0, arg1, arg2)
+}
+
 // LockOrder mocks base method.
 func (m *MockOrderRepository) LockOrder(arg0 context.Context, arg1 string) (*models.Order, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +186,50 @@ func (mr *MockOrderRepositoryMockRecorder) LockOrder(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockOrder", reflect.TypeOf((*MockOrderRepository)(nil).LockOrder), arg0, arg1)
 }
 
+// MarkStuck mocks base method.
+func (m *MockOrderRepository) MarkStuck(arg0 context.Context, arg1 *models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkStuck", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkStuck indicates an expected call of MarkStuck.
+func (mr *MockOrderRepositoryMockRecorder) MarkStuck(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkStuck", reflect.TypeOf((*MockOrderRepository)(nil).MarkStuck), arg0, arg1)
+}
+
+// MarkStuckOlderThan mocks base method.
+func (m *MockOrderRepository) MarkStuckOlderThan(arg0 context.Context, arg1 time.Time, arg2 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkStuckOlderThan", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkStuckOlderThan indicates an expected call of MarkStuckOlderThan.
+func (mr *MockOrderRepositoryMockRecorder) MarkStuckOlderThan(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkStuckOlderThan", reflect.TypeOf((*MockOrderRepository)(nil).MarkStuckOlderThan), arg0, arg1, arg2)
+}
+
+// Revive mocks base method.
+func (m *MockOrderRepository) Revive(arg0 context.Context, arg1 string) (*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revive", arg0, arg1)
+	ret0, _ := ret[0].(*models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revive indicates an expected call of Revive.
+func (mr *MockOrderRepositoryMockRecorder) Revive(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revive", reflect.TypeOf((*MockOrderRepository)(nil).Revive), arg0, arg1)
+}
+
 // Save mocks base method.
 func (m *MockOrderRepository) Save(arg0 context.Context, arg1 *models.Order) error {
 	m.ctrl.T.Helper()
@@ -124,6 +244,35 @@ func (mr *MockOrderRepositoryMockRecorder) Save(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOrderRepository)(nil).Save), arg0, arg1)
 }
 
+// SaveBatch mocks base method.
+func (m *MockOrderRepository) SaveBatch(arg0 context.Context, arg1 []models.Order) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBatch", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveBatch indicates an expected call of SaveBatch.
+func (mr *MockOrderRepositoryMockRecorder) SaveBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBatch", reflect.TypeOf((*MockOrderRepository)(nil).SaveBatch), arg0, arg1)
+}
+
+// ScheduleAttempt mocks base method.
+func (m *MockOrderRepository) ScheduleAttempt(arg0 context.Context, arg1 *models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleAttempt", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleAttempt indicates an expected call of ScheduleAttempt.
+func (mr *MockOrderRepositoryMockRecorder) ScheduleAttempt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleAttempt", reflect.TypeOf((*MockOrderRepository)(nil).ScheduleAttempt), arg0, arg1)
+}
+
 // UpdateStatus mocks base method.
 func (m *MockOrderRepository) UpdateStatus(arg0 context.Context, arg1 *models.Order) error {
 	m.ctrl.T.Helper()
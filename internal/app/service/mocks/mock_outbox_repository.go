@@ -0,0 +1,138 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/models (interfaces: OutboxRepository)
+
+// Package mock_models is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/da-semenov/gophermart/internal/app/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeletePublishedBefore mocks base method.
+func (m *MockOutboxRepository) DeletePublishedBefore(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublishedBefore", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePublishedBefore indicates an expected call of DeletePublishedBefore.
+func (mr *MockOutboxRepositoryMockRecorder) DeletePublishedBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublishedBefore", reflect.TypeOf((*MockOutboxRepository)(nil).DeletePublishedBefore), arg0, arg1)
+}
+
+// FindByStatus mocks base method.
+func (m *MockOutboxRepository) FindByStatus(arg0 context.Context, arg1 string, arg2 int) ([]models.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByStatus indicates an expected call of FindByStatus.
+func (mr *MockOutboxRepositoryMockRecorder) FindByStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByStatus", reflect.TypeOf((*MockOutboxRepository)(nil).FindByStatus), arg0, arg1, arg2)
+}
+
+// FindUnpublished mocks base method.
+func (m *MockOutboxRepository) FindUnpublished(arg0 context.Context, arg1, arg2 int) ([]models.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUnpublished", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUnpublished indicates an expected call of FindUnpublished.
+func (mr *MockOutboxRepositoryMockRecorder) FindUnpublished(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUnpublished", reflect.TypeOf((*MockOutboxRepository)(nil).FindUnpublished), arg0, arg1, arg2)
+}
+
+// MarkPublished mocks base method.
+func (m *MockOutboxRepository) MarkPublished(arg0 context.Context, arg1 []int, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPublished", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPublished indicates an expected call of MarkPublished.
+func (mr *MockOutboxRepositoryMockRecorder) MarkPublished(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPublished", reflect.TypeOf((*MockOutboxRepository)(nil).MarkPublished), arg0, arg1, arg2)
+}
+
+// RecordFailure mocks base method.
+func (m *MockOutboxRepository) RecordFailure(arg0 context.Context, arg1 int, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockOutboxRepositoryMockRecorder) RecordFailure(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockOutboxRepository)(nil).RecordFailure), arg0, arg1, arg2, arg3)
+}
+
+// Retry mocks base method.
+func (m *MockOutboxRepository) Retry(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retry", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Retry indicates an expected call of Retry.
+func (mr *MockOutboxRepositoryMockRecorder) Retry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retry", reflect.TypeOf((*MockOutboxRepository)(nil).Retry), arg0, arg1)
+}
+
+// Save mocks base method.
+func (m *MockOutboxRepository) Save(arg0 context.Context, arg1 *models.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockOutboxRepositoryMockRecorder) Save(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOutboxRepository)(nil).Save), arg0, arg1)
+}
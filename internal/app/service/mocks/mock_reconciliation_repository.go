@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/models (interfaces: ReconciliationRepository)
+
+// Package mock_models is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/da-semenov/gophermart/internal/app/models"
+	money "github.com/da-semenov/gophermart/internal/app/money"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReconciliationRepository is a mock of ReconciliationRepository interface.
+type MockReconciliationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconciliationRepositoryMockRecorder
+}
+
+// MockReconciliationRepositoryMockRecorder is the mock recorder for MockReconciliationRepository.
+type MockReconciliationRepositoryMockRecorder struct {
+	mock *MockReconciliationRepository
+}
+
+// NewMockReconciliationRepository creates a new mock instance.
+func NewMockReconciliationRepository(ctrl *gomock.Controller) *MockReconciliationRepository {
+	mock := &MockReconciliationRepository{ctrl: ctrl}
+	mock.recorder = &MockReconciliationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciliationRepository) EXPECT() *MockReconciliationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindAccountDrifts mocks base method.
+func (m *MockReconciliationRepository) FindAccountDrifts(arg0 context.Context) ([]models.AccountTotals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAccountDrifts", arg0)
+	ret0, _ := ret[0].([]models.AccountTotals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAccountDrifts indicates an expected call of FindAccountDrifts.
+func (mr *MockReconciliationRepositoryMockRecorder) FindAccountDrifts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAccountDrifts", reflect.TypeOf((*MockReconciliationRepository)(nil).FindAccountDrifts), arg0)
+}
+
+// FindUnbalancedEntries mocks base method.
+func (m *MockReconciliationRepository) FindUnbalancedEntries(arg0 context.Context) ([]models.EntryTotals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUnbalancedEntries", arg0)
+	ret0, _ := ret[0].([]models.EntryTotals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUnbalancedEntries indicates an expected call of FindUnbalancedEntries.
+func (mr *MockReconciliationRepositoryMockRecorder) FindUnbalancedEntries(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUnbalancedEntries", reflect.TypeOf((*MockReconciliationRepository)(nil).FindUnbalancedEntries), arg0)
+}
+
+// LockAccountTotals mocks base method.
+func (m *MockReconciliationRepository) LockAccountTotals(arg0 context.Context, arg1 int) (*models.AccountTotals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockAccountTotals", arg0, arg1)
+	ret0, _ := ret[0].(*models.AccountTotals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockAccountTotals indicates an expected call of LockAccountTotals.
+func (mr *MockReconciliationRepositoryMockRecorder) LockAccountTotals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAccountTotals", reflect.TypeOf((*MockReconciliationRepository)(nil).LockAccountTotals), arg0, arg1)
+}
+
+// SyncAccount mocks base method.
+func (m *MockReconciliationRepository) SyncAccount(arg0 context.Context, arg1 int, arg2, arg3, arg4 money.Amount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncAccount", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncAccount indicates an expected call of SyncAccount.
+func (mr *MockReconciliationRepositoryMockRecorder) SyncAccount(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncAccount", reflect.TypeOf((*MockReconciliationRepository)(nil).SyncAccount), arg0, arg1, arg2, arg3, arg4)
+}
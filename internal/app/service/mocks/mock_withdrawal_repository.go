@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/da-semenov/gophermart/internal/app/models (interfaces: WithdrawalRepository)
+
+// Package mock_models is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/da-semenov/gophermart/internal/app/models"
+	money "github.com/da-semenov/gophermart/internal/app/money"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWithdrawalRepository is a mock of WithdrawalRepository interface.
+type MockWithdrawalRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWithdrawalRepositoryMockRecorder
+}
+
+// MockWithdrawalRepositoryMockRecorder is the mock recorder for MockWithdrawalRepository.
+type MockWithdrawalRepositoryMockRecorder struct {
+	mock *MockWithdrawalRepository
+}
+
+// NewMockWithdrawalRepository creates a new mock instance.
+func NewMockWithdrawalRepository(ctrl *gomock.Controller) *MockWithdrawalRepository {
+	mock := &MockWithdrawalRepository{ctrl: ctrl}
+	mock.recorder = &MockWithdrawalRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWithdrawalRepository) EXPECT() *MockWithdrawalRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWithdrawalRepository) Create(arg0 context.Context, arg1 *models.Withdrawal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWithdrawalRepositoryMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWithdrawalRepository)(nil).Create), arg0, arg1)
+}
+
+// FindByStatus mocks base method.
+func (m *MockWithdrawalRepository) FindByStatus(arg0 context.Context, arg1 string) ([]models.Withdrawal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByStatus", arg0, arg1)
+	ret0, _ := ret[0].([]models.Withdrawal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByStatus indicates an expected call of FindByStatus.
+func (mr *MockWithdrawalRepositoryMockRecorder) FindByStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByStatus", reflect.TypeOf((*MockWithdrawalRepository)(nil).FindByStatus), arg0, arg1)
+}
+
+// GetWithdrawnTotal mocks base method.
+func (m *MockWithdrawalRepository) GetWithdrawnTotal(arg0 context.Context, arg1 int) (money.Amount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawnTotal", arg0, arg1)
+	ret0, _ := ret[0].(money.Amount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawnTotal indicates an expected call of GetWithdrawnTotal.
+func (mr *MockWithdrawalRepositoryMockRecorder) GetWithdrawnTotal(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawnTotal", reflect.TypeOf((*MockWithdrawalRepository)(nil).GetWithdrawnTotal), arg0, arg1)
+}
+
+// Lock mocks base method.
+func (m *MockWithdrawalRepository) Lock(arg0 context.Context, arg1 int) (*models.Withdrawal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", arg0, arg1)
+	ret0, _ := ret[0].(*models.Withdrawal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockWithdrawalRepositoryMockRecorder) Lock(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockWithdrawalRepository)(nil).Lock), arg0, arg1)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockWithdrawalRepository) UpdateStatus(arg0 context.Context, arg1 *models.Withdrawal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockWithdrawalRepositoryMockRecorder) UpdateStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockWithdrawalRepository)(nil).UpdateStatus), arg0, arg1)
+}
@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/da-semenov/gophermart/internal/app/domain"
 	"github.com/da-semenov/gophermart/internal/app/infrastructure"
 	"github.com/da-semenov/gophermart/internal/app/models"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
@@ -33,11 +36,17 @@ func (s *OrderService) mapOrderDomainToModel(src *domain.Order) *models.Order {
 }
 
 func (s *OrderService) mapOrderModelToDomain(src *models.Order) *domain.Order {
+	status := src.Status
+	if status == models.OrderStatusStuck {
+		// stuck orders are an internal state, for the user they are still being processed
+		status = models.OrderStatusProcessing
+	}
 	return &domain.Order{
 		UserID:   src.UserID,
 		Num:      src.Num,
-		Status:   src.Status,
+		Status:   status,
 		Accrual:  src.Accrual,
+		Clawback: src.Clawback,
 		UploadAt: src.UploadAt.Truncate(time.Second),
 	}
 }
@@ -93,13 +102,167 @@ func (s *OrderService) Save(ctx context.Context, order *domain.Order) error {
 	return nil
 }
 
-func (s *OrderService) GetOrderList(ctx context.Context, userID int) ([]domain.Order, error) {
+const (
+	bulkUploadMaxOrders = 10000
+	bulkUploadBatchSize = 1000
+)
+
+// UploadOrders registers orders of a bulk upload. Every line gets its own result, bad or already uploaded numbers
+// don't prevent registering the others.
+func (s *OrderService) UploadOrders(ctx context.Context, userID int, lines []domain.OrderLine) (*domain.BulkUploadReport, error) {
+	if userID == 0 || len(lines) == 0 {
+		s.log.Debug("OrderService: UploadOrders. Validation error")
+		return nil, domain.ErrBadParam
+	}
+	if len(lines) > bulkUploadMaxOrders {
+		s.log.Debug("OrderService: UploadOrders. Too many orders", zap.Int("count", len(lines)))
+		return nil, domain.ErrTooManyOrders
+	}
+
+	report := domain.BulkUploadReport{Results: make([]domain.BulkOrderResult, len(lines))}
+	uploadAt := time.Now().Truncate(time.Microsecond)
+	seen := make(map[string]bool)
+	var (
+		nums   []string
+		orders []models.Order
+	)
+	for i, l := range lines {
+		report.Results[i] = domain.BulkOrderResult{Line: l.Line, Num: l.Num}
+		switch {
+		case l.Num == "" || (s.EnableValidation && !CheckOrderNum(l.Num)):
+			report.Results[i].Result = domain.BulkOrderInvalid
+		case seen[l.Num]:
+			report.Results[i].Result = domain.BulkOrderDuplicate
+		default:
+			seen[l.Num] = true
+			nums = append(nums, l.Num)
+			orders = append(orders, models.Order{UserID: userID, Num: l.Num, Status: models.OrderStatusNew, UploadAt: uploadAt, UpdatedAt: uploadAt})
+		}
+	}
+	created := make(map[string]bool)
+	for len(orders) > 0 {
+		batch := orders
+		if len(batch) > bulkUploadBatchSize {
+			batch = batch[:bulkUploadBatchSize]
+		}
+		createdNums, err := s.dbOrder.SaveBatch(ctx, batch)
+		if err != nil {
+			s.log.Error("OrderService: UploadOrders. Can't save orders", zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+		for _, num := range createdNums {
+			created[num] = true
+		}
+		orders = orders[len(batch):]
+	}
+
+	// numbers that were not created are already uploaded by this or another user
+	var skipped []string
+	for _, num := range nums {
+		if !created[num] {
+			skipped = append(skipped, num)
+		}
+	}
+	saved := make(map[string]models.Order)
+	if len(skipped) > 0 {
+		found, err := s.dbOrder.FindByNums(ctx, skipped)
+		if err != nil {
+			s.log.Error("OrderService: UploadOrders. Can't get saved orders", zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+		for _, o := range found {
+			saved[o.Num] = o
+		}
+	}
+	for i := range report.Results {
+		r := &report.Results[i]
+		if r.Result == "" {
+			o, ok := saved[r.Num]
+			switch {
+			case created[r.Num]:
+				r.Result = domain.BulkOrderAccepted
+			case !ok:
+				s.log.Error("OrderService: UploadOrders. Order is not saved", zap.String("num", r.Num))
+				return nil, fmt.Errorf("order %s is not saved", r.Num)
+			case o.UserID != userID:
+				r.Result = domain.BulkOrderOwnedByAnotherUser
+			default:
+				r.Result = domain.BulkOrderDuplicate
+			}
+		}
+		switch r.Result {
+		case domain.BulkOrderAccepted:
+			report.Accepted++
+		case domain.BulkOrderDuplicate:
+			report.Duplicate++
+		case domain.BulkOrderOwnedByAnotherUser:
+			report.OwnedByAnotherUser++
+		case domain.BulkOrderInvalid:
+			report.Invalid++
+		}
+	}
+	s.log.Info("OrderService: UploadOrders. Orders uploaded", zap.Int("userID", userID), zap.Int("accepted", report.Accepted), zap.Int("lines", len(lines)))
+	return &report, nil
+}
+
+// GetOrder returns the order of the user with its status history.
+func (s *OrderService) GetOrder(ctx context.Context, userID int, num string) (*domain.OrderDetail, error) {
+	if num == "" {
+		s.log.Debug("OrderService: GetOrder. Got empty order num")
+		return nil, domain.ErrBadParam
+	}
+	order, err := s.dbOrder.GetUserOrder(ctx, userID, num)
+	if errors.Is(err, &models.NoRowFound) {
+		return nil, domain.ErrOrderNotFound
+	}
+	if err != nil {
+		s.log.Error("OrderService: GetOrder. Can't get order", zap.String("num", num), zap.Error(err))
+		return nil, err
+	}
+	history, err := s.dbOrder.GetStatusHistory(ctx, order.ID)
+	if err != nil {
+		s.log.Error("OrderService: GetOrder. Can't get status history", zap.String("num", num), zap.Error(err))
+		return nil, err
+	}
+	res := domain.OrderDetail{Order: *s.mapOrderModelToDomain(order), History: []domain.OrderStatusChange{}}
+	for _, c := range history {
+		res.History = append(res.History, domain.OrderStatusChange{
+			Status:          c.Status,
+			AccrualResponse: c.AccrualResponse,
+			ChangedAt:       c.ChangedAt.Truncate(time.Second),
+		})
+	}
+	return &res, nil
+}
+
+const (
+	orderListDefaultLimit = 100
+	orderListMaxLimit     = 1000
+)
+
+// userOrderStatuses are statuses a user can filter orders by, stuck orders are shown as processing.
+var userOrderStatuses = map[string][]string{
+	models.OrderStatusNew:        {models.OrderStatusNew},
+	models.OrderStatusRegistered: {models.OrderStatusRegistered},
+	models.OrderStatusProcessing: {models.OrderStatusProcessing, models.OrderStatusStuck},
+	models.OrderStatusInvalid:    {models.OrderStatusInvalid},
+	models.OrderStatusProcessed:  {models.OrderStatusProcessed},
+}
+
+func (s *OrderService) GetOrderList(ctx context.Context, userID int, filter domain.OrderFilter) (*domain.OrderList, error) {
 	if userID == 0 {
 		s.log.Debug("OrderService: GetOrderList. Got nil userID")
 		return nil, domain.ErrBadParam
 	}
+	f, err := s.mapOrderFilterToModel(userID, filter)
+	if err != nil {
+		s.log.Debug("OrderService: GetOrderList. Bad filter", zap.Error(err))
+		return nil, err
+	}
 
-	orderList, err := s.dbOrder.FindByUser(ctx, userID)
+	// one extra row tells whether there is a next page
+	f.Limit++
+	orderList, err := s.dbOrder.FindByUser(ctx, f)
 	if err != nil {
 		s.log.Error("OrderService: GetOrderList. Can't get order list",
 			zap.Int("userID", userID),
@@ -107,7 +270,54 @@ func (s *OrderService) GetOrderList(ctx context.Context, userID int) ([]domain.O
 		)
 		return nil, err
 	}
+	var res domain.OrderList
+	if len(orderList) == f.Limit {
+		orderList = orderList[:len(orderList)-1]
+		last := orderList[len(orderList)-1]
+		res.NextCursor = encodeCursor(last.UploadAt, last.ID)
+	}
+	res.Total = len(orderList)
+	if res.NextCursor != "" || filter.Cursor != "" {
+		res.Total, err = s.dbOrder.CountByUser(ctx, f)
+		if err != nil {
+			s.log.Error("OrderService: GetOrderList. Can't count orders", zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+	}
+	res.Orders = s.mapOrderListModelToDomain(orderList)
+	return &res, nil
+}
 
-	resList := s.mapOrderListModelToDomain(orderList)
-	return resList, nil
+func (s *OrderService) mapOrderFilterToModel(userID int, filter domain.OrderFilter) (models.OrderFilter, error) {
+	f := models.OrderFilter{
+		UserID:       userID,
+		UploadedFrom: filter.UploadedFrom,
+		UploadedTo:   filter.UploadedTo,
+		Descending:   filter.Descending,
+		Limit:        filter.Limit,
+	}
+	if !f.UploadedFrom.IsZero() && !f.UploadedTo.IsZero() && !f.UploadedFrom.Before(f.UploadedTo) {
+		return f, fmt.Errorf("%w: empty upload period", domain.ErrBadParam)
+	}
+	if f.Limit == 0 {
+		f.Limit = orderListDefaultLimit
+	}
+	if f.Limit < 0 || f.Limit > orderListMaxLimit {
+		return f, fmt.Errorf("%w: limit %d", domain.ErrBadParam, f.Limit)
+	}
+	for _, status := range filter.Statuses {
+		statuses, ok := userOrderStatuses[strings.ToUpper(status)]
+		if !ok {
+			return f, fmt.Errorf("%w: unknown order status %q", domain.ErrBadParam, status)
+		}
+		f.Statuses = append(f.Statuses, statuses...)
+	}
+	if filter.Cursor != "" {
+		var err error
+		f.AfterUploadAt, f.AfterID, err = decodeCursor(filter.Cursor)
+		if err != nil {
+			return f, err
+		}
+	}
+	return f, nil
 }
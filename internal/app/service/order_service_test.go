@@ -5,10 +5,12 @@ import (
 	"errors"
 	"github.com/da-semenov/gophermart/internal/app/domain"
 	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
 	"github.com/da-semenov/gophermart/internal/app/service/mocks"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestOrderService_Save(t *testing.T) {
@@ -180,3 +182,143 @@ func TestOrderService_Save2(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderService_GetOrderList(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	target := NewOrderService(orderRepository, log, true)
+	uploadAt := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	filter := models.OrderFilter{
+		UserID:     1,
+		Statuses:   []string{models.OrderStatusProcessing, models.OrderStatusStuck},
+		Descending: true,
+		Limit:      3,
+	}
+	orderRepository.EXPECT().FindByUser(ctx, filter).Return([]models.Order{
+		{ID: 7, Num: "7", Status: models.OrderStatusStuck, UploadAt: uploadAt},
+		{ID: 5, Num: "5", Status: models.OrderStatusProcessing, UploadAt: uploadAt},
+		{ID: 3, Num: "3", Status: models.OrderStatusProcessing, UploadAt: uploadAt.Add(-time.Hour)},
+	}, nil)
+	orderRepository.EXPECT().CountByUser(ctx, filter).Return(3, nil)
+	page, err := target.GetOrderList(ctx, 1, domain.OrderFilter{Statuses: []string{"processing"}, Descending: true, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Orders, 2)
+	assert.Equal(t, models.OrderStatusProcessing, page.Orders[0].Status, "stuck orders are shown as processing")
+	assert.Equal(t, 3, page.Total)
+	assert.NotEmpty(t, page.NextCursor)
+
+	orderRepository.EXPECT().FindByUser(ctx, models.OrderFilter{UserID: 1, AfterUploadAt: uploadAt, AfterID: 5, Limit: orderListDefaultLimit + 1}).
+		Return([]models.Order{{ID: 3, Num: "3", Status: models.OrderStatusNew, UploadAt: uploadAt.Add(-time.Hour)}}, nil)
+	orderRepository.EXPECT().CountByUser(ctx, gomock.Any()).Return(3, nil)
+	page, err = target.GetOrderList(ctx, 1, domain.OrderFilter{Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, page.Orders, 1)
+	assert.Equal(t, 3, page.Total, "the total counts orders of all pages")
+	assert.Empty(t, page.NextCursor)
+
+	orderRepository.EXPECT().FindByUser(ctx, models.OrderFilter{UserID: 1, Limit: orderListDefaultLimit + 1}).
+		Return([]models.Order{{ID: 3, Num: "3", Status: models.OrderStatusNew}}, nil)
+	page, err = target.GetOrderList(ctx, 1, domain.OrderFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Total, "a single page is counted without a query")
+
+	for _, filter := range []domain.OrderFilter{
+		{Statuses: []string{"LOST"}},
+		{Limit: orderListMaxLimit + 1},
+		{UploadedFrom: uploadAt, UploadedTo: uploadAt},
+		{Cursor: "not a cursor"},
+	} {
+		_, err = target.GetOrderList(ctx, 1, filter)
+		assert.ErrorIs(t, err, domain.ErrBadParam)
+	}
+}
+
+func TestOrderService_GetOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	target := NewOrderService(orderRepository, log, true)
+	uploadAt := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	response := []byte(`{"order":"12345678903","status":"PROCESSED","accrual":500}`)
+
+	orderRepository.EXPECT().GetUserOrder(ctx, 1, "12345678903").Return(&models.Order{
+		ID: 3, UserID: 1, Num: "12345678903", Status: models.OrderStatusProcessed, Accrual: money.Rubles(500, 0), UploadAt: uploadAt,
+	}, nil)
+	orderRepository.EXPECT().GetStatusHistory(ctx, 3).Return([]models.OrderStatusChange{
+		{Status: models.OrderStatusNew, ChangedAt: uploadAt},
+		{Status: models.OrderStatusProcessed, AccrualResponse: response, ChangedAt: uploadAt.Add(time.Minute)},
+	}, nil)
+	order, err := target.GetOrder(ctx, 1, "12345678903")
+	assert.NoError(t, err)
+	assert.Equal(t, money.Rubles(500, 0), order.Accrual)
+	assert.Equal(t, []domain.OrderStatusChange{
+		{Status: models.OrderStatusNew, ChangedAt: uploadAt},
+		{Status: models.OrderStatusProcessed, AccrualResponse: response, ChangedAt: uploadAt.Add(time.Minute)},
+	}, order.History)
+
+	orderRepository.EXPECT().GetUserOrder(ctx, 1, "79927398713").Return(nil, &models.NoRowFound)
+	_, err = target.GetOrder(ctx, 1, "79927398713")
+	assert.ErrorIs(t, err, domain.ErrOrderNotFound, "orders of other users are not found")
+
+	_, err = target.GetOrder(ctx, 1, "")
+	assert.ErrorIs(t, err, domain.ErrBadParam)
+}
+
+func TestOrderService_UploadOrders(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	orderRepository := mocks.NewMockOrderRepository(mockCtrl)
+	target := NewOrderService(orderRepository, log, true)
+	uploadedEarlier := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	orderRepository.EXPECT().SaveBatch(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, orders []models.Order) ([]string, error) {
+		assert.Len(t, orders, 3, "invalid and repeated numbers are not saved")
+		for _, o := range orders {
+			assert.Equal(t, models.OrderStatusNew, o.Status)
+			assert.Equal(t, 1, o.UserID)
+		}
+		return []string{"12345678903"}, nil
+	})
+	orderRepository.EXPECT().FindByNums(ctx, []string{"79927398713", "4561261212345467"}).DoAndReturn(
+		func(ctx context.Context, nums []string) ([]models.Order, error) {
+			return []models.Order{
+				{UserID: 1, Num: "79927398713", UploadAt: uploadedEarlier},
+				{UserID: 2, Num: "4561261212345467", UploadAt: uploadedEarlier},
+			}, nil
+		})
+	report, err := target.UploadOrders(ctx, 1, []domain.OrderLine{
+		{Line: 1, Num: "12345678903"},
+		{Line: 2, Num: "12345678904"},
+		{Line: 3, Num: "79927398713"},
+		{Line: 4, Num: "12345678903"},
+		{Line: 5, Num: "4561261212345467"},
+		{Line: 6, Num: ""},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.BulkOrderResult{
+		{Line: 1, Num: "12345678903", Result: domain.BulkOrderAccepted},
+		{Line: 2, Num: "12345678904", Result: domain.BulkOrderInvalid},
+		{Line: 3, Num: "79927398713", Result: domain.BulkOrderDuplicate},
+		{Line: 4, Num: "12345678903", Result: domain.BulkOrderDuplicate},
+		{Line: 5, Num: "4561261212345467", Result: domain.BulkOrderOwnedByAnotherUser},
+		{Line: 6, Num: "", Result: domain.BulkOrderInvalid},
+	}, report.Results)
+	assert.Equal(t, 1, report.Accepted)
+	assert.Equal(t, 2, report.Duplicate)
+	assert.Equal(t, 1, report.OwnedByAnotherUser)
+	assert.Equal(t, 2, report.Invalid)
+
+	report, err = target.UploadOrders(ctx, 1, []domain.OrderLine{{Line: 1, Num: "bad"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Invalid, "nothing is saved if all numbers are invalid")
+
+	_, err = target.UploadOrders(ctx, 1, nil)
+	assert.ErrorIs(t, err, domain.ErrBadParam)
+	_, err = target.UploadOrders(ctx, 1, make([]domain.OrderLine, bulkUploadMaxOrders+1))
+	assert.ErrorIs(t, err, domain.ErrTooManyOrders)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	outboxBatchSize       = 100
+	outboxFailedListLimit = 100
+)
+
+// OutboxWriter records domain events in the transaction of ctx, the outbox relay publishes them after the commit.
+type OutboxWriter interface {
+	Write(ctx context.Context, aggregateType string, aggregateID string, userID int, eventType string, data interface{}) error
+}
+
+// OutboxSink receives published events. An event is handed to all sinks again until all of them accept it,
+// so sinks must skip events they already handled, e.g. by the event ID.
+type OutboxSink interface {
+	Name() string
+	Handle(ctx context.Context, event domain.OutboxEvent) error
+}
+
+// OutboxService keeps domain events in the outbox table and relays them to the sinks at least once.
+// The relay runs on a single replica, events of an aggregate are relayed in order: after a failure
+// the following events of the aggregate wait until the failed one is published. After maxAttempts failed
+// attempts the event is marked failed and the aggregate goes on, failed events can be retried manually.
+type OutboxService struct {
+	db          models.OutboxRepository
+	sinks       []OutboxSink
+	log         *infrastructure.Logger
+	retention   time.Duration
+	maxAttempts int
+	now         func() time.Time
+}
+
+func NewOutboxService(outboxRepo models.OutboxRepository, log *infrastructure.Logger, retention time.Duration, maxAttempts int,
+	sinks ...OutboxSink) *OutboxService {
+	var target OutboxService
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	target.db = outboxRepo
+	target.sinks = sinks
+	target.log = log
+	target.retention = retention
+	target.maxAttempts = maxAttempts
+	target.now = time.Now
+	return &target
+}
+
+func (s *OutboxService) Write(ctx context.Context, aggregateType string, aggregateID string, userID int, eventType string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		s.log.Error("OutboxService: Write. Can't serialize payload", zap.String("type", eventType), zap.Error(err))
+		return err
+	}
+	event := models.OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		UserID:        userID,
+		Type:          eventType,
+		Payload:       b,
+		CreatedAt:     s.now(),
+	}
+	if err = s.db.Save(ctx, &event); err != nil {
+		s.log.Error("OutboxService: Write. Can't save event", zap.String("type", eventType), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Relay hands unpublished events to the sinks and marks the accepted ones as published.
+func (s *OutboxService) Relay(ctx context.Context) error {
+	blocked := make(map[string]bool)
+	afterID := 0
+	for {
+		events, err := s.db.FindUnpublished(ctx, afterID, outboxBatchSize)
+		if err != nil {
+			s.log.Error("OutboxService: Relay. Can't get events", zap.Error(err))
+			return err
+		}
+		var published []int
+		for _, e := range events {
+			afterID = e.ID
+			aggregate := e.AggregateType + ":" + e.AggregateID
+			if blocked[aggregate] {
+				continue
+			}
+			if err = s.dispatch(ctx, e); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				status := models.OutboxEventPending
+				if e.AttemptCount+1 >= s.maxAttempts {
+					status = models.OutboxEventFailed
+				}
+				s.log.Warn("OutboxService: Relay. Can't publish event",
+					zap.Int("id", e.ID),
+					zap.String("aggregate", aggregate),
+					zap.Int("attempt", e.AttemptCount+1),
+					zap.String("status", status),
+					zap.Error(err),
+				)
+				if err = s.db.RecordFailure(ctx, e.ID, status, err.Error()); err != nil {
+					s.log.Error("OutboxService: Relay. Can't record failure", zap.Int("id", e.ID), zap.Error(err))
+					status = models.OutboxEventPending
+				}
+				// a failed event no longer holds the aggregate
+				blocked[aggregate] = status == models.OutboxEventPending
+				continue
+			}
+			published = append(published, e.ID)
+		}
+		if len(published) > 0 {
+			if err = s.db.MarkPublished(ctx, published, s.now()); err != nil {
+				s.log.Error("OutboxService: Relay. Can't mark events as published", zap.Error(err))
+				return err
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(events) < outboxBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *OutboxService) dispatch(ctx context.Context, event models.OutboxEvent) error {
+	e := domain.OutboxEvent{
+		ID:            event.ID,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		UserID:        event.UserID,
+		Type:          event.Type,
+		Data:          event.Payload,
+		CreatedAt:     event.CreatedAt,
+	}
+	for _, sink := range s.sinks {
+		if err := sink.Handle(ctx, e); err != nil {
+			return fmt.Errorf("sink %s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// GetFailedEvents returns the latest events the relay gave up on.
+func (s *OutboxService) GetFailedEvents(ctx context.Context) ([]domain.OutboxEventRecord, error) {
+	events, err := s.db.FindByStatus(ctx, models.OutboxEventFailed, outboxFailedListLimit)
+	if err != nil {
+		s.log.Error("OutboxService: GetFailedEvents. Can't get events", zap.Error(err))
+		return nil, err
+	}
+	res := make([]domain.OutboxEventRecord, 0, len(events))
+	for _, e := range events {
+		res = append(res, domain.OutboxEventRecord{
+			ID:            e.ID,
+			AggregateType: e.AggregateType,
+			AggregateID:   e.AggregateID,
+			UserID:        e.UserID,
+			Type:          e.Type,
+			Data:          e.Payload,
+			Status:        e.Status,
+			AttemptCount:  e.AttemptCount,
+			LastError:     e.LastError,
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+// RetryEvent returns the failed event to the relay. It is published after the events of its aggregate
+// that were published while it was failed.
+func (s *OutboxService) RetryEvent(ctx context.Context, id int) error {
+	err := s.db.Retry(ctx, id)
+	if errors.Is(err, &models.NoRowFound) {
+		return domain.ErrOutboxEventNotFound
+	}
+	if err != nil {
+		s.log.Error("OutboxService: RetryEvent. Can't retry event", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DeletePublished removes events published longer than the retention period ago.
+func (s *OutboxService) DeletePublished(ctx context.Context) error {
+	count, err := s.db.DeletePublishedBefore(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		s.log.Error("OutboxService: DeletePublished. Can't delete events", zap.Error(err))
+		return err
+	}
+	s.log.Debug("OutboxService: DeletePublished. Published events deleted", zap.Int("count", count))
+	return nil
+}
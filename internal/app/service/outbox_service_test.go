@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type outboxSinkStub struct {
+	fail    map[int]bool
+	handled []int
+}
+
+func (s *outboxSinkStub) Name() string {
+	return "stub"
+}
+
+func (s *outboxSinkStub) Handle(ctx context.Context, event domain.OutboxEvent) error {
+	if s.fail[event.ID] {
+		return errors.New("sink is down")
+	}
+	s.handled = append(s.handled, event.ID)
+	return nil
+}
+
+func TestOutboxService_Write(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	outboxRepository := mocks.NewMockOutboxRepository(mockCtrl)
+	target := NewOutboxService(outboxRepository, log, time.Hour, 3)
+	now := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	target.now = func() time.Time { return now }
+
+	outboxRepository.EXPECT().Save(ctx, &models.OutboxEvent{
+		AggregateType: domain.AggregateOrder, AggregateID: "12345678903", UserID: 1, Type: domain.OutboxAccrualPosted,
+		Payload: []byte(`{"order":"12345678903","sum":500.5}`), CreatedAt: now,
+	}).Return(nil)
+	err := target.Write(ctx, domain.AggregateOrder, "12345678903", 1, domain.OutboxAccrualPosted,
+		domain.AccrualEvent{Num: "12345678903", Amount: money.Rubles(500, 50)})
+	assert.NoError(t, err)
+
+	outboxRepository.EXPECT().DeletePublishedBefore(ctx, now.Add(-time.Hour)).Return(3, nil)
+	assert.NoError(t, target.DeletePublished(ctx))
+}
+
+func TestOutboxService_Relay(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	outboxRepository := mocks.NewMockOutboxRepository(mockCtrl)
+	first := &outboxSinkStub{}
+	second := &outboxSinkStub{fail: map[int]bool{1: true}}
+	target := NewOutboxService(outboxRepository, log, time.Hour, 3, first, second)
+
+	outboxRepository.EXPECT().FindUnpublished(ctx, 0, outboxBatchSize).Return([]models.OutboxEvent{
+		{ID: 1, AggregateType: domain.AggregateOrder, AggregateID: "1"},
+		{ID: 2, AggregateType: domain.AggregateOrder, AggregateID: "2"},
+		{ID: 3, AggregateType: domain.AggregateOrder, AggregateID: "1"},
+		{ID: 4, AggregateType: domain.AggregateWithdrawal, AggregateID: "1"},
+	}, nil)
+	outboxRepository.EXPECT().RecordFailure(ctx, 1, models.OutboxEventPending, "sink stub: sink is down").Return(nil)
+	outboxRepository.EXPECT().MarkPublished(ctx, []int{2, 4}, gomock.Any()).Return(nil)
+	assert.NoError(t, target.Relay(ctx))
+	assert.Equal(t, []int{1, 2, 4}, first.handled, "events after a failed one of the same aggregate must wait")
+	assert.Equal(t, []int{2, 4}, second.handled)
+}
+
+func TestOutboxService_Relay_Batches(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	outboxRepository := mocks.NewMockOutboxRepository(mockCtrl)
+	target := NewOutboxService(outboxRepository, log, time.Hour, 3, &outboxSinkStub{})
+
+	batch := make([]models.OutboxEvent, outboxBatchSize)
+	ids := make([]int, outboxBatchSize)
+	for i := range batch {
+		batch[i] = models.OutboxEvent{ID: i + 1, AggregateType: domain.AggregateOrder, AggregateID: "1"}
+		ids[i] = i + 1
+	}
+	outboxRepository.EXPECT().FindUnpublished(ctx, 0, outboxBatchSize).Return(batch, nil)
+	outboxRepository.EXPECT().MarkPublished(ctx, ids, gomock.Any()).Return(nil)
+	outboxRepository.EXPECT().FindUnpublished(ctx, outboxBatchSize, outboxBatchSize).Return(nil, nil)
+	assert.NoError(t, target.Relay(ctx))
+}
+
+func TestOutboxService_Relay_MaxAttempts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	outboxRepository := mocks.NewMockOutboxRepository(mockCtrl)
+	sink := &outboxSinkStub{fail: map[int]bool{1: true}}
+	target := NewOutboxService(outboxRepository, log, time.Hour, 3, sink)
+
+	outboxRepository.EXPECT().FindUnpublished(ctx, 0, outboxBatchSize).Return([]models.OutboxEvent{
+		{ID: 1, AggregateType: domain.AggregateOrder, AggregateID: "1", AttemptCount: 2},
+		{ID: 2, AggregateType: domain.AggregateOrder, AggregateID: "1"},
+	}, nil)
+	outboxRepository.EXPECT().RecordFailure(ctx, 1, models.OutboxEventFailed, "sink stub: sink is down").Return(nil)
+	outboxRepository.EXPECT().MarkPublished(ctx, []int{2}, gomock.Any()).Return(nil)
+	assert.NoError(t, target.Relay(ctx))
+	assert.Equal(t, []int{2}, sink.handled, "a failed event must not hold the aggregate")
+}
+
+func TestOutboxService_RetryEvent(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	outboxRepository := mocks.NewMockOutboxRepository(mockCtrl)
+	target := NewOutboxService(outboxRepository, log, time.Hour, 3)
+	createdAt := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	outboxRepository.EXPECT().FindByStatus(ctx, models.OutboxEventFailed, outboxFailedListLimit).Return([]models.OutboxEvent{
+		{ID: 1, AggregateType: domain.AggregateOrder, AggregateID: "1", UserID: 2, Type: domain.OutboxOrderStatusChanged,
+			Payload: []byte(`{}`), CreatedAt: createdAt, AttemptCount: 3, Status: models.OutboxEventFailed, LastError: "sink is down"},
+	}, nil)
+	res, err := target.GetFailedEvents(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.OutboxEventRecord{{ID: 1, AggregateType: domain.AggregateOrder, AggregateID: "1", UserID: 2,
+		Type: domain.OutboxOrderStatusChanged, Data: []byte(`{}`), Status: models.OutboxEventFailed, AttemptCount: 3,
+		LastError: "sink is down", CreatedAt: createdAt}}, res)
+
+	outboxRepository.EXPECT().Retry(ctx, 1).Return(nil)
+	assert.NoError(t, target.RetryEvent(ctx, 1))
+	outboxRepository.EXPECT().Retry(ctx, 2).Return(&models.NoRowFound)
+	assert.ErrorIs(t, target.RetryEvent(ctx, 2), domain.ErrOutboxEventNotFound)
+}
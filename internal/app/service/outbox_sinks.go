@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"go.uber.org/zap"
+)
+
+// EventPublisher adds events to the log of user event streams and wakes up the streams of this instance.
+// An outbox event is added once.
+type EventPublisher interface {
+	Publish(ctx context.Context, outboxEventID int, userID int, eventType string, payload interface{}) error
+	Notify(userID int)
+}
+
+// WebhookEmitter queues events for the webhooks of users, an outbox event is queued once.
+type WebhookEmitter interface {
+	Emit(ctx context.Context, outboxEventID int, userID int, eventType string, eventID string, data interface{}) error
+}
+
+// LogSink writes published events to the log.
+type LogSink struct {
+	log *infrastructure.Logger
+}
+
+func NewLogSink(log *infrastructure.Logger) *LogSink {
+	var target LogSink
+	target.log = log
+	return &target
+}
+
+func (s *LogSink) Name() string {
+	return domain.OutboxSinkLog
+}
+
+func (s *LogSink) Handle(ctx context.Context, event domain.OutboxEvent) error {
+	s.log.Info("LogSink: event published",
+		zap.Int("id", event.ID),
+		zap.String("aggregate", event.AggregateType+":"+event.AggregateID),
+		zap.String("type", event.Type),
+		zap.Int("userID", event.UserID),
+		zap.ByteString("data", event.Data),
+	)
+	return nil
+}
+
+// EventStreamSink sends order events to the event streams of users, the in-memory bus of this instance wakes up
+// the streams at once, streams of other instances find the events by polling.
+type EventStreamSink struct {
+	events EventPublisher
+}
+
+func NewEventStreamSink(events EventPublisher) *EventStreamSink {
+	var target EventStreamSink
+	target.events = events
+	return &target
+}
+
+func (s *EventStreamSink) Name() string {
+	return domain.OutboxSinkEvents
+}
+
+func (s *EventStreamSink) Handle(ctx context.Context, event domain.OutboxEvent) error {
+	var eventType string
+	switch event.Type {
+	case domain.OutboxOrderStatusChanged:
+		eventType = domain.EventOrderStatus
+	case domain.OutboxAccrualPosted:
+		eventType = domain.EventAccrual
+	default:
+		return nil
+	}
+	if err := s.events.Publish(ctx, event.ID, event.UserID, eventType, event.Data); err != nil {
+		return err
+	}
+	s.events.Notify(event.UserID)
+	return nil
+}
+
+// WebhookSink queues webhook deliveries of final order statuses and withdrawals.
+type WebhookSink struct {
+	webhooks WebhookEmitter
+}
+
+func NewWebhookSink(webhooks WebhookEmitter) *WebhookSink {
+	var target WebhookSink
+	target.webhooks = webhooks
+	return &target
+}
+
+func (s *WebhookSink) Name() string {
+	return domain.OutboxSinkWebhook
+}
+
+func (s *WebhookSink) Handle(ctx context.Context, event domain.OutboxEvent) error {
+	var eventType string
+	switch event.Type {
+	case domain.OutboxOrderStatusChanged:
+		var status domain.OrderStatusEvent
+		if err := json.Unmarshal(event.Data, &status); err != nil {
+			return err
+		}
+		switch status.Status {
+		case models.OrderStatusProcessed:
+			eventType = domain.WebhookOrderProcessed
+		case models.OrderStatusInvalid:
+			eventType = domain.WebhookOrderInvalid
+		default:
+			return nil
+		}
+	case domain.OutboxWithdrawalCreated:
+		eventType = domain.WebhookWithdrawalCreated
+	default:
+		return nil
+	}
+	return s.webhooks.Emit(ctx, event.ID, event.UserID, eventType, eventType+":"+event.AggregateID, event.Data)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type eventPublisherStub struct {
+	outboxEventIDs []int
+	types          []string
+	notified       []int
+}
+
+func (s *eventPublisherStub) Publish(ctx context.Context, outboxEventID int, userID int, eventType string, payload interface{}) error {
+	s.outboxEventIDs = append(s.outboxEventIDs, outboxEventID)
+	s.types = append(s.types, eventType)
+	return nil
+}
+
+func (s *eventPublisherStub) Notify(userID int) {
+	s.notified = append(s.notified, userID)
+}
+
+type webhookEmitterStub struct {
+	eventIDs []string
+}
+
+func (s *webhookEmitterStub) Emit(ctx context.Context, outboxEventID int, userID int, eventType string, eventID string, data interface{}) error {
+	s.eventIDs = append(s.eventIDs, eventID)
+	return nil
+}
+
+func TestEventStreamSink_Handle(t *testing.T) {
+	events := &eventPublisherStub{}
+	target := NewEventStreamSink(events)
+	ctx := context.Background()
+
+	assert.NoError(t, target.Handle(ctx, domain.OutboxEvent{ID: 1, UserID: 7, Type: domain.OutboxAccrualPosted, Data: json.RawMessage(`{}`)}))
+	assert.NoError(t, target.Handle(ctx, domain.OutboxEvent{ID: 2, UserID: 7, Type: domain.OutboxOrderStatusChanged, Data: json.RawMessage(`{}`)}))
+	assert.NoError(t, target.Handle(ctx, domain.OutboxEvent{ID: 3, UserID: 7, Type: domain.OutboxWithdrawalCreated, Data: json.RawMessage(`{}`)}))
+	assert.Equal(t, []int{1, 2}, events.outboxEventIDs, "the outbox event ID deduplicates user events")
+	assert.Equal(t, []string{domain.EventAccrual, domain.EventOrderStatus}, events.types)
+	assert.Equal(t, []int{7, 7}, events.notified)
+}
+
+func TestWebhookSink_Handle(t *testing.T) {
+	webhooks := &webhookEmitterStub{}
+	target := NewWebhookSink(webhooks)
+	ctx := context.Background()
+
+	for _, e := range []domain.OutboxEvent{
+		{AggregateID: "1", Type: domain.OutboxOrderStatusChanged, Data: json.RawMessage(`{"number":"1","status":"PROCESSING"}`)},
+		{AggregateID: "1", Type: domain.OutboxAccrualPosted, Data: json.RawMessage(`{"order":"1","sum":5}`)},
+		{AggregateID: "1", Type: domain.OutboxOrderStatusChanged, Data: json.RawMessage(`{"number":"1","status":"PROCESSED","accrual":5}`)},
+		{AggregateID: "2", Type: domain.OutboxOrderStatusChanged, Data: json.RawMessage(`{"number":"2","status":"INVALID"}`)},
+		{AggregateID: "3", Type: domain.OutboxWithdrawalCreated, Data: json.RawMessage(`{"order":"3","sum":1}`)},
+	} {
+		assert.NoError(t, target.Handle(ctx, e))
+	}
+	assert.Equal(t, []string{"order.processed:1", "order.invalid:2", "withdrawal.created:3"}, webhooks.eventIDs)
+}
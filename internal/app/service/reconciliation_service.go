@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ReconciliationService compares cached totals of user accounts with their postings.
+//
+// A repair keeps the balance the user has seen: the difference between the cached and the ledger balance is
+// posted as an ADJUSTMENT entry against the adjustments system account, after that debit and credit caches
+// are recomputed from postings.
+type ReconciliationService struct {
+	db     models.ReconciliationRepository
+	ledger *LedgerService
+	tx     basedbhandler.Transactioner
+	log    *infrastructure.Logger
+}
+
+func NewReconciliationService(repo models.ReconciliationRepository, ledger *LedgerService, tx basedbhandler.Transactioner,
+	log *infrastructure.Logger) *ReconciliationService {
+	var target ReconciliationService
+	target.db = repo
+	target.ledger = ledger
+	target.tx = tx
+	target.log = log
+	return &target
+}
+
+// Reconcile reports accounts out of sync with their postings and unbalanced journal entries.
+// If repair is set, mismatched accounts are repaired, unbalanced entries are only reported.
+func (s *ReconciliationService) Reconcile(ctx context.Context, repair bool) (*domain.ReconciliationReport, error) {
+	report := domain.ReconciliationReport{CheckedAt: time.Now()}
+	driftList, err := s.db.FindAccountDrifts(ctx)
+	if err != nil {
+		s.log.Error("ReconciliationService: Reconcile. Can't find account drifts", zap.Error(err))
+		return nil, err
+	}
+	for _, t := range driftList {
+		mismatch := s.mapTotalsToDomain(&t)
+		s.log.Warn("ReconciliationService: Reconcile. Account out of sync",
+			zap.Int("accountID", t.AccountID),
+			zap.Int("userID", t.UserID),
+			zap.Stringer("balance", t.Balance),
+			zap.Stringer("ledgerBalance", t.LedgerBalance()),
+			zap.Stringer("debit", t.Debit),
+			zap.Stringer("ledgerDebit", t.LedgerDebit),
+			zap.Stringer("credit", t.Credit),
+			zap.Stringer("ledgerCredit", t.LedgerCredit))
+		if repair {
+			entryID, err := s.repairAccount(ctx, t.AccountID)
+			if err != nil {
+				s.log.Error("ReconciliationService: Reconcile. Can't repair account", zap.Int("accountID", t.AccountID), zap.Error(err))
+				return nil, err
+			}
+			mismatch.Repaired = true
+			mismatch.AdjustmentEntryID = entryID
+		}
+		report.Accounts = append(report.Accounts, mismatch)
+	}
+
+	entryList, err := s.db.FindUnbalancedEntries(ctx)
+	if err != nil {
+		s.log.Error("ReconciliationService: Reconcile. Can't find unbalanced entries", zap.Error(err))
+		return nil, err
+	}
+	for _, e := range entryList {
+		s.log.Error("ReconciliationService: Reconcile. Unbalanced journal entry",
+			zap.Int("entryID", e.EntryID), zap.Stringer("debit", e.Debit), zap.Stringer("credit", e.Credit))
+		report.UnbalancedEntries = append(report.UnbalancedEntries, domain.UnbalancedEntry{EntryID: e.EntryID, Debit: e.Debit, Credit: e.Credit})
+	}
+	s.log.Info("ReconciliationService: Reconcile. Done",
+		zap.Int("accounts", len(report.Accounts)), zap.Int("unbalancedEntries", len(report.UnbalancedEntries)), zap.Bool("repair", repair))
+	return &report, nil
+}
+
+// repairAccount returns the ID of the posted adjustment entry, 0 if no entry was needed.
+func (s *ReconciliationService) repairAccount(ctx context.Context, accountID int) (int, error) {
+	var entryID int
+	err := inTransaction(ctx, s.tx, func(ctx context.Context) error {
+		t, err := s.db.LockAccountTotals(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		if t.InSync() {
+			return nil
+		}
+		if diff := t.Balance - t.LedgerBalance(); diff != 0 {
+			adjustmentsID, err := s.ledger.SystemAccountID(ctx, models.SystemAccountAdjustments)
+			if err != nil {
+				return err
+			}
+			transfer := LedgerTransfer{
+				EntryType:   models.EntryAdjustment,
+				Description: fmt.Sprintf("reconciliation: cached balance %s, ledger balance %s", t.Balance, t.LedgerBalance()),
+				From:        adjustmentsID,
+				To:          accountID,
+				Amount:      diff,
+			}
+			if diff < 0 {
+				transfer.From, transfer.To, transfer.Amount = accountID, adjustmentsID, -diff
+			}
+			entry, err := s.ledger.Transfer(ctx, transfer)
+			if err != nil {
+				return err
+			}
+			entryID = entry.ID
+			if t, err = s.db.LockAccountTotals(ctx, accountID); err != nil {
+				return err
+			}
+		}
+		return s.db.SyncAccount(ctx, accountID, t.LedgerBalance(), t.LedgerDebit, t.LedgerCredit)
+	})
+	if errors.Is(err, &models.NoRowFound) {
+		return 0, fmt.Errorf("account %d not found: %w", accountID, err)
+	}
+	return entryID, err
+}
+
+func (s *ReconciliationService) mapTotalsToDomain(src *models.AccountTotals) domain.AccountMismatch {
+	return domain.AccountMismatch{
+		AccountID:     src.AccountID,
+		UserID:        src.UserID,
+		Balance:       src.Balance,
+		Debit:         src.Debit,
+		Credit:        src.Credit,
+		LedgerBalance: src.LedgerBalance(),
+		LedgerDebit:   src.LedgerDebit,
+		LedgerCredit:  src.LedgerCredit,
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReconciliationService_Reconcile(t *testing.T) {
+	tests := []struct {
+		name      string
+		totals    models.AccountTotals
+		repair    bool
+		from      int
+		to        int
+		amount    money.Amount
+		synced    models.AccountTotals
+		wantEntry int
+	}{
+		{
+			name:   "ReconciliationService. Reconcile. Test 1. Report only",
+			totals: models.AccountTotals{AccountID: 3, UserID: 7, Balance: money.Rubles(100, 0), Credit: money.Rubles(100, 0), LedgerCredit: money.Rubles(80, 0)},
+			repair: false,
+		},
+		{
+			name:      "ReconciliationService. Reconcile. Test 2. Cached balance above ledger balance",
+			totals:    models.AccountTotals{AccountID: 3, UserID: 7, Balance: money.Rubles(100, 0), Credit: money.Rubles(100, 0), LedgerCredit: money.Rubles(80, 0)},
+			repair:    true,
+			from:      100,
+			to:        3,
+			amount:    money.Rubles(20, 0),
+			synced:    models.AccountTotals{AccountID: 3, UserID: 7, Balance: money.Rubles(120, 0), Credit: money.Rubles(120, 0), LedgerCredit: money.Rubles(100, 0)},
+			wantEntry: 42,
+		},
+		{
+			name:      "ReconciliationService. Reconcile. Test 3. Cached balance below ledger balance",
+			totals:    models.AccountTotals{AccountID: 3, UserID: 7, Balance: money.Rubles(50, 0), Credit: money.Rubles(80, 0), LedgerCredit: money.Rubles(80, 0), Debit: money.Rubles(30, 0), LedgerDebit: money.Rubles(20, 0)},
+			repair:    true,
+			from:      3,
+			to:        100,
+			amount:    money.Rubles(10, 0),
+			synced:    models.AccountTotals{AccountID: 3, UserID: 7, Balance: money.Rubles(40, 0), Credit: money.Rubles(80, 0), LedgerCredit: money.Rubles(80, 0), Debit: money.Rubles(40, 0), LedgerDebit: money.Rubles(30, 0)},
+			wantEntry: 42,
+		},
+		{
+			name:   "ReconciliationService. Reconcile. Test 4. Only debit and credit caches differ",
+			totals: models.AccountTotals{AccountID: 3, UserID: 7, Balance: money.Rubles(50, 0), Credit: money.Rubles(90, 0), LedgerCredit: money.Rubles(80, 0), Debit: money.Rubles(40, 0), LedgerDebit: money.Rubles(30, 0)},
+			repair: true,
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	reconciliationRepository := mocks.NewMockReconciliationRepository(mockCtrl)
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	ledgerRepository.EXPECT().GetSystemAccount(gomock.Any(), models.SystemAccountAdjustments).Return(&models.Account{ID: 100}, nil).AnyTimes()
+	target := NewReconciliationService(reconciliationRepository, NewLedgerService(ledgerRepository, log), &transactionerStub{}, log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciliationRepository.EXPECT().FindAccountDrifts(ctx).Return([]models.AccountTotals{tt.totals}, nil)
+			reconciliationRepository.EXPECT().FindUnbalancedEntries(ctx).Return(nil, nil)
+			expected := tt.totals
+			if tt.repair {
+				first := reconciliationRepository.EXPECT().LockAccountTotals(gomock.Any(), 3).Return(&tt.totals, nil)
+				if tt.amount != 0 {
+					ledgerRepository.EXPECT().Post(gomock.Any(), gomock.Any()).DoAndReturn(
+						func(ctx context.Context, entry *models.JournalEntry) error {
+							assert.Equal(t, models.EntryAdjustment, entry.EntryType)
+							for _, op := range entry.Operations {
+								assert.Equal(t, tt.amount, op.Amount)
+								if op.OperationType == models.OperationDebit {
+									assert.Equal(t, tt.from, op.AccountID)
+								} else {
+									assert.Equal(t, tt.to, op.AccountID)
+								}
+							}
+							entry.ID = 42
+							return nil
+						},
+					)
+					reconciliationRepository.EXPECT().LockAccountTotals(gomock.Any(), 3).Return(&tt.synced, nil).After(first)
+					expected = tt.synced
+				}
+				reconciliationRepository.EXPECT().SyncAccount(gomock.Any(), 3, expected.LedgerBalance(), expected.LedgerDebit, expected.LedgerCredit).Return(nil)
+			}
+			report, err := target.Reconcile(ctx, tt.repair)
+			assert.NoError(t, err)
+			if assert.Len(t, report.Accounts, 1) {
+				assert.Equal(t, tt.totals.LedgerBalance(), report.Accounts[0].LedgerBalance)
+				assert.Equal(t, tt.repair, report.Accounts[0].Repaired)
+				assert.Equal(t, tt.wantEntry, report.Accounts[0].AdjustmentEntryID)
+			}
+			if tt.repair && tt.amount != 0 {
+				assert.Equal(t, tt.totals.Balance, expected.LedgerBalance(), "the balance seen by the user must be kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/repository/basedbhandler"
+)
+
+// inTransaction runs fn in a new transaction stored in the context the same way the
+// Transactional middleware does, so repositories pick it up transparently.
+func inTransaction(ctx context.Context, h basedbhandler.Transactioner, fn func(ctx context.Context) error) error {
+	tx, err := h.NewTx(ctx)
+	if err != nil {
+		return err
+	}
+	txCtx := context.WithValue(ctx, basedbhandler.TransactionKey("tx"), tx)
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback(ctx)
+			panic(r)
+		}
+	}()
+	if err = fn(txCtx); err != nil {
+		_ = h.Rollback(txCtx)
+		return err
+	}
+	return h.Commit(txCtx)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"go.uber.org/zap"
+	"time"
+)
+
+// TransferLimits restrict transfers of a single user, zero disables the limit. Daily limits reset at midnight UTC.
+type TransferLimits struct {
+	MaxAmount  money.Amount
+	DailyLimit money.Amount
+}
+
+// TransferService moves points between users, e.g. to pool points of a family.
+type TransferService struct {
+	dbBalance models.BalanceRepository
+	dbUser    models.UserRepository
+	ledger    *LedgerService
+	log       *infrastructure.Logger
+	limits    TransferLimits
+	now       func() time.Time
+}
+
+func NewTransferService(balanceRepo models.BalanceRepository, userRepo models.UserRepository, ledger *LedgerService,
+	log *infrastructure.Logger, limits TransferLimits) *TransferService {
+	var target TransferService
+	target.dbBalance = balanceRepo
+	target.dbUser = userRepo
+	target.ledger = ledger
+	target.log = log
+	target.limits = limits
+	target.now = time.Now
+	return &target
+}
+
+// Transfer must run in a transaction. Both accounts are locked in the order of their IDs,
+// so opposite transfers between the same users can't deadlock.
+func (s *TransferService) Transfer(ctx context.Context, obj *domain.Transfer, userID int) error {
+	if userID == 0 || obj == nil || obj.Login == "" || obj.Amount <= 0 {
+		s.log.Debug("TransferService: Transfer. Bad params", zap.Int("userID", userID))
+		return domain.ErrBadParam
+	}
+	if s.limits.MaxAmount > 0 && obj.Amount > s.limits.MaxAmount {
+		s.log.Debug("TransferService: Transfer. Amount exceeds the per-transfer limit", zap.Stringer("amount", obj.Amount))
+		return domain.ErrTransferLimitExceeded
+	}
+	recipient, err := s.dbUser.GetUserByLogin(ctx, obj.Login)
+	if err != nil && !errors.Is(err, &models.NoRowFound) {
+		s.log.Error("TransferService: Transfer. Can't get recipient", zap.Error(err))
+		return err
+	}
+	if recipient == nil {
+		s.log.Debug("TransferService: Transfer. Recipient not found", zap.String("login", obj.Login))
+		return domain.ErrRecipientNotFound
+	}
+	if recipient.ID == userID {
+		s.log.Debug("TransferService: Transfer. Transfer to oneself", zap.Int("userID", userID))
+		return domain.ErrBadParam
+	}
+
+	from, err := s.dbBalance.GetAccount(ctx, userID)
+	if err != nil {
+		s.log.Error("TransferService: Transfer. Can't get sender account", zap.Error(err))
+		return err
+	}
+	to, err := s.dbBalance.GetAccount(ctx, recipient.ID)
+	if err != nil {
+		s.log.Error("TransferService: Transfer. Can't get recipient account", zap.Error(err))
+		return err
+	}
+	lockOrder := []int{userID, recipient.ID}
+	if to.ID < from.ID {
+		lockOrder[0], lockOrder[1] = lockOrder[1], lockOrder[0]
+	}
+	for _, id := range lockOrder {
+		account, err := s.dbBalance.LockAccount(ctx, id)
+		if err != nil {
+			s.log.Error("TransferService: Transfer. Can't lock account", zap.Int("userID", id), zap.Error(err))
+			return err
+		}
+		if id == userID {
+			from = account
+		}
+	}
+
+	if from.Balance < obj.Amount {
+		s.log.Debug("TransferService: Transfer. In account not enough funds")
+		return domain.ErrNotEnoughFunds
+	}
+	if s.limits.DailyLimit > 0 {
+		dayStart := s.now().UTC().Truncate(24 * time.Hour)
+		transferred, err := s.dbBalance.GetTransferredTotal(ctx, from.ID, dayStart)
+		if err != nil {
+			s.log.Error("TransferService: Transfer. Can't get transferred total", zap.Error(err))
+			return err
+		}
+		if transferred+obj.Amount > s.limits.DailyLimit {
+			s.log.Debug("TransferService: Transfer. Daily limit exceeded",
+				zap.Stringer("transferred", transferred), zap.Stringer("amount", obj.Amount))
+			return domain.ErrTransferLimitExceeded
+		}
+	}
+	_, err = s.ledger.Transfer(ctx, LedgerTransfer{
+		EntryType:   models.EntryTransfer,
+		Description: fmt.Sprintf("transfer from user %d to user %d", userID, recipient.ID),
+		From:        from.ID,
+		To:          to.ID,
+		Amount:      obj.Amount,
+	})
+	if err != nil {
+		s.log.Error("TransferService: Transfer. Can't post transfer", zap.Error(err))
+		return err
+	}
+	s.log.Info("TransferService: Transfer. Points transferred", zap.Int("from", userID), zap.Int("to", recipient.ID), zap.Stringer("amount", obj.Amount))
+	return nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTransferService_Transfer(t *testing.T) {
+	type args struct {
+		login       string
+		amount      money.Amount
+		balance     money.Amount
+		transferred money.Amount
+		fromAccount int
+		toAccount   int
+	}
+	type wants struct {
+		lockOrder []int
+		error     error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name:  "TransferService. Transfer. Test 1. Positive",
+			args:  args{login: "wife", amount: money.Rubles(100, 0), balance: money.Rubles(500, 0), fromAccount: 3, toAccount: 8},
+			wants: wants{lockOrder: []int{1, 2}},
+		},
+		{
+			name:  "TransferService. Transfer. Test 2. Recipient account locked first",
+			args:  args{login: "wife", amount: money.Rubles(100, 0), balance: money.Rubles(500, 0), fromAccount: 8, toAccount: 3},
+			wants: wants{lockOrder: []int{2, 1}},
+		},
+		{
+			name:  "TransferService. Transfer. Test 3. Not enough funds",
+			args:  args{login: "wife", amount: money.Rubles(100, 0), balance: money.Rubles(99, 99), fromAccount: 3, toAccount: 8},
+			wants: wants{lockOrder: []int{1, 2}, error: domain.ErrNotEnoughFunds},
+		},
+		{
+			name:  "TransferService. Transfer. Test 4. Daily limit exceeded",
+			args:  args{login: "wife", amount: money.Rubles(100, 0), balance: money.Rubles(500, 0), transferred: money.Rubles(950, 0), fromAccount: 3, toAccount: 8},
+			wants: wants{lockOrder: []int{1, 2}, error: domain.ErrTransferLimitExceeded},
+		},
+		{
+			name:  "TransferService. Transfer. Test 5. Per-transfer limit exceeded",
+			args:  args{login: "wife", amount: money.Rubles(600, 0)},
+			wants: wants{error: domain.ErrTransferLimitExceeded},
+		},
+		{
+			name:  "TransferService. Transfer. Test 6. Recipient not found",
+			args:  args{login: "nobody", amount: money.Rubles(100, 0)},
+			wants: wants{error: domain.ErrRecipientNotFound},
+		},
+		{
+			name:  "TransferService. Transfer. Test 7. Transfer to oneself",
+			args:  args{login: "me", amount: money.Rubles(100, 0)},
+			wants: wants{error: domain.ErrBadParam},
+		},
+		{
+			name:  "TransferService. Transfer. Test 8. Non-positive amount",
+			args:  args{login: "wife", amount: 0},
+			wants: wants{error: domain.ErrBadParam},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	balanceRepository := mocks.NewMockBalanceRepository(mockCtrl)
+	userRepository := mocks.NewMockUserRepository(mockCtrl)
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	target := NewTransferService(balanceRepository, userRepository, NewLedgerService(ledgerRepository, log), log,
+		TransferLimits{MaxAmount: money.Rubles(500, 0), DailyLimit: money.Rubles(1000, 0)})
+	target.now = func() time.Time { return time.Date(2022, 5, 1, 15, 30, 0, 0, time.UTC) }
+	userRepository.EXPECT().GetUserByLogin(ctx, "wife").Return(&models.User{ID: 2, Login: "wife"}, nil).AnyTimes()
+	userRepository.EXPECT().GetUserByLogin(ctx, "me").Return(&models.User{ID: 1, Login: "me"}, nil).AnyTimes()
+	userRepository.EXPECT().GetUserByLogin(ctx, "nobody").Return(nil, &models.NoRowFound).AnyTimes()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wants.lockOrder != nil {
+				from := &models.Account{ID: tt.args.fromAccount, UserID: 1, Balance: tt.args.balance}
+				to := &models.Account{ID: tt.args.toAccount, UserID: 2}
+				balanceRepository.EXPECT().GetAccount(ctx, 1).Return(from, nil)
+				balanceRepository.EXPECT().GetAccount(ctx, 2).Return(to, nil)
+				accounts := map[int]*models.Account{1: from, 2: to}
+				var locked []int
+				balanceRepository.EXPECT().LockAccount(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, userID int) (*models.Account, error) {
+						locked = append(locked, userID)
+						return accounts[userID], nil
+					},
+				).Times(2)
+				defer func() {
+					assert.Equal(t, tt.wants.lockOrder, locked, "accounts must be locked in the order of their IDs")
+				}()
+				if tt.wants.error != domain.ErrNotEnoughFunds {
+					balanceRepository.EXPECT().GetTransferredTotal(ctx, tt.args.fromAccount, time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)).
+						Return(tt.args.transferred, nil)
+				}
+				if tt.wants.error == nil {
+					ledgerRepository.EXPECT().Post(ctx, gomock.Any()).DoAndReturn(
+						func(ctx context.Context, entry *models.JournalEntry) error {
+							assert.Equal(t, models.EntryTransfer, entry.EntryType)
+							for _, op := range entry.Operations {
+								assert.Equal(t, tt.args.amount, op.Amount)
+								if op.OperationType == models.OperationDebit {
+									assert.Equal(t, tt.args.fromAccount, op.AccountID)
+								} else {
+									assert.Equal(t, tt.args.toAccount, op.AccountID)
+								}
+							}
+							return nil
+						},
+					)
+				}
+			}
+			err := target.Transfer(ctx, &domain.Transfer{Login: tt.args.login, Amount: tt.args.amount}, 1)
+			assert.ErrorIs(t, err, tt.wants.error)
+		})
+	}
+}
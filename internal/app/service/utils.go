@@ -1,5 +1,7 @@
 package service
 
+import "time"
+
 func CheckOrderNum(orderNum string) bool {
 	var (
 		number int
@@ -22,3 +24,20 @@ func CheckOrderNum(orderNum string) bool {
 
 	return (check*9)%10 == 0
 }
+
+// retryDelay returns the exponential backoff delay for the given attempt (starting from 1) capped by max.
+// Jitter spreads the delay over [delay/2, delay) so that failed orders don't retry in lockstep.
+func retryDelay(attempt int, base time.Duration, max time.Duration, jitter func() float64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	half := delay / 2
+	return half + time.Duration(jitter()*float64(delay-half))
+}
@@ -1,6 +1,9 @@
 package service
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestCheckOrderNum(t *testing.T) {
 	type args struct {
@@ -45,3 +48,49 @@ func TestCheckOrderNum(t *testing.T) {
 		})
 	}
 }
+
+func TestRetryDelay(t *testing.T) {
+	type args struct {
+		attempt int
+		jitter  float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want time.Duration
+	}{
+		{
+			name: "AccrualService. retryDelay. Test 1. First attempt without jitter",
+			args: args{attempt: 1, jitter: 0},
+			want: 500 * time.Millisecond,
+		},
+		{
+			name: "AccrualService. retryDelay. Test 2. First attempt with full jitter",
+			args: args{attempt: 1, jitter: 1},
+			want: time.Second,
+		},
+		{
+			name: "AccrualService. retryDelay. Test 3. Exponential growth",
+			args: args{attempt: 4, jitter: 1},
+			want: 8 * time.Second,
+		},
+		{
+			name: "AccrualService. retryDelay. Test 4. Capped by max",
+			args: args{attempt: 100, jitter: 1},
+			want: time.Minute,
+		},
+		{
+			name: "AccrualService. retryDelay. Test 5. Zero attempt",
+			args: args{attempt: 0, jitter: 1},
+			want: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jitter := func() float64 { return tt.args.jitter }
+			if got := retryDelay(tt.args.attempt, time.Second, time.Minute, jitter); got != tt.want {
+				t.Errorf("retryDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"go.uber.org/zap"
+	mathrand "math/rand"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	webhookSecretMinLength = 16
+	webhookDeliveriesLimit = 100
+	webhookBatchSize       = 20
+)
+
+// Headers of webhook requests. The signature is the hex HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret.
+const (
+	WebhookEventHeader     = "X-Gophermart-Event"
+	WebhookDeliveryHeader  = "X-Gophermart-Delivery"
+	WebhookTimestampHeader = "X-Gophermart-Timestamp"
+	WebhookSignatureHeader = "X-Gophermart-Signature"
+)
+
+var webhookEventTypes = map[string]bool{
+	domain.WebhookOrderProcessed:    true,
+	domain.WebhookOrderInvalid:      true,
+	domain.WebhookWithdrawalCreated: true,
+}
+
+// WebhookSender posts the body to the webhook and returns the response status code.
+type WebhookSender interface {
+	Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error)
+}
+
+type WebhookConfig struct {
+	// MaxAttempts failed attempts mark a delivery as failed, it can be redelivered manually.
+	MaxAttempts int
+	RetryBase   time.Duration
+	RetryMax    time.Duration
+}
+
+type WebhookService struct {
+	db     models.WebhookRepository
+	sender WebhookSender
+	log    *infrastructure.Logger
+	config WebhookConfig
+	now    func() time.Time
+}
+
+func NewWebhookService(webhookRepo models.WebhookRepository, sender WebhookSender, log *infrastructure.Logger, config WebhookConfig) *WebhookService {
+	var target WebhookService
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	if config.RetryBase <= 0 {
+		config.RetryBase = time.Second
+	}
+	if config.RetryMax < config.RetryBase {
+		config.RetryMax = config.RetryBase
+	}
+	target.db = webhookRepo
+	target.sender = sender
+	target.log = log
+	target.config = config
+	target.now = time.Now
+	return &target
+}
+
+// CreateWebhook registers the webhook, a secret is generated if not given.
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID int, webhook *domain.Webhook) (*domain.Webhook, error) {
+	if webhook == nil {
+		return nil, domain.ErrBadParam
+	}
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		s.log.Debug("WebhookService: CreateWebhook. Bad url", zap.String("url", webhook.URL))
+		return nil, fmt.Errorf("%w: bad webhook url %q", domain.ErrBadParam, webhook.URL)
+	}
+	// the client checks resolved addresses, literal internal hosts are rejected early
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); host == "localhost" || strings.HasSuffix(host, ".localhost") || (ip != nil && !infrastructure.PublicIP(ip)) {
+		s.log.Debug("WebhookService: CreateWebhook. Internal url", zap.String("url", webhook.URL))
+		return nil, fmt.Errorf("%w: webhook url %q points to an internal address", domain.ErrBadParam, webhook.URL)
+	}
+	eventTypes := []string{}
+	for _, t := range webhook.EventTypes {
+		t = strings.ToLower(t)
+		if !webhookEventTypes[t] {
+			return nil, fmt.Errorf("%w: unknown event type %q", domain.ErrBadParam, t)
+		}
+		eventTypes = append(eventTypes, t)
+	}
+	secret := webhook.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err = rand.Read(b); err != nil {
+			s.log.Error("WebhookService: CreateWebhook. Can't generate secret", zap.Error(err))
+			return nil, err
+		}
+		secret = hex.EncodeToString(b)
+	}
+	if len(secret) < webhookSecretMinLength {
+		return nil, fmt.Errorf("%w: webhook secret is shorter than %d", domain.ErrBadParam, webhookSecretMinLength)
+	}
+	model := models.Webhook{UserID: userID, URL: webhook.URL, Secret: secret, EventTypes: eventTypes, CreatedAt: s.now().Truncate(time.Second)}
+	if err = s.db.Create(ctx, &model); err != nil {
+		s.log.Error("WebhookService: CreateWebhook. Can't create webhook", zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	res := mapWebhookModelToDomain(&model)
+	res.Secret = secret
+	return res, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, userID int) ([]domain.Webhook, error) {
+	webhooks, err := s.db.FindByUser(ctx, userID)
+	if err != nil {
+		s.log.Error("WebhookService: GetWebhooks. Can't get webhooks", zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	resList := []domain.Webhook{}
+	for _, w := range webhooks {
+		w := w
+		resList = append(resList, *mapWebhookModelToDomain(&w))
+	}
+	return resList, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID int, id int) error {
+	err := s.db.Delete(ctx, userID, id)
+	if errors.Is(err, &models.NoRowFound) {
+		return domain.ErrWebhookNotFound
+	}
+	if err != nil {
+		s.log.Error("WebhookService: DeleteWebhook. Can't delete webhook", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of the webhook from the newest one.
+func (s *WebhookService) GetDeliveries(ctx context.Context, userID int, webhookID int) ([]domain.WebhookDelivery, error) {
+	_, err := s.db.Get(ctx, userID, webhookID)
+	if errors.Is(err, &models.NoRowFound) {
+		return nil, domain.ErrWebhookNotFound
+	}
+	if err != nil {
+		s.log.Error("WebhookService: GetDeliveries. Can't get webhook", zap.Int("id", webhookID), zap.Error(err))
+		return nil, err
+	}
+	deliveries, err := s.db.FindDeliveries(ctx, userID, webhookID, webhookDeliveriesLimit)
+	if err != nil {
+		s.log.Error("WebhookService: GetDeliveries. Can't get deliveries", zap.Int("id", webhookID), zap.Error(err))
+		return nil, err
+	}
+	resList := []domain.WebhookDelivery{}
+	for _, d := range deliveries {
+		d := d
+		resList = append(resList, *mapWebhookDeliveryModelToDomain(&d))
+	}
+	return resList, nil
+}
+
+// Redeliver sends the event of the delivery to the webhook once more as a new delivery.
+func (s *WebhookService) Redeliver(ctx context.Context, userID int, webhookID int, deliveryID int) (*domain.WebhookDelivery, error) {
+	delivery, err := s.db.GetDelivery(ctx, userID, webhookID, deliveryID)
+	if errors.Is(err, &models.NoRowFound) {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		s.log.Error("WebhookService: Redeliver. Can't get delivery", zap.Int("id", deliveryID), zap.Error(err))
+		return nil, err
+	}
+	redelivery := models.WebhookDelivery{
+		WebhookID: delivery.WebhookID,
+		UserID:    delivery.UserID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Payload:   delivery.Payload,
+		Status:    models.WebhookDeliveryPending,
+		CreatedAt: s.now(),
+	}
+	if err = s.db.CreateDelivery(ctx, &redelivery); err != nil {
+		s.log.Error("WebhookService: Redeliver. Can't create delivery", zap.Int("id", deliveryID), zap.Error(err))
+		return nil, err
+	}
+	return mapWebhookDeliveryModelToDomain(&redelivery), nil
+}
+
+// Emit queues the event for the webhooks of the user subscribed to it, once per outbox event.
+// eventID identifies the event, e.g. the order it is about.
+func (s *WebhookService) Emit(ctx context.Context, outboxEventID int, userID int, eventType string, eventID string, data interface{}) error {
+	now := s.now()
+	payload, err := json.Marshal(domain.WebhookPayload{ID: eventID, Type: eventType, CreatedAt: now.Truncate(time.Second), Data: data})
+	if err != nil {
+		s.log.Error("WebhookService: Emit. Can't serialize payload", zap.String("eventID", eventID), zap.Error(err))
+		return err
+	}
+	_, err = s.db.Enqueue(ctx, userID, &models.WebhookDelivery{
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        models.WebhookDeliveryPending,
+		CreatedAt:     now,
+		OutboxEventID: outboxEventID,
+	})
+	if err != nil {
+		s.log.Error("WebhookService: Emit. Can't enqueue deliveries", zap.String("eventID", eventID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DeliverPending sends due deliveries, failed ones are retried with backoff.
+func (s *WebhookService) DeliverPending(ctx context.Context) error {
+	for ctx.Err() == nil {
+		deliveries, err := s.db.FindDueDeliveries(ctx, s.now(), webhookBatchSize)
+		if err != nil {
+			s.log.Error("WebhookService: DeliverPending. Can't get deliveries", zap.Error(err))
+			return err
+		}
+		for i := range deliveries {
+			if err = s.deliver(ctx, &deliveries[i]); err != nil {
+				return err
+			}
+		}
+		if len(deliveries) < webhookBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	headers := map[string]string{
+		"Content-Type":         "application/json",
+		WebhookEventHeader:     delivery.EventType,
+		WebhookDeliveryHeader:  delivery.EventID,
+		WebhookTimestampHeader: timestamp,
+		WebhookSignatureHeader: "sha256=" + SignWebhook(delivery.Secret, timestamp, delivery.Payload),
+	}
+	code, err := s.sender.Send(ctx, delivery.URL, headers, delivery.Payload)
+	delivery.AttemptCount++
+	delivery.ResponseCode = code
+	delivery.LastError = ""
+	delivery.UpdatedAt = s.now()
+	switch {
+	case err == nil && code >= 200 && code < 300:
+		delivery.Status = models.WebhookDeliveryDelivered
+	case err != nil && ctx.Err() != nil:
+		// stopped, the delivery is sent again later
+		return ctx.Err()
+	default:
+		if err != nil {
+			delivery.LastError = err.Error()
+		} else {
+			delivery.LastError = fmt.Sprintf("unexpected response status %d", code)
+		}
+		if delivery.AttemptCount >= s.config.MaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+		} else {
+			delivery.NextAttemptAt = s.now().Add(retryDelay(delivery.AttemptCount, s.config.RetryBase, s.config.RetryMax, mathrand.Float64))
+		}
+	}
+	if err = s.db.UpdateDelivery(ctx, delivery); err != nil {
+		s.log.Error("WebhookService: deliver. Can't save delivery", zap.Int("id", delivery.ID), zap.Error(err))
+		return err
+	}
+	s.log.Debug("WebhookService: deliver. Delivery attempt",
+		zap.Int("id", delivery.ID),
+		zap.String("status", delivery.Status),
+		zap.Int("responseCode", code),
+		zap.String("error", delivery.LastError),
+	)
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 signature of the webhook body sent at the timestamp.
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func mapWebhookModelToDomain(src *models.Webhook) *domain.Webhook {
+	eventTypes := src.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return &domain.Webhook{
+		ID:         src.ID,
+		URL:        src.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  src.CreatedAt,
+	}
+}
+
+func mapWebhookDeliveryModelToDomain(src *models.WebhookDelivery) *domain.WebhookDelivery {
+	res := domain.WebhookDelivery{
+		ID:           src.ID,
+		EventID:      src.EventID,
+		EventType:    src.EventType,
+		Status:       src.Status,
+		AttemptCount: src.AttemptCount,
+		ResponseCode: src.ResponseCode,
+		LastError:    src.LastError,
+		CreatedAt:    src.CreatedAt.Truncate(time.Second),
+		UpdatedAt:    src.UpdatedAt.Truncate(time.Second),
+	}
+	if src.Status == models.WebhookDeliveryPending {
+		nextAttemptAt := src.NextAttemptAt.Truncate(time.Second)
+		res.NextAttemptAt = &nextAttemptAt
+	}
+	return &res
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type webhookSenderStub struct {
+	codes   []int
+	err     error
+	headers []map[string]string
+	bodies  [][]byte
+}
+
+func (s *webhookSenderStub) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	s.headers = append(s.headers, headers)
+	s.bodies = append(s.bodies, body)
+	if s.err != nil {
+		return 0, s.err
+	}
+	code := s.codes[0]
+	s.codes = s.codes[1:]
+	return code, nil
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook domain.Webhook
+		wantErr error
+	}{
+		{
+			name:    "WebhookService. CreateWebhook. Test 1. Positive",
+			webhook: domain.Webhook{URL: "https://example.com/hook", EventTypes: []string{domain.WebhookOrderProcessed}},
+		},
+		{
+			name:    "WebhookService. CreateWebhook. Test 2. Bad url",
+			webhook: domain.Webhook{URL: "ftp://example.com/hook"},
+			wantErr: domain.ErrBadParam,
+		},
+		{
+			name:    "WebhookService. CreateWebhook. Test 3. Unknown event type",
+			webhook: domain.Webhook{URL: "https://example.com/hook", EventTypes: []string{"order.created"}},
+			wantErr: domain.ErrBadParam,
+		},
+		{
+			name:    "WebhookService. CreateWebhook. Test 4. Short secret",
+			webhook: domain.Webhook{URL: "https://example.com/hook", Secret: "secret"},
+			wantErr: domain.ErrBadParam,
+		},
+		{
+			name:    "WebhookService. CreateWebhook. Test 5. Loopback address",
+			webhook: domain.Webhook{URL: "http://127.0.0.1:8080/hook"},
+			wantErr: domain.ErrBadParam,
+		},
+		{
+			name:    "WebhookService. CreateWebhook. Test 6. Private address",
+			webhook: domain.Webhook{URL: "http://10.0.0.5/hook"},
+			wantErr: domain.ErrBadParam,
+		},
+		{
+			name:    "WebhookService. CreateWebhook. Test 7. Localhost",
+			webhook: domain.Webhook{URL: "http://localhost/hook"},
+			wantErr: domain.ErrBadParam,
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	webhookRepository := mocks.NewMockWebhookRepository(mockCtrl)
+	target := NewWebhookService(webhookRepository, nil, log, WebhookConfig{MaxAttempts: 3})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				webhookRepository.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, webhook *models.Webhook) error {
+						assert.Equal(t, 1, webhook.UserID)
+						assert.Len(t, webhook.Secret, 64, "the secret must be generated")
+						webhook.ID = 5
+						return nil
+					},
+				)
+			}
+			res, err := target.CreateWebhook(ctx, 1, &tt.webhook)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, 5, res.ID)
+				assert.NotEmpty(t, res.Secret, "the secret is returned on creation")
+			}
+		})
+	}
+}
+
+func TestWebhookService_DeliverPending(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	webhookRepository := mocks.NewMockWebhookRepository(mockCtrl)
+	sender := &webhookSenderStub{codes: []int{200, 500}}
+	target := NewWebhookService(webhookRepository, sender, log, WebhookConfig{MaxAttempts: 3, RetryBase: time.Minute, RetryMax: time.Hour})
+	now := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	target.now = func() time.Time { return now }
+
+	payload := []byte(`{"id":"order.processed:1"}`)
+	webhookRepository.EXPECT().FindDueDeliveries(ctx, now, webhookBatchSize).Return([]models.WebhookDelivery{
+		{ID: 1, EventID: "order.processed:1", EventType: domain.WebhookOrderProcessed, Payload: payload, Status: models.WebhookDeliveryPending, Secret: "0123456789abcdef"},
+		{ID: 2, EventID: "order.processed:2", EventType: domain.WebhookOrderProcessed, Payload: payload, Status: models.WebhookDeliveryPending, AttemptCount: 1},
+	}, nil)
+	webhookRepository.EXPECT().UpdateDelivery(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, delivery *models.WebhookDelivery) error {
+			assert.Equal(t, models.WebhookDeliveryDelivered, delivery.Status)
+			assert.Equal(t, 1, delivery.AttemptCount)
+			assert.Equal(t, 200, delivery.ResponseCode)
+			return nil
+		},
+	)
+	webhookRepository.EXPECT().UpdateDelivery(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, delivery *models.WebhookDelivery) error {
+			assert.Equal(t, models.WebhookDeliveryPending, delivery.Status, "the delivery must be retried")
+			assert.Equal(t, 2, delivery.AttemptCount)
+			assert.Equal(t, "unexpected response status 500", delivery.LastError)
+			assert.True(t, delivery.NextAttemptAt.After(now), "the next attempt must be delayed")
+			return nil
+		},
+	)
+	assert.NoError(t, target.DeliverPending(ctx))
+
+	headers := sender.headers[0]
+	assert.Equal(t, "order.processed:1", headers[WebhookDeliveryHeader])
+	assert.Equal(t, "1623758400", headers[WebhookTimestampHeader])
+	assert.Equal(t, "sha256="+SignWebhook("0123456789abcdef", "1623758400", payload), headers[WebhookSignatureHeader])
+	assert.NotEqual(t, SignWebhook("another secret", "1623758400", payload), SignWebhook("0123456789abcdef", "1623758400", payload))
+}
+
+func TestWebhookService_DeliverPending_Failed(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	webhookRepository := mocks.NewMockWebhookRepository(mockCtrl)
+	sender := &webhookSenderStub{err: errors.New("connection refused")}
+	target := NewWebhookService(webhookRepository, sender, log, WebhookConfig{MaxAttempts: 3})
+
+	webhookRepository.EXPECT().FindDueDeliveries(ctx, gomock.Any(), webhookBatchSize).Return([]models.WebhookDelivery{
+		{ID: 1, Status: models.WebhookDeliveryPending, AttemptCount: 2},
+	}, nil)
+	webhookRepository.EXPECT().UpdateDelivery(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, delivery *models.WebhookDelivery) error {
+			assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status, "exhausted deliveries must be failed")
+			assert.Equal(t, "connection refused", delivery.LastError)
+			return nil
+		},
+	)
+	assert.NoError(t, target.DeliverPending(ctx))
+}
+
+func TestWebhookService_Redeliver(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	webhookRepository := mocks.NewMockWebhookRepository(mockCtrl)
+	target := NewWebhookService(webhookRepository, nil, log, WebhookConfig{})
+
+	webhookRepository.EXPECT().GetDelivery(ctx, 1, 2, 3).Return(&models.WebhookDelivery{
+		ID: 3, WebhookID: 2, UserID: 1, EventID: "order.invalid:1", EventType: domain.WebhookOrderInvalid,
+		Payload: []byte(`{}`), Status: models.WebhookDeliveryFailed, AttemptCount: 10,
+	}, nil)
+	webhookRepository.EXPECT().CreateDelivery(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, delivery *models.WebhookDelivery) error {
+			assert.Equal(t, "order.invalid:1", delivery.EventID, "the event id must be kept")
+			assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+			assert.Equal(t, 0, delivery.AttemptCount)
+			delivery.ID = 4
+			return nil
+		},
+	)
+	res, err := target.Redeliver(ctx, 1, 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, res.ID)
+
+	webhookRepository.EXPECT().GetDelivery(ctx, 1, 2, 5).Return(nil, &models.NoRowFound)
+	_, err = target.Redeliver(ctx, 1, 2, 5)
+	assert.ErrorIs(t, err, domain.ErrWebhookDeliveryNotFound)
+}
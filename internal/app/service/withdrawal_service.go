@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/infrastructure"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"go.uber.org/zap"
+)
+
+// withdrawalTransition describes where the held or withdrawn amount goes when a withdrawal changes its status.
+type withdrawalTransition struct {
+	from      string
+	to        string
+	entryType string
+	// debited is the system account the amount leaves, it goes to credited or to the user account if credited is empty.
+	debited  string
+	credited string
+}
+
+var (
+	confirmTransition = withdrawalTransition{
+		from:      models.WithdrawalStatusPending,
+		to:        models.WithdrawalStatusConfirmed,
+		entryType: models.EntrySettlement,
+		debited:   models.SystemAccountHolds,
+		credited:  models.SystemAccountWithdrawals,
+	}
+	rejectTransition = withdrawalTransition{
+		from:      models.WithdrawalStatusPending,
+		to:        models.WithdrawalStatusRejected,
+		entryType: models.EntryReversal,
+		debited:   models.SystemAccountHolds,
+	}
+	reverseTransition = withdrawalTransition{
+		from:      models.WithdrawalStatusConfirmed,
+		to:        models.WithdrawalStatusReversed,
+		entryType: models.EntryReversal,
+		debited:   models.SystemAccountWithdrawals,
+	}
+)
+
+// WithdrawalService moves withdrawals through their lifecycle on behalf of the payment side.
+type WithdrawalService struct {
+	db     models.WithdrawalRepository
+	ledger *LedgerService
+	log    *infrastructure.Logger
+}
+
+func NewWithdrawalService(repo models.WithdrawalRepository, ledger *LedgerService, log *infrastructure.Logger) *WithdrawalService {
+	var target WithdrawalService
+	target.db = repo
+	target.ledger = ledger
+	target.log = log
+	return &target
+}
+
+func (s *WithdrawalService) mapWithdrawalModelToDomain(src *models.Withdrawal) domain.WithdrawalRecord {
+	return domain.WithdrawalRecord{
+		ID:          src.ID,
+		UserID:      src.UserID,
+		OrderNum:    src.OrderNum,
+		Amount:      src.Amount,
+		Status:      src.Status,
+		Reason:      src.Reason,
+		ProcessedAt: src.ProcessedAt,
+		UpdatedAt:   src.UpdatedAt,
+	}
+}
+
+func (s *WithdrawalService) GetWithdrawals(ctx context.Context, status string) ([]domain.WithdrawalRecord, error) {
+	switch status {
+	case "", models.WithdrawalStatusPending, models.WithdrawalStatusConfirmed, models.WithdrawalStatusRejected, models.WithdrawalStatusReversed:
+	default:
+		s.log.Debug("WithdrawalService: GetWithdrawals. Unknown status", zap.String("status", status))
+		return nil, domain.ErrBadParam
+	}
+	withdrawalList, err := s.db.FindByStatus(ctx, status)
+	if err != nil {
+		s.log.Error("WithdrawalService: GetWithdrawals. Can't get withdrawal list", zap.Error(err))
+		return nil, err
+	}
+	resList := make([]domain.WithdrawalRecord, 0, len(withdrawalList))
+	for i := range withdrawalList {
+		resList = append(resList, s.mapWithdrawalModelToDomain(&withdrawalList[i]))
+	}
+	return resList, nil
+}
+
+// ConfirmWithdrawal settles the held amount.
+func (s *WithdrawalService) ConfirmWithdrawal(ctx context.Context, id int) (*domain.WithdrawalRecord, error) {
+	return s.move(ctx, id, confirmTransition, "")
+}
+
+// RejectWithdrawal returns the held amount to the user.
+func (s *WithdrawalService) RejectWithdrawal(ctx context.Context, id int, reason string) (*domain.WithdrawalRecord, error) {
+	return s.move(ctx, id, rejectTransition, reason)
+}
+
+// ReverseWithdrawal returns the amount of a confirmed withdrawal to the user.
+func (s *WithdrawalService) ReverseWithdrawal(ctx context.Context, id int, reason string) (*domain.WithdrawalRecord, error) {
+	return s.move(ctx, id, reverseTransition, reason)
+}
+
+// move must run in a transaction. Repeating a transition that is already done is not an error,
+// so the payment side can safely retry its calls.
+func (s *WithdrawalService) move(ctx context.Context, id int, t withdrawalTransition, reason string) (*domain.WithdrawalRecord, error) {
+	w, err := s.db.Lock(ctx, id)
+	if errors.Is(err, &models.NoRowFound) {
+		s.log.Debug("WithdrawalService: move. Withdrawal not found", zap.Int("id", id))
+		return nil, domain.ErrWithdrawalNotFound
+	}
+	if err != nil {
+		s.log.Error("WithdrawalService: move. Can't lock withdrawal", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	if w.Status == t.to {
+		res := s.mapWithdrawalModelToDomain(w)
+		return &res, nil
+	}
+	if w.Status != t.from {
+		s.log.Debug("WithdrawalService: move. Transition is not allowed", zap.Int("id", id), zap.String("status", w.Status), zap.String("to", t.to))
+		return nil, domain.ErrWithdrawalState
+	}
+
+	from, err := s.ledger.SystemAccountID(ctx, t.debited)
+	if err != nil {
+		return nil, err
+	}
+	// points returned to the user keep the expiration of the lots the withdrawal took them from
+	to, restores := w.AccountID, w.EntryID
+	if t.credited != "" {
+		if to, err = s.ledger.SystemAccountID(ctx, t.credited); err != nil {
+			return nil, err
+		}
+		restores = 0
+	}
+	entry, err := s.ledger.Transfer(ctx, LedgerTransfer{
+		EntryType:       t.entryType,
+		EntryKey:        withdrawalEventKey(t.entryType, w.ID),
+		OrderNum:        w.OrderNum,
+		Description:     reason,
+		From:            from,
+		To:              to,
+		Amount:          w.Amount,
+		RestoresEntryID: restores,
+	})
+	if err != nil {
+		s.log.Error("WithdrawalService: move. Can't post entry", zap.Int("id", id), zap.String("to", t.to), zap.Error(err))
+		return nil, err
+	}
+	w.Status = t.to
+	w.Reason = reason
+	w.UpdatedAt = entry.CreatedAt
+	if err = s.db.UpdateStatus(ctx, w); err != nil {
+		s.log.Error("WithdrawalService: move. Can't update withdrawal", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	s.log.Info("WithdrawalService: move. Withdrawal status changed", zap.Int("id", id), zap.String("status", w.Status))
+	res := s.mapWithdrawalModelToDomain(w)
+	return &res, nil
+}
+
+func withdrawalEventKey(entryType string, withdrawalID int) string {
+	return fmt.Sprintf("%s:%s:%d", entryType, models.EntryWithdrawal, withdrawalID)
+}
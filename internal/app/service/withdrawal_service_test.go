@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/da-semenov/gophermart/internal/app/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWithdrawalService_Move(t *testing.T) {
+	const (
+		userAccount        = 3
+		holdsAccount       = 100
+		withdrawalsAccount = 101
+	)
+	type args struct {
+		status string
+		action string
+	}
+	type wants struct {
+		status   string
+		from     int
+		to       int
+		entryKey string
+		restores int
+		error    error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name:  "WithdrawalService. Move. Test 1. Confirm pending withdrawal",
+			args:  args{status: models.WithdrawalStatusPending, action: "confirm"},
+			wants: wants{status: models.WithdrawalStatusConfirmed, from: holdsAccount, to: withdrawalsAccount, entryKey: "SETTLEMENT:WITHDRAWAL:7"},
+		},
+		{
+			name:  "WithdrawalService. Move. Test 2. Reject pending withdrawal",
+			args:  args{status: models.WithdrawalStatusPending, action: "reject"},
+			wants: wants{status: models.WithdrawalStatusRejected, from: holdsAccount, to: userAccount, entryKey: "REVERSAL:WITHDRAWAL:7", restores: 42},
+		},
+		{
+			name:  "WithdrawalService. Move. Test 3. Reverse confirmed withdrawal",
+			args:  args{status: models.WithdrawalStatusConfirmed, action: "reverse"},
+			wants: wants{status: models.WithdrawalStatusReversed, from: withdrawalsAccount, to: userAccount, entryKey: "REVERSAL:WITHDRAWAL:7", restores: 42},
+		},
+		{
+			name:  "WithdrawalService. Move. Test 4. Repeated confirmation",
+			args:  args{status: models.WithdrawalStatusConfirmed, action: "confirm"},
+			wants: wants{status: models.WithdrawalStatusConfirmed},
+		},
+		{
+			name:  "WithdrawalService. Move. Test 5. Reverse pending withdrawal",
+			args:  args{status: models.WithdrawalStatusPending, action: "reverse"},
+			wants: wants{error: domain.ErrWithdrawalState},
+		},
+		{
+			name:  "WithdrawalService. Move. Test 6. Confirm rejected withdrawal",
+			args:  args{status: models.WithdrawalStatusRejected, action: "confirm"},
+			wants: wants{error: domain.ErrWithdrawalState},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	withdrawalRepository := mocks.NewMockWithdrawalRepository(mockCtrl)
+	ledgerRepository := mocks.NewMockLedgerRepository(mockCtrl)
+	ledgerRepository.EXPECT().GetSystemAccount(ctx, models.SystemAccountHolds).Return(&models.Account{ID: holdsAccount}, nil).AnyTimes()
+	ledgerRepository.EXPECT().GetSystemAccount(ctx, models.SystemAccountWithdrawals).Return(&models.Account{ID: withdrawalsAccount}, nil).AnyTimes()
+	target := NewWithdrawalService(withdrawalRepository, NewLedgerService(ledgerRepository, log), log)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withdrawal := &models.Withdrawal{ID: 7, UserID: 1, AccountID: userAccount, OrderNum: "12345678903", Amount: money.Rubles(50, 0), Status: tt.args.status, EntryID: 42}
+			withdrawalRepository.EXPECT().Lock(ctx, 7).Return(withdrawal, nil)
+			if tt.wants.from != 0 {
+				ledgerRepository.EXPECT().Post(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, entry *models.JournalEntry) error {
+						assert.Equal(t, tt.wants.entryKey, entry.EntryKey)
+						assert.Equal(t, tt.wants.restores, entry.RestoresEntryID)
+						for _, op := range entry.Operations {
+							assert.Equal(t, money.Rubles(50, 0), op.Amount)
+							if op.OperationType == models.OperationDebit {
+								assert.Equal(t, tt.wants.from, op.AccountID)
+							} else {
+								assert.Equal(t, tt.wants.to, op.AccountID)
+							}
+						}
+						return nil
+					},
+				)
+				withdrawalRepository.EXPECT().UpdateStatus(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, w *models.Withdrawal) error {
+						assert.Equal(t, tt.wants.status, w.Status)
+						return nil
+					},
+				)
+			}
+			var (
+				res *domain.WithdrawalRecord
+				err error
+			)
+			switch tt.args.action {
+			case "confirm":
+				res, err = target.ConfirmWithdrawal(ctx, 7)
+			case "reject":
+				res, err = target.RejectWithdrawal(ctx, 7, "payment declined")
+			case "reverse":
+				res, err = target.ReverseWithdrawal(ctx, 7, "order cancelled")
+			}
+			assert.ErrorIs(t, err, tt.wants.error)
+			if tt.wants.error == nil {
+				assert.Equal(t, tt.wants.status, res.Status)
+			}
+		})
+	}
+}
+
+func TestWithdrawalService_NotFound(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.Background()
+	withdrawalRepository := mocks.NewMockWithdrawalRepository(mockCtrl)
+	target := NewWithdrawalService(withdrawalRepository, nil, log)
+
+	withdrawalRepository.EXPECT().Lock(ctx, 7).Return(nil, &models.NoRowFound)
+	_, err := target.ConfirmWithdrawal(ctx, 7)
+	assert.ErrorIs(t, err, domain.ErrWithdrawalNotFound)
+
+	_, err = target.GetWithdrawals(ctx, "UNKNOWN")
+	assert.ErrorIs(t, err, domain.ErrBadParam)
+}
@@ -0,0 +1,65 @@
+package statement
+
+import (
+	"encoding/csv"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVWriter writes the header fields, a table of operations and the totals, one record per line.
+// Debits have negative sums.
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) Writer {
+	var target CSVWriter
+	target.w = csv.NewWriter(w)
+	return &target
+}
+
+func (c *CSVWriter) Begin(h domain.StatementHeader) error {
+	records := [][]string{
+		{"period_from", formatTime(h.From, time.RFC3339, "")},
+		{"period_to", formatTime(h.To, time.RFC3339, "")},
+		{"generated_at", formatTime(h.GeneratedAt, time.RFC3339, "")},
+		{"opening_balance", h.OpeningBalance.String()},
+		{"processed_at", "type", "operation_type", "order", "description", "sum", "balance"},
+	}
+	for _, r := range records {
+		if err := c.w.Write(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CSVWriter) Operation(op domain.HistoryOperation) error {
+	return c.w.Write([]string{
+		formatTime(op.ProcessedAt, time.RFC3339, ""),
+		op.Type,
+		op.OperationType,
+		op.OrderNum,
+		op.Description,
+		signedAmount(op).String(),
+		op.Balance.String(),
+	})
+}
+
+func (c *CSVWriter) End(t domain.StatementTotals) error {
+	records := [][]string{
+		{"operations", strconv.Itoa(t.Operations)},
+		{"total_credit", t.Credit.String()},
+		{"total_debit", t.Debit.String()},
+		{"closing_balance", t.ClosingBalance.String()},
+	}
+	for _, r := range records {
+		if err := c.w.Write(r); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
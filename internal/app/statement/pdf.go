@@ -0,0 +1,180 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A4 page with the built-in Courier font, which needs no embedding but has no Cyrillic glyphs,
+// so the PDF statement is in English and non-ASCII characters are replaced.
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 40
+	pdfFontSize     = 9
+	pdfLineHeight   = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	pdfLineWidth    = 94
+
+	pdfTimeLayout = "2006-01-02 15:04:05"
+)
+
+// Object numbers fixed by the writer, page objects follow them.
+const (
+	pdfCatalogObj = 1
+	pdfPagesObj   = 2
+	pdfFontObj    = 3
+)
+
+const pdfRowFormat = "%-19s  %-10s  %-6s  %-20s  %14s  %14s"
+
+// PDFWriter writes every page as soon as it is full, only the object offsets are kept until the end.
+type PDFWriter struct {
+	w       *countingWriter
+	page    bytes.Buffer
+	lines   int
+	offsets []int64
+	pages   []int
+	err     error
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+func NewPDFWriter(w io.Writer) Writer {
+	var target PDFWriter
+	target.w = &countingWriter{w: w}
+	// object 0 is the head of the free list
+	target.offsets = make([]int64, pdfFontObj+1)
+	return &target
+}
+
+func (p *PDFWriter) Begin(h domain.StatementHeader) error {
+	p.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	p.object(pdfCatalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObj))
+	p.object(pdfFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	p.line("Loyalty account statement")
+	p.line("")
+	p.line("Period:          " + formatTime(h.From, pdfTimeLayout, "beginning") + " - " + formatTime(h.To, pdfTimeLayout, "now") + " UTC")
+	p.line("Generated:       " + formatTime(h.GeneratedAt, pdfTimeLayout, "") + " UTC")
+	p.line("Opening balance: " + h.OpeningBalance.String())
+	p.line("")
+	p.columns()
+	return p.err
+}
+
+func (p *PDFWriter) Operation(op domain.HistoryOperation) error {
+	p.line(fmt.Sprintf(pdfRowFormat, formatTime(op.ProcessedAt, pdfTimeLayout, ""), op.Type, op.OperationType, op.OrderNum,
+		signedAmount(op).String(), op.Balance.String()))
+	if op.Description != "" {
+		p.line("    " + op.Description)
+	}
+	return p.err
+}
+
+func (p *PDFWriter) End(t domain.StatementTotals) error {
+	p.line("")
+	p.line("Operations:      " + strconv.Itoa(t.Operations))
+	p.line("Total credit:    " + t.Credit.String())
+	p.line("Total debit:     " + t.Debit.String())
+	p.line("Closing balance: " + t.ClosingBalance.String())
+	p.flushPage()
+
+	kids := make([]string, 0, len(p.pages))
+	for _, n := range p.pages {
+		kids = append(kids, strconv.Itoa(n)+" 0 R")
+	}
+	p.object(pdfPagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+
+	xref := p.w.n
+	var b strings.Builder
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(p.offsets))
+	for _, offset := range p.offsets[1:] {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets), pdfCatalogObj, xref)
+	p.write(b.String())
+	return p.err
+}
+
+func (p *PDFWriter) columns() {
+	p.line(fmt.Sprintf(pdfRowFormat, "Date (UTC)", "Type", "Side", "Order", "Amount", "Balance"))
+	p.line(strings.Repeat("-", pdfLineWidth))
+}
+
+func (p *PDFWriter) line(s string) {
+	if utf8.RuneCountInString(s) > pdfLineWidth {
+		s = string([]rune(s)[:pdfLineWidth])
+	}
+	if p.lines == pdfLinesPerPage {
+		p.flushPage()
+		p.columns()
+	}
+	if p.lines == 0 {
+		fmt.Fprintf(&p.page, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+	}
+	p.page.WriteString("(" + pdfEscape(s) + ") Tj T*\n")
+	p.lines++
+}
+
+// flushPage writes the content stream and the page object of the current page.
+func (p *PDFWriter) flushPage() {
+	if p.lines == 0 {
+		return
+	}
+	p.page.WriteString("ET")
+	content := p.newObject()
+	p.object(content, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", p.page.Len(), p.page.String()))
+	page := p.newObject()
+	p.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObj, pdfPageWidth, pdfPageHeight, pdfFontObj, content))
+	p.pages = append(p.pages, page)
+	p.page.Reset()
+	p.lines = 0
+}
+
+func (p *PDFWriter) newObject() int {
+	p.offsets = append(p.offsets, 0)
+	return len(p.offsets) - 1
+}
+
+func (p *PDFWriter) object(n int, body string) {
+	p.offsets[n] = p.w.n
+	p.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", n, body))
+}
+
+func (p *PDFWriter) write(s string) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = io.WriteString(p.w, s)
+}
+
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > '~':
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+// Package statement renders balance statements. Writers get operations one by one and write them out right away,
+// so a statement of any length is never kept in memory.
+package statement
+
+import (
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"io"
+	"time"
+)
+
+// Writer renders a statement: Begin, operations oldest first, End.
+type Writer interface {
+	Begin(h domain.StatementHeader) error
+	Operation(op domain.HistoryOperation) error
+	End(t domain.StatementTotals) error
+}
+
+type Format struct {
+	ContentType string
+	Extension   string
+	NewWriter   func(w io.Writer) Writer
+}
+
+var formats = map[string]Format{
+	"csv": {ContentType: "text/csv; charset=utf-8", Extension: "csv", NewWriter: NewCSVWriter},
+	"pdf": {ContentType: "application/pdf", Extension: "pdf", NewWriter: NewPDFWriter},
+}
+
+// GetFormat returns the format by its name, csv or pdf.
+func GetFormat(name string) (Format, bool) {
+	f, ok := formats[name]
+	return f, ok
+}
+
+// signedAmount shows debits as negative amounts.
+func signedAmount(op domain.HistoryOperation) money.Amount {
+	if op.OperationType == models.OperationDebit {
+		return -op.Amount
+	}
+	return op.Amount
+}
+
+func formatTime(t time.Time, layout string, zero string) string {
+	if t.IsZero() {
+		return zero
+	}
+	return t.UTC().Format(layout)
+}
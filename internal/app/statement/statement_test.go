@@ -0,0 +1,95 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/da-semenov/gophermart/internal/app/domain"
+	"github.com/da-semenov/gophermart/internal/app/models"
+	"github.com/da-semenov/gophermart/internal/app/money"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	header = domain.StatementHeader{
+		From:           time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: money.Rubles(100, 0),
+		GeneratedAt:    time.Date(2021, 7, 2, 10, 0, 0, 0, time.UTC),
+	}
+	operations = []domain.HistoryOperation{
+		{ID: 1, Type: models.EntryAccrual, OperationType: models.OperationCredit, OrderNum: "12345678903", Amount: money.Rubles(50, 50),
+			Balance: money.Rubles(150, 50), ProcessedAt: time.Date(2021, 6, 10, 8, 0, 0, 0, time.UTC)},
+		{ID: 2, Type: models.EntryClawback, OperationType: models.OperationDebit, OrderNum: "12345678903", Description: "возврат (частичный)",
+			Amount: money.Rubles(20, 0), Balance: money.Rubles(130, 50), ProcessedAt: time.Date(2021, 6, 12, 9, 30, 0, 0, time.UTC)},
+	}
+	totals = domain.StatementTotals{Operations: 2, Credit: money.Rubles(50, 50), Debit: money.Rubles(20, 0), ClosingBalance: money.Rubles(130, 50)}
+)
+
+func writeStatement(t *testing.T, w Writer, ops []domain.HistoryOperation) {
+	assert.NoError(t, w.Begin(header))
+	for _, op := range ops {
+		assert.NoError(t, w.Operation(op))
+	}
+	assert.NoError(t, w.End(totals))
+}
+
+func TestCSVWriter(t *testing.T) {
+	var b bytes.Buffer
+	writeStatement(t, NewCSVWriter(&b), operations)
+	assert.Equal(t, "period_from,2021-06-01T00:00:00Z\n"+
+		"period_to,2021-07-01T00:00:00Z\n"+
+		"generated_at,2021-07-02T10:00:00Z\n"+
+		"opening_balance,100\n"+
+		"processed_at,type,operation_type,order,description,sum,balance\n"+
+		"2021-06-10T08:00:00Z,ACCRUAL,CREDIT,12345678903,,50.5,150.5\n"+
+		"2021-06-12T09:30:00Z,CLAWBACK,DEBIT,12345678903,возврат (частичный),-20,130.5\n"+
+		"operations,2\n"+
+		"total_credit,50.5\n"+
+		"total_debit,20\n"+
+		"closing_balance,130.5\n", b.String())
+}
+
+func TestPDFWriter(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations int
+		pages      int
+	}{
+		{name: "PDFWriter. Test 1. Empty statement", operations: 0, pages: 1},
+		{name: "PDFWriter. Test 2. One page", operations: 2, pages: 1},
+		{name: "PDFWriter. Test 3. Many pages", operations: 200, pages: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []domain.HistoryOperation
+			for i := 0; i < tt.operations; i++ {
+				ops = append(ops, operations[i%len(operations)])
+			}
+			var b bytes.Buffer
+			writeStatement(t, NewPDFWriter(&b), ops)
+			doc := b.String()
+
+			assert.True(t, strings.HasPrefix(doc, "%PDF-1.4\n"))
+			assert.True(t, strings.HasSuffix(doc, "%%EOF\n"))
+			assert.Contains(t, doc, fmt.Sprintf("/Count %d", tt.pages))
+			assert.Contains(t, doc, "(Closing balance: 130.5) Tj")
+			assert.NotContains(t, doc, "возврат", "non-ASCII text must be replaced")
+
+			startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(doc)
+			assert.Len(t, startxref, 2)
+			xref, _ := strconv.Atoi(startxref[1])
+			assert.True(t, strings.HasPrefix(doc[xref:], "xref\n"), "startxref must point to the xref table")
+			entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllStringSubmatch(doc[xref:], -1)
+			assert.Len(t, entries, 3+2*tt.pages)
+			for i, e := range entries {
+				offset, _ := strconv.Atoi(e[1])
+				assert.True(t, strings.HasPrefix(doc[offset:], fmt.Sprintf("%d 0 obj\n", i+1)), "object %d offset", i+1)
+			}
+		})
+	}
+}